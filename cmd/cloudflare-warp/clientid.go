@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"gopkg.in/urfave/cli.v2"
+)
+
+const clientIDFile = "client-id"
+
+// clientIDPath returns where the client ID is kept: next to the origin certificate, or in the
+// default config directory if there is none.
+func clientIDPath(c *cli.Context) (string, error) {
+	configDir := defaultConfigDirs[0]
+	if originCert := c.String("origincert"); originCert != "" {
+		configDir = filepath.Dir(originCert)
+	}
+	path, err := homedir.Expand(filepath.Join(configDir, clientIDFile))
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot resolve path %s", configDir)
+	}
+	return path, nil
+}
+
+// loadClientID reads the client ID saved at path, generating and saving one the first time, so
+// that the edge recognises the client across restarts.
+func loadClientID(path string) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err == nil {
+		clientID := strings.TrimSpace(string(content))
+		if _, err := hex.DecodeString(clientID); err != nil || clientID == "" {
+			return "", fmt.Errorf("%s doesn't contain a client ID, run cloudflare-warp id rotate to replace it", path)
+		}
+		return clientID, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	return saveNewClientID(path)
+}
+
+// saveNewClientID generates a client ID and saves it at path, replacing the previous one.
+func saveNewClientID(path string) (string, error) {
+	clientID, err := generateClientID()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), clientIDFile)
+	if err != nil {
+		return "", err
+	}
+	_, err = tmpFile.WriteString(clientID + "\n")
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return "", err
+	}
+	return clientID, nil
+}
+
+func generateClientID() (string, error) {
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.Wrap(err, "Cannot generate a client ID")
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// rotateClientID replaces the saved client ID. The edge sees the next start as a new client.
+func rotateClientID(c *cli.Context) error {
+	path, err := clientIDPath(c)
+	if err != nil {
+		return err
+	}
+	clientID, err := saveNewClientID(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saved the new client ID %s to %s\n", clientID, path)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientIDFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config", clientIDFile)
+	clientID, err := loadClientID(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clientID) != 64 {
+		t.Fatalf("unexpected client ID %s", clientID)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Fatalf("client ID is readable by other users: %s", info.Mode())
+	}
+	// the same ID is used on the next start
+	if loaded, err := loadClientID(path); err != nil || loaded != clientID {
+		t.Fatalf("expected client ID %s, got %s (%v)", clientID, loaded, err)
+	}
+	rotated, err := saveNewClientID(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := loadClientID(path); err != nil || loaded != rotated || rotated == clientID {
+		t.Fatalf("expected rotated client ID %s, got %s (%v)", rotated, loaded, err)
+	}
+}
+
+func TestInvalidClientIDFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clientid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, clientIDFile)
+	if err := ioutil.WriteFile(path, []byte("not an id\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadClientID(path); err == nil {
+		t.Fatal("loaded an invalid client ID")
+	}
+}
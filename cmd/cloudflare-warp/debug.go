@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+)
+
+// replay feeds a frame capture into a new muxer, logging everything the muxer does.
+func replay(c *cli.Context) error {
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("expected the path of a capture")
+	}
+	capture, err := os.Open(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer capture.Close()
+	protoLogger := logrus.New()
+	protoLogger.Level = logrus.DebugLevel
+	config := h2mux.MuxerConfig{Name: "replay", Logger: protoLogger}
+	if c.IsSet("output") {
+		output, err := os.OpenFile(c.String("output"), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+		config.Capture = h2mux.NewFrameCapture(output, false)
+		defer config.Capture.Close()
+	}
+	if err := h2mux.Replay(capture, config, c.Bool("realtime")); err != nil {
+		Log.WithError(err).Error("Muxer failed during replay")
+		return err
+	}
+	Log.Info("Replay complete")
+	return nil
+}
@@ -2,10 +2,8 @@ package main
 
 import (
 	"crypto/tls"
-	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"net"
 	"net/http"
 	"os"
@@ -114,6 +112,32 @@ WARNING:
 			EnvVars: []string{"TUNNEL_EDGE"},
 			Hidden:  true,
 		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "edge-discovery",
+			Usage:   "Methods to find the Cloudflare tunnel servers, tried in order: system (the system DNS resolver), doh (DNS over HTTPS) or cache (the servers found last time).",
+			Value:   cli.NewStringSlice("system", "doh", "cache"),
+			EnvVars: []string{"TUNNEL_EDGE_DISCOVERY"},
+			Hidden:  true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "edge-ip-version",
+			Usage:   "IP version of the Cloudflare tunnel servers to connect to: 4, 6, or auto to prefer the version that works.",
+			Value:   "auto",
+			EnvVars: []string{"TUNNEL_EDGE_IP_VERSION"},
+			Hidden:  true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "edge-cache-file",
+			Usage:   "File to save the Cloudflare tunnel servers to, for the cache discovery method. Defaults to edge-addrs.json in the config directory.",
+			EnvVars: []string{"TUNNEL_EDGE_CACHE_FILE"},
+			Hidden:  true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "state-file",
+			Usage:   "File to remember the health of the Cloudflare tunnel servers, the registrations and the reconnects in, across restarts. Defaults to state.json in the config directory.",
+			EnvVars: []string{"TUNNEL_STATE_FILE"},
+			Hidden:  true,
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "cacert",
 			Usage:   "Certificate Authority authenticating the Cloudflare tunnel connection.",
@@ -126,6 +150,11 @@ WARNING:
 			EnvVars: []string{"TUNNEL_ORIGIN_CERT"},
 			Value:   findDefaultOriginCertPath(),
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "credentials-file",
+			Usage:   "Register the hostname with the credentials of a named tunnel, written to `FILE` by cloudflare-warp tunnel create, instead of the origin certificate.",
+			EnvVars: []string{"TUNNEL_CRED_FILE"},
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "url",
 			Value:   "https://localhost:8080",
@@ -137,6 +166,11 @@ WARNING:
 			Usage:   "Set a hostname on a Cloudflare zone to route traffic through this tunnel.",
 			EnvVars: []string{"TUNNEL_HOSTNAME"},
 		}),
+		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+			Name:    "additional-hostname",
+			Usage:   "Also route traffic for `HOSTNAME` through this tunnel, on the same connections as --hostname. May be specified multiple times.",
+			EnvVars: []string{"TUNNEL_ADDITIONAL_HOSTNAME"},
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "origin-server-name",
 			Usage:   "Hostname on the origin server certificate.",
@@ -144,7 +178,7 @@ WARNING:
 		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "id",
-			Usage:   "A unique identifier used to tie connections to this tunnel instance.",
+			Usage:   "A unique identifier used to tie connections to this tunnel instance. Defaults to the ID saved in the config directory, generated on first run.",
 			EnvVars: []string{"TUNNEL_ID"},
 			Hidden:  true,
 		}),
@@ -200,6 +234,56 @@ WARNING:
 			Value:  5,
 			Hidden: true,
 		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:   "dead-peer-detection",
+			Usage:  "Retry unacked heartbeats based on the measured round-trip time, to detect a dead connection sooner.",
+			Hidden: true,
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "protocol",
+			Value:   origin.ProtocolH2Mux,
+			Usage:   "Protocol used to connect to the Cloudflare edge {h2mux, quic}",
+			EnvVars: []string{"TUNNEL_PROTOCOL"},
+		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "capture-dir",
+			Usage:   "Write a capture of the frames of each connection to the edge to this directory, for debug replay. Only supported with the h2mux protocol.",
+			EnvVars: []string{"TUNNEL_CAPTURE_DIR"},
+			Hidden:  true,
+		}),
+		altsrc.NewBoolFlag(&cli.BoolFlag{
+			Name:    "capture-redact",
+			Value:   true,
+			Usage:   "Replace header values and request and response bodies in frame captures",
+			EnvVars: []string{"TUNNEL_CAPTURE_REDACT"},
+			Hidden:  true,
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "stream-idle-timeout",
+			Usage:   "Reset requests that send or receive no data for this long. 0 disables the timeout.",
+			EnvVars: []string{"TUNNEL_STREAM_IDLE_TIMEOUT"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "stream-max-lifetime",
+			Usage:   "Reset requests that stay open for longer than this. 0 disables the limit.",
+			EnvVars: []string{"TUNNEL_STREAM_MAX_LIFETIME"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "websocket-idle-timeout",
+			Usage:   "Like stream-idle-timeout, for WebSocket connections. 0 exempts WebSockets from the timeout.",
+			EnvVars: []string{"TUNNEL_WEBSOCKET_IDLE_TIMEOUT"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "websocket-max-lifetime",
+			Usage:   "Like stream-max-lifetime, for WebSocket connections. 0 exempts WebSockets from the limit.",
+			EnvVars: []string{"TUNNEL_WEBSOCKET_MAX_LIFETIME"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "drain-overlap",
+			Usage:   "When a Cloudflare tunnel server goes away, keep serving the requests in flight on its connection for up to this long, while a new connection replaces it. 0 resets them right away.",
+			Value:   time.Second * 30,
+			EnvVars: []string{"TUNNEL_DRAIN_OVERLAP"},
+		}),
 		altsrc.NewStringFlag(&cli.StringFlag{
 			Name:    "loglevel",
 			Value:   "info",
@@ -218,6 +302,17 @@ WARNING:
 			Usage:   "Maximum number of retries for connection/protocol errors.",
 			EnvVars: []string{"TUNNEL_RETRIES"},
 		}),
+		altsrc.NewStringFlag(&cli.StringFlag{
+			Name:    "backoff-policy",
+			Value:   "exponential",
+			Usage:   "How to spread retries after connection errors: exponential, full-jitter or decorrelated-jitter.",
+			EnvVars: []string{"TUNNEL_BACKOFF_POLICY"},
+		}),
+		altsrc.NewDurationFlag(&cli.DurationFlag{
+			Name:    "backoff-max",
+			Usage:   "Longest wait between retries after connection errors, if set.",
+			EnvVars: []string{"TUNNEL_BACKOFF_MAX"},
+		}),
 		altsrc.NewBoolFlag(&cli.BoolFlag{
 			Name:    "hello-world",
 			Value:   false,
@@ -239,6 +334,39 @@ WARNING:
 			Value:  4,
 			Hidden: true,
 		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "ha-min-locations",
+			Value:   0,
+			Usage:   "Reconnect HA connections that share an edge location until they reach this many distinct locations, when possible. 0 or 1 leaves the connections where they are.",
+			EnvVars: []string{"TUNNEL_HA_MIN_LOCATIONS"},
+			Hidden:  true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "ha-min-connections",
+			Usage:   "Fewest HA connections to keep when they are idle. Defaults to ha-connections.",
+			EnvVars: []string{"TUNNEL_HA_MIN_CONNECTIONS"},
+			Hidden:  true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "ha-max-connections",
+			Usage:   "Most HA connections to open when they are busy. Defaults to ha-connections.",
+			EnvVars: []string{"TUNNEL_HA_MAX_CONNECTIONS"},
+			Hidden:  true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "ha-scale-up-requests",
+			Value:   100,
+			Usage:   "Add an HA connection while the connections proxy more concurrent requests each than this on average.",
+			EnvVars: []string{"TUNNEL_HA_SCALE_UP_REQUESTS"},
+			Hidden:  true,
+		}),
+		altsrc.NewIntFlag(&cli.IntFlag{
+			Name:    "ha-scale-up-request-rate",
+			Value:   0,
+			Usage:   "Add an HA connection while the connections start more requests per second each than this on average. 0 only counts concurrent requests.",
+			EnvVars: []string{"TUNNEL_HA_SCALE_UP_REQUEST_RATE"},
+			Hidden:  true,
+		}),
 		altsrc.NewDurationFlag(&cli.DurationFlag{
 			Name:  "proxy-connect-timeout",
 			Usage: "HTTP proxy timeout for establishing a new connection",
@@ -288,7 +416,7 @@ WARNING:
 		altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
 			Name:    "proxy-dns-upstream",
 			Usage:   "Upstream endpoint URL, you can specify multiple endpoints for redundancy.",
-			Value:   cli.NewStringSlice("https://dns.cloudflare.com/.well-known/dns-query"),
+			Value:   cli.NewStringSlice(tunneldns.DefaultUpstream),
 			EnvVars: []string{"TUNNEL_DNS_UPSTREAM"},
 		}),
 	}
@@ -349,6 +477,86 @@ WARNING:
 			},
 			ArgsUsage: " ", // can't be the empty string or we get the default output
 		},
+		{
+			Name:  "tunnel",
+			Usage: "Manage named tunnels, whose credentials only authorize their own hostnames",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "create",
+					Action:    createTunnel,
+					Usage:     "Create a named tunnel and save its credentials",
+					ArgsUsage: "NAME",
+					Flags: []cli.Flag{
+						&cli.StringSliceFlag{
+							Name:  "hostname",
+							Usage: "Allow the tunnel to route traffic for `HOSTNAME`. May be specified multiple times.",
+						},
+						&cli.StringFlag{
+							Name:  "credentials-file",
+							Usage: "Save the credentials to `FILE` instead of ~/.cloudflare-warp/TUNNEL_ID.json.",
+						},
+					},
+				},
+				{
+					Name:      "list",
+					Action:    listTunnels,
+					Usage:     "List the named tunnels of the zone of your origin certificate",
+					ArgsUsage: " ",
+				},
+				{
+					Name:      "delete",
+					Action:    deleteTunnel,
+					Usage:     "Delete a named tunnel, revoking its credentials",
+					ArgsUsage: "TUNNEL_ID",
+				},
+			},
+		},
+		{
+			Name:  "id",
+			Usage: "Manage the ID that identifies this client to the edge across restarts",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "rotate",
+					Action:    rotateClientID,
+					Usage:     "Replace the saved client ID with a new one",
+					ArgsUsage: " ",
+				},
+			},
+		},
+		{
+			Name:      "status",
+			Action:    status,
+			Usage:     "Show the state the agent remembers across restarts",
+			ArgsUsage: " ",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Read the state file, without asking a running agent.",
+				},
+			},
+		},
+		{
+			Name:  "debug",
+			Usage: "Tools for debugging tunnel connections",
+			Subcommands: []*cli.Command{
+				{
+					Name:      "replay",
+					Action:    replay,
+					Usage:     "Replay a frame capture written with --capture-dir into a new muxer",
+					ArgsUsage: "CAPTURE",
+					Flags: []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "realtime",
+							Usage: "Keep the delays between frames in the capture.",
+						},
+						&cli.StringFlag{
+							Name:  "output",
+							Usage: "Write a capture of the frames of the replayed muxer to this file.",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:   "proxy-dns",
 			Action: tunneldns.Run,
@@ -375,7 +583,7 @@ WARNING:
 				&cli.StringSliceFlag{
 					Name:    "upstream",
 					Usage:   "Upstream endpoint URL, you can specify multiple endpoints for redundancy.",
-					Value:   cli.NewStringSlice("https://dns.cloudflare.com/.well-known/dns-query"),
+					Value:   cli.NewStringSlice(tunneldns.DefaultUpstream),
 					EnvVars: []string{"TUNNEL_DNS_UPSTREAM"},
 				},
 			},
@@ -425,13 +633,40 @@ func startServer(c *cli.Context) {
 		go autoupdate(c.Duration("autoupdate-freq"), shutdownC)
 	}
 
+	if _, err := origin.NewEdgeTransport(c.String("protocol"), nil); err != nil {
+		Log.WithError(err).Fatal("Invalid protocol")
+	}
+	if c.IsSet("capture-dir") && c.String("protocol") != origin.ProtocolH2Mux {
+		Log.Warnf("Frame capture is not supported with the %s protocol", c.String("protocol"))
+	}
+
 	hostname, err := validation.ValidateHostname(c.String("hostname"))
 	if err != nil {
 		Log.WithError(err).Fatal("Invalid hostname")
 	}
+	var hostnames []string
+	for _, additionalHostname := range c.StringSlice("additional-hostname") {
+		additionalHostname, err := validation.ValidateHostname(additionalHostname)
+		if err != nil {
+			Log.WithError(err).Fatal("Invalid additional hostname")
+		}
+		hostnames = append(hostnames, additionalHostname)
+	}
 	clientID := c.String("id")
 	if !c.IsSet("id") {
-		clientID = generateRandomClientID()
+		path, err := clientIDPath(c)
+		if err == nil {
+			clientID, err = loadClientID(path)
+		}
+		if err != nil {
+			// Such as in a read-only config directory. The edge then sees each start as a new
+			// client, but only id rotate has to save the client ID.
+			Log.WithError(err).Warn("Cannot load the client ID, using a new one until the next start")
+			clientID, err = generateClientID()
+		}
+		if err != nil {
+			Log.WithError(err).Fatal("Cannot generate a client ID")
+		}
 	}
 
 	tags, err := NewTagSliceFromCLI(c.StringSlice("tag"))
@@ -481,33 +716,57 @@ func startServer(c *cli.Context) {
 		Log.Fatal("You don't need to give us your api-key anymore. Please use the new log in method. Just run cloudflare-warp login")
 	}
 
-	// Check that the user has acquired a certificate using the log in command
-	originCertPath, err := homedir.Expand(c.String("origincert"))
+	// Named tunnels authenticate with their own credentials, which only authorize their hostnames
+	var originCert []byte
+	var credentials *tunnelpogs.TunnelCredentials
+	if c.IsSet("credentials-file") {
+		if len(hostnames) > 0 {
+			Log.Fatal("--additional-hostname is not supported with --credentials-file")
+		}
+		credentials, err = loadTunnelCredentials(c.String("credentials-file"))
+		if err != nil {
+			Log.WithError(err).Fatal("Cannot load tunnel credentials")
+		}
+	} else {
+		originCert, err = loadOriginCert(c)
+		if err != nil {
+			Log.Fatal(err)
+		}
+	}
+
+	discoveries, err := edgeDiscovery(c)
 	if err != nil {
-		Log.WithError(err).Fatalf("Cannot resolve path %s", c.String("origincert"))
+		Log.Fatal(err)
 	}
-	ok, err := fileExists(originCertPath)
+	edgeIPVersion, err := origin.ParseIPVersion(c.String("edge-ip-version"))
 	if err != nil {
-		Log.Fatalf("Cannot check if origin cert exists at path %s", c.String("origincert"))
+		Log.Fatal(err)
 	}
-	if !ok {
-		Log.Fatalf(`Cannot find a valid certificate for your origin at the path:
-
-    %s
-
-If the path above is wrong, specify the path with the -origincert option.
-If you don't have a certificate signed by Cloudflare, run the command:
-
-    %s login
-`, originCertPath, os.Args[0])
+	backoffPolicy, err := origin.ParseBackoffPolicy(c.String("backoff-policy"))
+	if err != nil {
+		Log.Fatal(err)
 	}
-	// Easier to send the certificate as []byte via RPC than decoding it at this point
-	originCert, err := ioutil.ReadFile(originCertPath)
+	statePath, err := stateFilePath(c)
+	if err != nil {
+		Log.Fatal(err)
+	}
+	stateFile, err := origin.LoadStateFile(statePath)
 	if err != nil {
-		Log.WithError(err).Fatalf("Cannot read %s to load origin certificate", originCertPath)
+		Log.WithError(err).Warnf("Cannot read the client state from %s, starting afresh", statePath)
 	}
 
 	tunnelMetrics := origin.NewTunnelMetrics()
+	connectionTracker := origin.NewConnectionTracker()
+	connectionTracker.Subscribe(func(status origin.ConnectionStatus) {
+		entry := Log.WithField("connection", status.Index)
+		if status.Addr != "" {
+			entry = entry.WithField("edge", status.Addr)
+		}
+		if status.LastError != nil {
+			entry = entry.WithError(status.LastError)
+		}
+		entry.Debugf("Connection is %s", status.State)
+	})
 	httpTransport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -527,26 +786,48 @@ If you don't have a certificate signed by Cloudflare, run the command:
 	}
 
 	tunnelConfig := &origin.TunnelConfig{
-		EdgeAddrs:         c.StringSlice("edge"),
-		OriginUrl:         url,
-		Hostname:          hostname,
-		OriginCert:        originCert,
-		TlsConfig:         tlsconfig.CreateTunnelConfig(c, c.StringSlice("edge")),
-		ClientTlsConfig:   httpTransport.TLSClientConfig,
-		Retries:           c.Uint("retries"),
-		HeartbeatInterval: c.Duration("heartbeat-interval"),
-		MaxHeartbeats:     c.Uint64("heartbeat-count"),
-		ClientID:          clientID,
-		ReportedVersion:   Version,
-		LBPool:            c.String("lb-pool"),
-		Tags:              tags,
-		HAConnections:     c.Int("ha-connections"),
-		HTTPTransport:     httpTransport,
-		Metrics:           tunnelMetrics,
-		MetricsUpdateFreq: c.Duration("metrics-update-freq"),
-		ProtocolLogger:    protoLogger,
-		Logger:            Log,
-		IsAutoupdated:     c.Bool("is-autoupdated"),
+		EdgeAddrs:            c.StringSlice("edge"),
+		EdgeDiscovery:        discoveries,
+		EdgeIPVersion:        edgeIPVersion,
+		OriginUrl:            url,
+		Hostname:             hostname,
+		Hostnames:            hostnames,
+		OriginCert:           originCert,
+		Credentials:          credentials,
+		TlsConfig:            tlsconfig.CreateTunnelConfig(c, c.StringSlice("edge")),
+		ClientTlsConfig:      httpTransport.TLSClientConfig,
+		Retries:              c.Uint("retries"),
+		BackoffPolicy:        backoffPolicy,
+		MaxBackoff:           c.Duration("backoff-max"),
+		HeartbeatInterval:    c.Duration("heartbeat-interval"),
+		MaxHeartbeats:        c.Uint64("heartbeat-count"),
+		DeadPeerDetection:    c.Bool("dead-peer-detection"),
+		Protocol:             c.String("protocol"),
+		CaptureDir:           c.String("capture-dir"),
+		RedactCapture:        c.Bool("capture-redact"),
+		StreamIdleTimeout:    c.Duration("stream-idle-timeout"),
+		StreamMaxLifetime:    c.Duration("stream-max-lifetime"),
+		WebSocketIdleTimeout: c.Duration("websocket-idle-timeout"),
+		WebSocketMaxLifetime: c.Duration("websocket-max-lifetime"),
+		DrainOverlap:         c.Duration("drain-overlap"),
+		ClientID:             clientID,
+		ReportedVersion:      Version,
+		LBPool:               c.String("lb-pool"),
+		Tags:                 tags,
+		HAConnections:        c.Int("ha-connections"),
+		HAMinLocations:       c.Int("ha-min-locations"),
+		HAMinConnections:     c.Int("ha-min-connections"),
+		HAMaxConnections:     c.Int("ha-max-connections"),
+		HAScaleUpRequests:    c.Int("ha-scale-up-requests"),
+		HAScaleUpRequestRate: c.Int("ha-scale-up-request-rate"),
+		HTTPTransport:        httpTransport,
+		Metrics:              tunnelMetrics,
+		ConnectionTracker:    connectionTracker,
+		StateFile:            stateFile,
+		MetricsUpdateFreq:    c.Duration("metrics-update-freq"),
+		ProtocolLogger:       protoLogger,
+		Logger:               Log,
+		IsAutoupdated:        c.Bool("is-autoupdated"),
 	}
 	connectedSignal := make(chan struct{})
 
@@ -643,6 +924,85 @@ func updateApplied() bool {
 	return false
 }
 
+// loadOriginCert reads the certificate the user acquired using the log in command.
+func loadOriginCert(c *cli.Context) ([]byte, error) {
+	originCertPath, err := homedir.Expand(c.String("origincert"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot resolve path %s", c.String("origincert"))
+	}
+	ok, err := fileExists(originCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot check if origin cert exists at path %s", c.String("origincert"))
+	}
+	if !ok {
+		return nil, fmt.Errorf(`Cannot find a valid certificate for your origin at the path:
+
+    %s
+
+If the path above is wrong, specify the path with the -origincert option.
+If you don't have a certificate signed by Cloudflare, run the command:
+
+    %s login
+`, originCertPath, os.Args[0])
+	}
+	// Easier to send the certificate as []byte via RPC than decoding it at this point
+	originCert, err := ioutil.ReadFile(originCertPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Cannot read %s to load origin certificate", originCertPath)
+	}
+	return originCert, nil
+}
+
+// edgeDiscovery returns the methods to find the edge servers, in the order of --edge-discovery.
+func edgeDiscovery(c *cli.Context) ([]origin.EdgeDiscovery, error) {
+	var discoveries []origin.EdgeDiscovery
+	for _, method := range c.StringSlice("edge-discovery") {
+		switch method {
+		case "system":
+			discoveries = append(discoveries, origin.SystemDiscovery{})
+		case "doh":
+			discovery, err := origin.NewDoHDiscovery(tunneldns.DefaultUpstream, tunneldns.DefaultBootstrapAddress)
+			if err != nil {
+				return nil, err
+			}
+			discoveries = append(discoveries, discovery)
+		case "cache":
+			path, err := edgeCacheFilePath(c)
+			if err != nil {
+				return nil, err
+			}
+			discoveries = append(discoveries, &origin.CacheDiscovery{Path: path})
+		default:
+			return nil, fmt.Errorf("Unknown edge discovery method %s, expected system, doh or cache", method)
+		}
+	}
+	return discoveries, nil
+}
+
+func edgeCacheFilePath(c *cli.Context) (string, error) {
+	cacheFile := c.String("edge-cache-file")
+	if cacheFile == "" {
+		cacheFile = filepath.Join(defaultConfigDirs[0], "edge-addrs.json")
+	}
+	path, err := homedir.Expand(cacheFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot resolve path %s", cacheFile)
+	}
+	return path, nil
+}
+
+func stateFilePath(c *cli.Context) (string, error) {
+	stateFile := c.String("state-file")
+	if stateFile == "" {
+		stateFile = filepath.Join(defaultConfigDirs[0], "state.json")
+	}
+	path, err := homedir.Expand(stateFile)
+	if err != nil {
+		return "", errors.Wrapf(err, "Cannot resolve path %s", stateFile)
+	}
+	return path, nil
+}
+
 func fileExists(path string) (bool, error) {
 	f, err := os.Open(path)
 	if err != nil {
@@ -694,13 +1054,6 @@ func findInputSourceContext(context *cli.Context) (altsrc.InputSourceContext, er
 	return nil, nil
 }
 
-func generateRandomClientID() string {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	id := make([]byte, 32)
-	r.Read(id)
-	return hex.EncodeToString(id)
-}
-
 func writePidFile(waitForSignal chan struct{}, pidFile string) {
 	<-waitForSignal
 	daemon.SdNotify(false, "READY=1")
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cloudflare/cloudflare-warp/origin"
+	"github.com/cloudflare/cloudflare-warp/tlsconfig"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+	"github.com/cloudflare/cloudflare-warp/validation"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/urfave/cli.v2"
+)
+
+// namedTunnelTimeout bounds the commands that manage named tunnels.
+const namedTunnelTimeout = time.Minute
+
+// tunnelCredentialsFile is the content of the credentials file written by tunnel create. It should
+// be kept as secret as the origin certificate, but only authorizes the hostnames of its tunnel.
+type tunnelCredentialsFile struct {
+	TunnelID     string
+	TunnelName   string
+	TunnelSecret []byte
+}
+
+func createTunnel(c *cli.Context) error {
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("expected the name of the tunnel")
+	}
+	name := c.Args().First()
+	var hostnames []string
+	for _, hostname := range c.StringSlice("hostname") {
+		hostname, err := validation.ValidateHostname(hostname)
+		if err != nil {
+			return err
+		}
+		hostnames = append(hostnames, hostname)
+	}
+	if len(hostnames) == 0 {
+		return fmt.Errorf("specify the hostnames of the tunnel with --hostname")
+	}
+	// Create the credentials file first, so that a tunnel isn't created without a place to save
+	// its credentials.
+	file, err := createTunnelCredentialsFile(c.String("credentials-file"))
+	if err != nil {
+		return fmt.Errorf("Cannot create the credentials file: %s", err)
+	}
+	created := false
+	defer func() {
+		if !created {
+			file.Close()
+			os.Remove(file.Name())
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), namedTunnelTimeout)
+	defer cancel()
+	client, err := dialNamedTunnelClient(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	credentials, err := client.CreateTunnel(ctx, name, hostnames)
+	if err != nil {
+		return err
+	}
+	created = true
+	path := file.Name()
+	if err := writeTunnelCredentials(file, name, credentials); err != nil {
+		return fmt.Errorf("Created tunnel %s with ID %s, but cannot save its credentials to %s: %s", name, credentials.TunnelID, path, err)
+	}
+	if c.String("credentials-file") == "" {
+		// name the file after the tunnel, now that its ID is known
+		path = filepath.Join(filepath.Dir(file.Name()), credentials.TunnelID+".json")
+		if err := os.Link(file.Name(), path); err != nil {
+			return fmt.Errorf("Created tunnel %s with ID %s, but cannot move its credentials from %s to %s: %s", name, credentials.TunnelID, file.Name(), path, err)
+		}
+		os.Remove(file.Name())
+	}
+	fmt.Fprintf(os.Stderr, `Created tunnel %s with ID %s. Its credentials have been saved to:
+%s
+
+Run the tunnel with the option --credentials-file %s
+`, name, credentials.TunnelID, path, path)
+	return nil
+}
+
+func listTunnels(c *cli.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), namedTunnelTimeout)
+	defer cancel()
+	client, err := dialNamedTunnelClient(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	tunnels, err := client.ListTunnels(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tCREATED\tHOSTNAMES")
+	for _, tunnel := range tunnels {
+		created := time.Unix(tunnel.CreatedAt, 0).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", tunnel.TunnelID, tunnel.Name, created, strings.Join(tunnel.Hostnames, ","))
+	}
+	return w.Flush()
+}
+
+func deleteTunnel(c *cli.Context) error {
+	if c.NArg() != 1 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("expected the ID of the tunnel")
+	}
+	tunnelID := c.Args().First()
+	ctx, cancel := context.WithTimeout(context.Background(), namedTunnelTimeout)
+	defer cancel()
+	client, err := dialNamedTunnelClient(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.DeleteTunnel(ctx, tunnelID); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Deleted tunnel %s. Its credentials can't be used anymore.\n", tunnelID)
+	return nil
+}
+
+// dialNamedTunnelClient connects to the edge with the global options, authorized by the origin
+// certificate.
+func dialNamedTunnelClient(ctx context.Context, c *cli.Context) (*origin.NamedTunnelClient, error) {
+	originCert, err := loadOriginCert(c)
+	if err != nil {
+		return nil, err
+	}
+	discoveries, err := edgeDiscovery(c)
+	if err != nil {
+		return nil, err
+	}
+	edgeIPVersion, err := origin.ParseIPVersion(c.String("edge-ip-version"))
+	if err != nil {
+		return nil, err
+	}
+	protoLogger := logrus.New()
+	protoLogger.Level = logrus.WarnLevel
+	return origin.DialNamedTunnelClient(ctx, &origin.TunnelConfig{
+		EdgeAddrs:      c.StringSlice("edge"),
+		EdgeDiscovery:  discoveries,
+		EdgeIPVersion:  edgeIPVersion,
+		OriginCert:     originCert,
+		TlsConfig:      tlsconfig.CreateTunnelConfig(c, c.StringSlice("edge")),
+		Protocol:       c.String("protocol"),
+		ProtocolLogger: protoLogger,
+		Logger:         Log,
+	})
+}
+
+// createTunnelCredentialsFile creates the file to save the credentials of a new tunnel in, which only
+// the user can read. It doesn't overwrite existing files. If path is empty, a file with a temporary
+// name is created in the default config directory.
+func createTunnelCredentialsFile(path string) (*os.File, error) {
+	if path == "" {
+		dir, err := homedir.Expand(defaultConfigDirs[0])
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+		file, err := ioutil.TempFile(dir, "tunnel-credentials")
+		if err != nil {
+			return nil, err
+		}
+		if err := file.Chmod(0400); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return nil, err
+		}
+		return file, nil
+	}
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+}
+
+// writeTunnelCredentials saves the credentials of a new tunnel in a file created by
+// createTunnelCredentialsFile, and closes it.
+func writeTunnelCredentials(file *os.File, name string, credentials *tunnelpogs.TunnelCredentials) error {
+	content, err := json.Marshal(tunnelCredentialsFile{
+		TunnelID:     credentials.TunnelID,
+		TunnelName:   name,
+		TunnelSecret: credentials.Secret,
+	})
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadTunnelCredentials reads a credentials file written by tunnel create.
+func loadTunnelCredentials(path string) (*tunnelpogs.TunnelCredentials, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file tunnelCredentialsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("Cannot parse tunnel credentials in %s: %s", path, err)
+	}
+	if file.TunnelID == "" || len(file.TunnelSecret) == 0 {
+		return nil, fmt.Errorf("%s doesn't contain tunnel credentials", path)
+	}
+	return &tunnelpogs.TunnelCredentials{TunnelID: file.TunnelID, Secret: file.TunnelSecret}, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+)
+
+func TestTunnelCredentialsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config", "tunnel.json")
+	file, err := createTunnelCredentialsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := &tunnelpogs.TunnelCredentials{TunnelID: "id", Secret: []byte{1, 2, 3}}
+	if err := writeTunnelCredentials(file, "name", credentials); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		t.Fatalf("credentials are readable by other users: %s", info.Mode())
+	}
+	loaded, err := loadTunnelCredentials(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.TunnelID != "id" || !bytes.Equal(loaded.Secret, credentials.Secret) {
+		t.Fatalf("unexpected credentials %v", loaded)
+	}
+	// existing credentials aren't overwritten
+	if _, err := createTunnelCredentialsFile(path); err == nil {
+		t.Fatal("overwrote existing credentials")
+	}
+}
+
+func TestLoadInvalidTunnelCredentials(t *testing.T) {
+	file, err := ioutil.TempFile("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString(`{"TunnelID": "id"}`)
+	file.Close()
+	if _, err := loadTunnelCredentials(file.Name()); err == nil {
+		t.Fatal("loaded credentials without a secret")
+	}
+}
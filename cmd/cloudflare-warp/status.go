@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/cloudflare/cloudflare-warp/origin"
+)
+
+// status prints the state the agent remembers across restarts, and the edge addresses it found
+// last.
+func status(c *cli.Context) error {
+	if !c.Bool("offline") {
+		return fmt.Errorf("only the state file can be read for now, use --offline")
+	}
+	path, err := stateFilePath(c)
+	if err != nil {
+		return err
+	}
+	state, err := origin.ReadClientState(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no state file at %s, the agent hasn't run with it yet", path)
+	} else if err != nil {
+		return fmt.Errorf("cannot read the state file %s: %s", path, err)
+	}
+	return printClientState(os.Stdout, state, lastGoodEdges(c))
+}
+
+// lastGoodEdges returns the edge addresses saved by the cache discovery method, if any.
+func lastGoodEdges(c *cli.Context) []string {
+	path, err := edgeCacheFilePath(c)
+	if err != nil {
+		return nil
+	}
+	addrsByTarget, err := (&origin.CacheDiscovery{Path: path}).DiscoverEdge()
+	if err != nil {
+		return nil
+	}
+	var addrs []string
+	for _, addr := range origin.FlattenServiceIPs(addrsByTarget) {
+		addrs = append(addrs, addr.String())
+	}
+	return addrs
+}
+
+func printClientState(out io.Writer, state *origin.ClientState, lastGoodEdges []string) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Client ID:\t%s\n", state.ClientID)
+	fmt.Fprintf(w, "Updated:\t%s\n", formatTime(state.UpdatedAt))
+
+	fmt.Fprintln(w, "\nHOSTNAME\tREGISTERED AT")
+	hostnames := make([]string, 0, len(state.RegistrationURLs))
+	for hostname := range state.RegistrationURLs {
+		hostnames = append(hostnames, hostname)
+	}
+	sort.Strings(hostnames)
+	for _, hostname := range hostnames {
+		fmt.Fprintf(w, "%s\t%s\n", hostname, state.RegistrationURLs[hostname])
+	}
+
+	fmt.Fprintln(w, "\nEDGE\tFAILURES\tQUARANTINED UNTIL\tLATENCY\tLAST GOOD")
+	lastGood := map[string]bool{}
+	for _, addr := range lastGoodEdges {
+		lastGood[addr] = true
+	}
+	for _, edge := range state.Edges {
+		quarantinedUntil := "-"
+		if edge.QuarantinedUntil.After(time.Now()) {
+			quarantinedUntil = formatTime(edge.QuarantinedUntil)
+		}
+		latency := "-"
+		if edge.Latency > 0 {
+			latency = edge.Latency.String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%t\n", edge.Addr, edge.Failures, quarantinedUntil, latency, lastGood[edge.Addr])
+	}
+
+	fmt.Fprintln(w, "\nRECONNECTED\tCONNECTION\tEDGE\tERROR")
+	for _, reconnect := range state.Reconnects {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", formatTime(reconnect.Time), reconnect.Connection, reconnect.Addr, reconnect.Error)
+	}
+	return w.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,559 @@
+// Package edgetest runs an in-process stand-in for the Cloudflare edge, for end-to-end tests of the
+// tunnel client. It accepts TLS connections and tunnel registrations, proxies HTTP requests to the
+// connected origin, and can inject faults into the connection.
+package edgetest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+	"github.com/cloudflare/cloudflare-warp/tunnelrpc"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+
+	"github.com/sirupsen/logrus"
+	rpc "zombiezen.com/go/capnproto2/rpc"
+)
+
+// ServerName is the name on the certificate presented by the edge.
+const ServerName = "localhost"
+
+// Features are the optional features the edge supports, unless SetServerInfo changes them.
+var Features = []string{
+	tunnelpogs.FeatureUnregisterTunnel,
+	tunnelpogs.FeatureTunnelClient,
+	tunnelpogs.FeatureRegistrationErrorCodes,
+	tunnelpogs.FeatureServerHeartbeat,
+	tunnelpogs.FeatureNamedTunnels,
+}
+
+// Registration records a call to RegisterTunnel or RegisterNamedTunnel, or a hostname in a call to
+// RegisterTunnels.
+type Registration struct {
+	OriginCert []byte
+	// TunnelID is the named tunnel registered with RegisterNamedTunnel.
+	TunnelID string
+	Hostname string
+	Options  tunnelpogs.RegistrationOptions
+}
+
+// namedTunnel is a tunnel created with CreateTunnel.
+type namedTunnel struct {
+	info   tunnelpogs.NamedTunnel
+	secret []byte
+}
+
+// Server is a fake edge listening on the loopback interface.
+type Server struct {
+	listener  net.Listener
+	rootCAs   *x509.CertPool
+	logger    *logrus.Logger
+	connsChan chan *Conn
+
+	sync.Mutex
+	registration    tunnelpogs.TunnelRegistration
+	registrationErr error
+	serverInfo      tunnelpogs.ServerInfo
+	muxerConfig     h2mux.MuxerConfig
+	registrations   []Registration
+	unregistrations int
+	conns           []*Conn
+	closed          bool
+	// hostnameRegistrations override registration for some hostnames.
+	hostnameRegistrations map[string]tunnelpogs.TunnelRegistration
+	namedTunnels          []namedTunnel
+}
+
+// NewServer starts a fake edge. Registrations succeed until SetRegistration is called.
+func NewServer() (*Server, error) {
+	cert, rootCAs, err := newCertificate()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener:     listener,
+		rootCAs:      rootCAs,
+		logger:       logrus.New(),
+		connsChan:    make(chan *Conn, 16),
+		registration: tunnelpogs.TunnelRegistration{Url: "https://" + ServerName},
+		serverInfo:   tunnelpogs.ServerInfo{LocationName: "edgetest", SupportedFeatures: Features},
+		muxerConfig:  h2mux.MuxerConfig{Timeout: 5 * time.Second, Name: "edge"},
+	}
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the edge is listening on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// ClientTLSConfig returns a TLS configuration that trusts the edge.
+func (s *Server) ClientTLSConfig() *tls.Config {
+	return &tls.Config{RootCAs: s.rootCAs, ServerName: ServerName}
+}
+
+// SetRegistration sets the result of subsequent RegisterTunnel calls. If err is non-nil, the RPC
+// fails with it instead.
+func (s *Server) SetRegistration(registration tunnelpogs.TunnelRegistration, err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.registration = registration
+	s.registrationErr = err
+}
+
+// SetHostnameRegistration sets the result of subsequent registrations of a hostname, overriding
+// SetRegistration.
+func (s *Server) SetHostnameRegistration(hostname string, registration tunnelpogs.TunnelRegistration) {
+	s.Lock()
+	defer s.Unlock()
+	if s.hostnameRegistrations == nil {
+		s.hostnameRegistrations = make(map[string]tunnelpogs.TunnelRegistration)
+	}
+	s.hostnameRegistrations[hostname] = registration
+}
+
+// SetServerInfo sets the result of subsequent GetServerInfo calls.
+func (s *Server) SetServerInfo(serverInfo tunnelpogs.ServerInfo) {
+	s.Lock()
+	defer s.Unlock()
+	s.serverInfo = serverInfo
+}
+
+// SetMuxerConfig sets the configuration of the muxer used for new connections. Handler and IsClient
+// are ignored.
+func (s *Server) SetMuxerConfig(config h2mux.MuxerConfig) {
+	s.Lock()
+	defer s.Unlock()
+	s.muxerConfig = config
+}
+
+// Registrations returns the registrations received so far, on any connection.
+func (s *Server) Registrations() []Registration {
+	s.Lock()
+	defer s.Unlock()
+	return append([]Registration(nil), s.registrations...)
+}
+
+// Unregistrations returns the number of calls to UnregisterTunnel so far, on any connection.
+func (s *Server) Unregistrations() int {
+	s.Lock()
+	defer s.Unlock()
+	return s.unregistrations
+}
+
+// Conns returns the connections accepted so far.
+func (s *Server) Conns() []*Conn {
+	s.Lock()
+	defer s.Unlock()
+	return append([]*Conn(nil), s.conns...)
+}
+
+// WaitForConn returns the next connection to complete the muxer handshake.
+func (s *Server) WaitForConn(ctx context.Context) (*Conn, error) {
+	select {
+	case conn := <-s.connsChan:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// WaitForRegistration blocks until n registrations have been received, and returns them.
+func (s *Server) WaitForRegistration(ctx context.Context, n int) ([]Registration, error) {
+	for {
+		if registrations := s.Registrations(); len(registrations) >= n {
+			return registrations, nil
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops accepting connections and drops the open ones.
+func (s *Server) Close() error {
+	s.Lock()
+	s.closed = true
+	conns := s.conns
+	s.Unlock()
+	err := s.listener.Close()
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		tlsConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handshake(tlsConn)
+	}
+}
+
+func (s *Server) handshake(tlsConn net.Conn) {
+	s.Lock()
+	config := s.muxerConfig
+	s.Unlock()
+	conn := &Conn{
+		server:    s,
+		tlsConn:   tlsConn,
+		writer:    &faultyWriter{Writer: tlsConn},
+		serveDone: make(chan struct{}),
+	}
+	config.Handler = h2mux.MuxedStreamFunc(conn.serveStream)
+	config.IsClient = false
+	if config.Logger == nil {
+		config.Logger = s.logger
+	}
+	muxer, err := h2mux.Handshake(conn.writer, tlsConn, config)
+	if err != nil {
+		s.logger.WithError(err).Debug("edgetest: handshake failed")
+		tlsConn.Close()
+		return
+	}
+	conn.muxer = muxer
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		tlsConn.Close()
+		return
+	}
+	s.conns = append(s.conns, conn)
+	s.Unlock()
+	go func() {
+		conn.serveErr = muxer.Serve()
+		close(conn.serveDone)
+	}()
+	s.connsChan <- conn
+}
+
+// RegisterTunnel implements tunnelpogs.TunnelServer.
+func (s *Server) RegisterTunnel(ctx context.Context, originCert []byte, hostname string, options *tunnelpogs.RegistrationOptions) (*tunnelpogs.TunnelRegistration, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.registrations = append(s.registrations, Registration{
+		OriginCert: originCert,
+		Hostname:   hostname,
+		Options:    *options,
+	})
+	if s.registrationErr != nil {
+		return nil, s.registrationErr
+	}
+	registration := s.registrationFor(hostname)
+	return &registration, nil
+}
+
+// RegisterTunnels implements tunnelpogs.TunnelServer.
+func (s *Server) RegisterTunnels(ctx context.Context, originCert []byte, hostnames []tunnelpogs.HostnameRegistration) ([]tunnelpogs.HostnameTunnelRegistration, error) {
+	s.Lock()
+	defer s.Unlock()
+	var registrations []tunnelpogs.HostnameTunnelRegistration
+	for _, hostname := range hostnames {
+		s.registrations = append(s.registrations, Registration{
+			OriginCert: originCert,
+			Hostname:   hostname.Hostname,
+			Options:    hostname.Options,
+		})
+		registrations = append(registrations, tunnelpogs.HostnameTunnelRegistration{
+			Hostname:     hostname.Hostname,
+			Registration: s.registrationFor(hostname.Hostname),
+		})
+	}
+	if s.registrationErr != nil {
+		return nil, s.registrationErr
+	}
+	return registrations, nil
+}
+
+// UnregisterTunnel implements tunnelpogs.TunnelServer.
+func (s *Server) UnregisterTunnel(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+	s.unregistrations++
+	return nil
+}
+
+// RegisterNamedTunnel implements tunnelpogs.TunnelServer. Registration fails unless the credentials
+// are those of a tunnel created with CreateTunnel for the hostname.
+func (s *Server) RegisterNamedTunnel(ctx context.Context, credentials *tunnelpogs.TunnelCredentials, hostname string, options *tunnelpogs.RegistrationOptions) (*tunnelpogs.TunnelRegistration, error) {
+	s.Lock()
+	defer s.Unlock()
+	s.registrations = append(s.registrations, Registration{
+		TunnelID: credentials.TunnelID,
+		Hostname: hostname,
+		Options:  *options,
+	})
+	if s.registrationErr != nil {
+		return nil, s.registrationErr
+	}
+	for _, tunnel := range s.namedTunnels {
+		if tunnel.info.TunnelID != credentials.TunnelID || !bytes.Equal(tunnel.secret, credentials.Secret) {
+			continue
+		}
+		for _, tunnelHostname := range tunnel.info.Hostnames {
+			if tunnelHostname == hostname {
+				registration := s.registrationFor(hostname)
+				return &registration, nil
+			}
+		}
+	}
+	return &tunnelpogs.TunnelRegistration{
+		Err:              "the credentials don't authorize " + hostname,
+		PermanentFailure: true,
+		ErrorCode:        tunnelrpc.RegistrationErrorCode_unauthorized,
+	}, nil
+}
+
+// CreateTunnel implements tunnelpogs.TunnelServer. Any origin certificate is accepted.
+func (s *Server) CreateTunnel(ctx context.Context, originCert []byte, name string, hostnames []string) (*tunnelpogs.TunnelCredentials, error) {
+	id := make([]byte, 16)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	s.Lock()
+	defer s.Unlock()
+	tunnel := namedTunnel{
+		info: tunnelpogs.NamedTunnel{
+			TunnelID:  hex.EncodeToString(id),
+			Name:      name,
+			Hostnames: hostnames,
+			CreatedAt: time.Now().Unix(),
+		},
+		secret: secret,
+	}
+	s.namedTunnels = append(s.namedTunnels, tunnel)
+	return &tunnelpogs.TunnelCredentials{TunnelID: tunnel.info.TunnelID, Secret: secret}, nil
+}
+
+// ListTunnels implements tunnelpogs.TunnelServer.
+func (s *Server) ListTunnels(ctx context.Context, originCert []byte) ([]tunnelpogs.NamedTunnel, error) {
+	s.Lock()
+	defer s.Unlock()
+	var tunnels []tunnelpogs.NamedTunnel
+	for _, tunnel := range s.namedTunnels {
+		tunnels = append(tunnels, tunnel.info)
+	}
+	return tunnels, nil
+}
+
+// DeleteTunnel implements tunnelpogs.TunnelServer.
+func (s *Server) DeleteTunnel(ctx context.Context, originCert []byte, tunnelID string) error {
+	s.Lock()
+	defer s.Unlock()
+	for i, tunnel := range s.namedTunnels {
+		if tunnel.info.TunnelID == tunnelID {
+			s.namedTunnels = append(s.namedTunnels[:i], s.namedTunnels[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown tunnel %s", tunnelID)
+}
+
+// registrationFor returns the result of registering a hostname. Must be called with the lock held.
+func (s *Server) registrationFor(hostname string) tunnelpogs.TunnelRegistration {
+	if registration, ok := s.hostnameRegistrations[hostname]; ok {
+		return registration
+	}
+	return s.registration
+}
+
+// GetServerInfo implements tunnelpogs.TunnelServer.
+func (s *Server) GetServerInfo(ctx context.Context) (*tunnelpogs.ServerInfo, error) {
+	s.Lock()
+	defer s.Unlock()
+	serverInfo := s.serverInfo
+	return &serverInfo, nil
+}
+
+// Conn is a muxed connection from an origin.
+type Conn struct {
+	server    *Server
+	tlsConn   net.Conn
+	writer    *faultyWriter
+	muxer     *h2mux.Muxer
+	serveErr  error
+	serveDone chan struct{}
+
+	sync.Mutex
+	// rpcConn is the last RPC connection opened by the origin
+	rpcConn *rpc.Conn
+}
+
+// Muxer returns the edge end of the muxed connection.
+func (c *Conn) Muxer() *h2mux.Muxer {
+	return c.muxer
+}
+
+// RoundTrip sends an HTTP request to the origin over a new stream, like the edge does for eyeball
+// requests.
+func (c *Conn) RoundTrip(req *http.Request) (*http.Response, error) {
+	return h2mux.NewRoundTripper(c.muxer).RoundTrip(req)
+}
+
+// OpenStream opens a stream to the origin with the given headers. Faults can be injected by
+// resetting the stream.
+func (c *Conn) OpenStream(headers []h2mux.Header, body io.Reader) (*h2mux.MuxedStream, error) {
+	return c.muxer.OpenStream(headers, body)
+}
+
+// GoAway gracefully shuts down the connection, sending GOAWAY to the origin.
+func (c *Conn) GoAway() {
+	c.muxer.Shutdown()
+}
+
+// DropPings stops sending anything to the origin, including PING acknowledgements, as if the
+// network silently dropped every packet. The origin should give up on the connection once its
+// heartbeats go unanswered.
+func (c *Conn) DropPings() {
+	c.writer.blackhole()
+}
+
+// Close drops the connection without a GOAWAY.
+func (c *Conn) Close() error {
+	return c.tlsConn.Close()
+}
+
+// Wait blocks until the connection is closed, and returns the error from serving it.
+func (c *Conn) Wait(ctx context.Context) error {
+	select {
+	case <-c.serveDone:
+		return c.serveErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serveStream handles streams opened by the origin, which are RPC streams.
+func (c *Conn) serveStream(stream *h2mux.MuxedStream) error {
+	if !isRPCStreamRequest(stream.Headers) {
+		stream.Reset(http2.ErrCodeRefusedStream)
+		return nil
+	}
+	stream.WriteHeaders([]h2mux.Header{{Name: ":status", Value: "200"}})
+	main := tunnelpogs.TunnelServer_ServerToClient(c.server)
+	conn := rpc.NewConn(rpc.StreamTransport(stream), rpc.MainInterface(main.Client))
+	c.Lock()
+	c.rpcConn = conn
+	c.Unlock()
+	return conn.Wait()
+}
+
+// TunnelClient returns the commands exported by the origin on its last RPC connection, waiting for
+// the origin to open one. The client must not be closed, as that closes the RPC connection.
+func (c *Conn) TunnelClient(ctx context.Context) (tunnelpogs.TunnelClient_PogsClient, error) {
+	for {
+		c.Lock()
+		conn := c.rpcConn
+		c.Unlock()
+		if conn != nil {
+			return tunnelpogs.TunnelClient_PogsClient{Client: conn.Bootstrap(ctx), Conn: conn}, nil
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-ctx.Done():
+			return tunnelpogs.TunnelClient_PogsClient{}, ctx.Err()
+		}
+	}
+}
+
+func isRPCStreamRequest(headers []h2mux.Header) bool {
+	var method, scheme string
+	for _, header := range headers {
+		switch header.Name {
+		case ":method":
+			method = header.Value
+		case ":scheme":
+			scheme = header.Value
+		}
+	}
+	return method == "RPC" && scheme == "capnp"
+}
+
+// faultyWriter passes writes to the connection until blackholed, after which they are discarded.
+type faultyWriter struct {
+	io.Writer
+	sync.Mutex
+	blackholed bool
+}
+
+func (w *faultyWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	if w.blackholed {
+		return len(p), nil
+	}
+	return w.Writer.Write(p)
+}
+
+func (w *faultyWriter) Close() error {
+	if closer, ok := w.Writer.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *faultyWriter) blackhole() {
+	w.Lock()
+	defer w.Unlock()
+	w.blackholed = true
+}
+
+// newCertificate generates a self-signed certificate for ServerName, and a pool that trusts it.
+func newCertificate() (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: ServerName},
+		DNSNames:              []string{ServerName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, rootCAs, nil
+}
@@ -0,0 +1,653 @@
+package edgetest
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+	"github.com/cloudflare/cloudflare-warp/origin"
+	"github.com/cloudflare/cloudflare-warp/tunnelrpc"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+
+	"github.com/sirupsen/logrus"
+)
+
+var tunnelMetrics = origin.NewTunnelMetrics()
+
+func init() {
+	origin.Log = logrus.New()
+}
+
+// startTunnel connects a tunnel from originServer to the edge, registering hostnames in addition
+// to tunnel.example.com. It returns once the tunnel has connected, and the returned channel
+// receives the error from ServeTunnel.
+func startTunnel(t *testing.T, ctx context.Context, edge *Server, originServer *httptest.Server, hostnames ...string) (*Conn, <-chan error) {
+	config := newTunnelConfig(edge, originServer)
+	config.Hostnames = hostnames
+	return startTunnelWithConfig(t, ctx, edge, config)
+}
+
+func newTunnelConfig(edge *Server, originServer *httptest.Server) *origin.TunnelConfig {
+	return &origin.TunnelConfig{
+		OriginUrl:         originServer.URL,
+		Hostname:          "tunnel.example.com",
+		OriginCert:        []byte("origin cert"),
+		TlsConfig:         edge.ClientTLSConfig(),
+		HTTPTransport:     originServer.Client().Transport,
+		HeartbeatInterval: 5 * time.Second,
+		MaxHeartbeats:     1,
+		ClientID:          "edgetest",
+		Metrics:           tunnelMetrics,
+		MetricsUpdateFreq: time.Second,
+		ProtocolLogger:    logrus.New(),
+	}
+}
+
+func startTunnelWithConfig(t *testing.T, ctx context.Context, edge *Server, config *origin.TunnelConfig) (*Conn, <-chan error) {
+	addr, err := net.ResolveTCPAddr("tcp", edge.Addr())
+	if err != nil {
+		t.Fatalf("error resolving edge address: %s", err)
+	}
+	errC := make(chan error, 1)
+	go func() {
+		err, _ := origin.ServeTunnel(ctx, config, addr, 0, h2mux.NewBooleanFuse(), &origin.BackoffHandler{})
+		errC <- err
+	}()
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := edge.WaitForConn(waitCtx)
+	if err != nil {
+		t.Fatalf("timeout waiting for tunnel connection: %s", err)
+	}
+	return conn, errC
+}
+
+func newOrigin() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Host", r.Host)
+		w.Header().Set("X-Tag", r.Header.Get(origin.TagHeaderNamePrefix+"Test"))
+		w.Write([]byte(r.URL.Path))
+	}))
+}
+
+func TestRegistration(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+	edge.SetServerInfo(tunnelpogs.ServerInfo{LocationName: "LHR"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startTunnel(t, ctx, edge, originServer)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	registrations, err := edge.WaitForRegistration(waitCtx, 1)
+	if err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	if registrations[0].Hostname != "tunnel.example.com" {
+		t.Fatalf("unexpected hostname %s", registrations[0].Hostname)
+	}
+	if string(registrations[0].OriginCert) != "origin cert" {
+		t.Fatalf("unexpected origin cert %s", registrations[0].OriginCert)
+	}
+	if registrations[0].Options.ClientID != "edgetest" {
+		t.Fatalf("unexpected client ID %s", registrations[0].Options.ClientID)
+	}
+}
+
+func TestPermanentRegistrationFailure(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+	edge.SetRegistration(tunnelpogs.TunnelRegistration{Err: "not entitled", PermanentFailure: true}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errC := startTunnel(t, ctx, edge, originServer)
+	select {
+	case err := <-errC:
+		if err == nil || err.Error() != "Server error: not entitled" {
+			t.Fatalf("unexpected error from ServeTunnel: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to fail")
+	}
+}
+
+func TestOverloadedBackoff(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+	edge.SetRegistration(tunnelpogs.TunnelRegistration{
+		Err:               "overloaded",
+		ErrorCode:         tunnelrpc.RegistrationErrorCode_overloaded,
+		RetryAfterSeconds: 1,
+	}, nil)
+
+	config := newTunnelConfig(edge, originServer)
+	config.EdgeAddrs = []string{edge.Addr()}
+	config.Retries = 1
+	config.Logger = origin.Log
+	shutdownC := make(chan struct{})
+	daemonErrC := make(chan error, 1)
+	go func() {
+		daemonErrC <- origin.StartTunnelDaemon(config, shutdownC, make(chan struct{}))
+	}()
+	defer func() {
+		close(shutdownC)
+		select {
+		case <-daemonErrC:
+		case <-time.After(5 * time.Second):
+			t.Error("tunnel daemon did not stop")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := edge.WaitForRegistration(ctx, 1); err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	start := time.Now()
+	// the only edge is overloaded, so the tunnel waits as long as the edge asked before trying again
+	if _, err := edge.WaitForRegistration(ctx, 2); err != nil {
+		t.Fatalf("timeout waiting for the registration to be retried: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatalf("registration retried after %s", elapsed)
+	}
+}
+
+func TestMultipleHostnames(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+	edge.SetHostnameRegistration("b.example.com", tunnelpogs.TunnelRegistration{Err: "not entitled", PermanentFailure: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, errC := startTunnel(t, ctx, edge, originServer, "a.example.com", "b.example.com")
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	registrations, err := edge.WaitForRegistration(waitCtx, 3)
+	if err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	for i, hostname := range []string{"tunnel.example.com", "a.example.com", "b.example.com"} {
+		if registrations[i].Hostname != hostname {
+			t.Fatalf("expected registration of %s, got %s", hostname, registrations[i].Hostname)
+		}
+		if registrations[i].Options.ClientID != "edgetest" {
+			t.Fatalf("unexpected client ID %s", registrations[i].Options.ClientID)
+		}
+	}
+	// the tunnel stays up as some hostnames were registered
+	req, _ := http.NewRequest("GET", "https://a.example.com/hello", nil)
+	response, err := conn.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("error in RoundTrip: %s", err)
+	}
+	response.Body.Close()
+	select {
+	case err := <-errC:
+		t.Fatalf("tunnel closed: %v", err)
+	default:
+	}
+}
+
+func TestMultipleHostnamesFailure(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+	edge.SetRegistration(tunnelpogs.TunnelRegistration{Err: "not entitled", PermanentFailure: true}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errC := startTunnel(t, ctx, edge, originServer, "a.example.com")
+	select {
+	case err := <-errC:
+		if err == nil || err.Error() != "Server error: not entitled" {
+			t.Fatalf("unexpected error from ServeTunnel: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to fail")
+	}
+}
+
+func TestUnregisterOnShutdown(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errC := startTunnel(t, ctx, edge, originServer)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	if _, err := edge.WaitForRegistration(waitCtx, 1); err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	if edge.Unregistrations() != 0 {
+		t.Fatal("unregistered before shutdown")
+	}
+	cancel()
+	select {
+	case <-errC:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to shut down")
+	}
+	if edge.Unregistrations() != 1 {
+		t.Fatalf("expected 1 unregistration, got %d", edge.Unregistrations())
+	}
+}
+
+func TestNoUnregisterWithoutFeature(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	// Servers that predate feature negotiation don't list any features
+	edge.SetServerInfo(tunnelpogs.ServerInfo{LocationName: "edgetest"})
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, errC := startTunnel(t, ctx, edge, originServer)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	registrations, err := edge.WaitForRegistration(waitCtx, 1)
+	if err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	supported := false
+	for _, feature := range registrations[0].Options.Features {
+		supported = supported || feature == tunnelpogs.FeatureUnregisterTunnel
+	}
+	if !supported {
+		t.Fatalf("client didn't list %s in %v", tunnelpogs.FeatureUnregisterTunnel, registrations[0].Options.Features)
+	}
+	cancel()
+	select {
+	case <-errC:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to shut down")
+	}
+	if edge.Unregistrations() != 0 {
+		t.Fatalf("expected no unregistration, got %d", edge.Unregistrations())
+	}
+}
+
+func TestNamedTunnel(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := origin.DialNamedTunnelClient(ctx, &origin.TunnelConfig{
+		EdgeAddrs:      []string{edge.Addr()},
+		OriginCert:     []byte("origin cert"),
+		TlsConfig:      edge.ClientTLSConfig(),
+		ProtocolLogger: logrus.New(),
+		Logger:         logrus.New(),
+	})
+	if err != nil {
+		t.Fatalf("error connecting to the edge: %s", err)
+	}
+	defer client.Close()
+	credentials, err := client.CreateTunnel(ctx, "test", []string{"tunnel.example.com"})
+	if err != nil {
+		t.Fatalf("error creating tunnel: %s", err)
+	}
+	tunnels, err := client.ListTunnels(ctx)
+	if err != nil {
+		t.Fatalf("error listing tunnels: %s", err)
+	}
+	if len(tunnels) != 1 || tunnels[0].TunnelID != credentials.TunnelID || tunnels[0].Name != "test" {
+		t.Fatalf("unexpected tunnels %v", tunnels)
+	}
+
+	config := newTunnelConfig(edge, originServer)
+	config.OriginCert = nil
+	config.Credentials = credentials
+	_, errC := startTunnelWithConfig(t, ctx, edge, config)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	registrations, err := edge.WaitForRegistration(waitCtx, 1)
+	if err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	if registrations[0].TunnelID != credentials.TunnelID || len(registrations[0].OriginCert) != 0 {
+		t.Fatalf("unexpected registration %v", registrations[0])
+	}
+	select {
+	case err := <-errC:
+		t.Fatalf("tunnel failed: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The credentials don't authorize other hostnames
+	config = newTunnelConfig(edge, originServer)
+	config.Hostname = "other.example.com"
+	config.Credentials = credentials
+	_, errC = startTunnelWithConfig(t, ctx, edge, config)
+	select {
+	case err := <-errC:
+		if err == nil {
+			t.Fatal("registered a hostname the credentials don't authorize")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the registration to fail")
+	}
+
+	if err := client.DeleteTunnel(ctx, credentials.TunnelID); err != nil {
+		t.Fatalf("error deleting tunnel: %s", err)
+	}
+	if tunnels, err := client.ListTunnels(ctx); err != nil || len(tunnels) != 0 {
+		t.Fatalf("unexpected tunnels %v after deletion (%v)", tunnels, err)
+	}
+}
+
+func TestTunnelClientCommands(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, errC := startTunnel(t, ctx, edge, originServer)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	if _, err := edge.WaitForRegistration(waitCtx, 1); err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	client, err := conn.TunnelClient(waitCtx)
+	if err != nil {
+		t.Fatalf("error getting tunnel client: %s", err)
+	}
+
+	diagnostics, err := client.GetDiagnostics(waitCtx)
+	if err != nil {
+		t.Fatalf("error in GetDiagnostics: %s", err)
+	}
+	if diagnostics.ConnectionID != 0 || diagnostics.Goroutines == "" {
+		t.Fatalf("unexpected diagnostics %+v", diagnostics)
+	}
+
+	if err := client.SetTags(waitCtx, []tunnelpogs.Tag{{Name: "Test", Value: "pushed"}}); err != nil {
+		t.Fatalf("error in SetTags: %s", err)
+	}
+	req, _ := http.NewRequest("GET", "https://tunnel.example.com/hello", nil)
+	response, err := conn.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("error in RoundTrip: %s", err)
+	}
+	response.Body.Close()
+	if tag := response.Header.Get("X-Tag"); tag != "pushed" {
+		t.Fatalf("expected the pushed tag, got %#v", tag)
+	}
+
+	if err := client.SetLogLevel(waitCtx, "loud"); err == nil {
+		t.Fatal("expected an error setting an invalid log level")
+	}
+
+	if err := client.Reconnect(waitCtx, "maintenance"); err != nil {
+		t.Fatalf("error in Reconnect: %s", err)
+	}
+	select {
+	case err := <-errC:
+		if err == nil || !strings.Contains(err.Error(), "maintenance") {
+			t.Fatalf("unexpected error from ServeTunnel: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for the tunnel to reconnect")
+	}
+	if edge.Unregistrations() != 1 {
+		t.Fatalf("expected 1 unregistration, got %d", edge.Unregistrations())
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, _ := startTunnel(t, ctx, edge, originServer)
+	req, _ := http.NewRequest("GET", "https://tunnel.example.com/hello", nil)
+	response, err := conn.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("error in RoundTrip: %s", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status %d", response.StatusCode)
+	}
+	if host := response.Header.Get("X-Host"); host != "tunnel.example.com" {
+		t.Fatalf("origin received request for host %s", host)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %s", err)
+	}
+	if string(body) != "/hello" {
+		t.Fatalf("unexpected response body %s", body)
+	}
+}
+
+func TestResetReachesOrigin(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	cancelledC := make(chan struct{})
+	originServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// send the response headers, then wait for the edge to give up on the body
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(cancelledC)
+	}))
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, _ := startTunnel(t, ctx, edge, originServer)
+	req, _ := http.NewRequest("GET", "https://tunnel.example.com/slow", nil)
+	stream, err := conn.OpenStream(h2mux.H1RequestToH2Request(req), nil)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	stream.Reset(http2.ErrCodeCancel)
+	select {
+	case <-cancelledC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("origin request was not cancelled")
+	}
+}
+
+func TestGoAway(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, errC := startTunnel(t, ctx, edge, originServer)
+	waitCtx, waitCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer waitCancel()
+	if _, err := edge.WaitForRegistration(waitCtx, 1); err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	conn.GoAway()
+	select {
+	case <-errC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tunnel did not disconnect after GOAWAY")
+	}
+}
+
+func TestGoAwayReplacesConnection(t *testing.T) {
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	startedC := make(chan struct{})
+	finishC := make(chan struct{})
+	originServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(startedC)
+		<-finishC
+		w.Write([]byte("finished"))
+	}))
+	defer originServer.Close()
+
+	config := newTunnelConfig(edge, originServer)
+	config.EdgeAddrs = []string{edge.Addr()}
+	config.DrainOverlap = time.Minute
+	config.Logger = origin.Log
+	shutdownC := make(chan struct{})
+	daemonErrC := make(chan error, 1)
+	go func() {
+		daemonErrC <- origin.StartTunnelDaemon(config, shutdownC, make(chan struct{}))
+	}()
+	defer func() {
+		close(shutdownC)
+		select {
+		case <-daemonErrC:
+		case <-time.After(5 * time.Second):
+			t.Error("tunnel daemon did not stop")
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := edge.WaitForConn(ctx)
+	if err != nil {
+		t.Fatalf("timeout waiting for tunnel connection: %s", err)
+	}
+	if _, err := edge.WaitForRegistration(ctx, 1); err != nil {
+		t.Fatalf("timeout waiting for registration: %s", err)
+	}
+	type result struct {
+		response *http.Response
+		err      error
+	}
+	resultC := make(chan result, 1)
+	go func() {
+		req, _ := http.NewRequest("GET", "https://tunnel.example.com/slow", nil)
+		response, err := conn.RoundTrip(req)
+		resultC <- result{response, err}
+	}()
+	select {
+	case <-startedC:
+	case <-ctx.Done():
+		t.Fatal("origin did not receive the request")
+	}
+
+	// the replacement registers while the request is still in flight
+	conn.GoAway()
+	registrations, err := edge.WaitForRegistration(ctx, 2)
+	if err != nil {
+		t.Fatalf("timeout waiting for the replacement to register: %s", err)
+	}
+	if registrations[1].Options.ConnectionID == registrations[0].Options.ConnectionID {
+		t.Fatalf("replacement registered with the draining connection's ID %d", registrations[0].Options.ConnectionID)
+	}
+
+	close(finishC)
+	select {
+	case result := <-resultC:
+		if result.err != nil {
+			t.Fatalf("error in RoundTrip: %s", result.err)
+		}
+		defer result.response.Body.Close()
+		body, err := ioutil.ReadAll(result.response.Body)
+		if err != nil {
+			t.Fatalf("error reading response body: %s", err)
+		}
+		if string(body) != "finished" {
+			t.Fatalf("unexpected response body %s", body)
+		}
+	case <-ctx.Done():
+		t.Fatal("request in flight did not finish after GOAWAY")
+	}
+}
+
+func TestDropPings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for heartbeats to time out")
+	}
+	edge, err := NewServer()
+	if err != nil {
+		t.Fatalf("error starting edge: %s", err)
+	}
+	defer edge.Close()
+	originServer := newOrigin()
+	defer originServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conn, errC := startTunnel(t, ctx, edge, originServer)
+	conn.DropPings()
+	select {
+	case err := <-errC:
+		if err != h2mux.ErrConnectionDropped {
+			t.Fatalf("unexpected error from ServeTunnel: %v", err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("tunnel did not detect the dead connection")
+	}
+}
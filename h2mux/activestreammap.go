@@ -76,6 +76,9 @@ func (m *activeStreamMap) Set(newStream *MuxedStream) bool {
 func (m *activeStreamMap) Delete(streamID uint32) {
 	m.Lock()
 	defer m.Unlock()
+	if stream, ok := m.streams[streamID]; ok {
+		stream.stopTimers()
+	}
 	delete(m.streams, streamID)
 	if len(m.streams) == 0 && m.streamsEmpty != nil {
 		close(m.streamsEmpty)
@@ -165,11 +168,22 @@ func (m *activeStreamMap) Abort() {
 	m.Lock()
 	defer m.Unlock()
 	for _, stream := range m.streams {
+		stream.cancel()
+		stream.stopTimers()
 		stream.Close()
 	}
 	m.ignoreNewStreams = true
 }
 
+// Cancel cancels the context of every active stream, without closing them.
+func (m *activeStreamMap) Cancel() {
+	m.RLock()
+	defer m.RUnlock()
+	for _, stream := range m.streams {
+		stream.cancel()
+	}
+}
+
 func (m *activeStreamMap) Metrics() *FlowControlMetrics {
 	m.Lock()
 	defer m.Unlock()
@@ -0,0 +1,424 @@
+package h2mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// CaptureVersion is the version of the capture format written by FrameCapture.
+	CaptureVersion = 1
+	// CaptureRead and CaptureWrite are the directions of captured frames, from the point of view of
+	// the Muxer that captured them.
+	CaptureRead  = "read"
+	CaptureWrite = "write"
+
+	redactedValue = "REDACTED"
+
+	// captureBufferFrames is the number of frames queued for parsing in each direction. Once the
+	// queue is full, frames are dropped rather than slow down the Muxer.
+	captureBufferFrames = 1024
+	frameHeaderLen      = 9
+)
+
+// CaptureHeader is the first record of a capture.
+type CaptureHeader struct {
+	Version  int    `json:"version"`
+	Name     string `json:"name,omitempty"`
+	IsClient bool   `json:"client"`
+	Redacted bool   `json:"redacted"`
+}
+
+// CapturedFrame is a frame read or written by a Muxer. Header blocks are decoded, so a HEADERS frame
+// and its CONTINUATION frames are captured as a single HEADERS frame with END_HEADERS set. The
+// payload of other frames is captured as is.
+type CapturedFrame struct {
+	Time     time.Time   `json:"time"`
+	Dir      string      `json:"dir"`
+	Type     string      `json:"type"`
+	StreamID uint32      `json:"stream"`
+	Flags    http2.Flags `json:"flags"`
+	Headers  []Header    `json:"headers,omitempty"`
+	Payload  []byte      `json:"payload,omitempty"`
+}
+
+// FrameCapture records the frames read and written by a Muxer, when set as MuxerConfig.Capture. The
+// capture is written as a CaptureHeader followed by one CapturedFrame per frame, each encoded as a
+// line of JSON. A FrameCapture records a single connection.
+//
+// Frames are parsed from copies of the bytes on the wire, so the capture shows what was actually
+// sent and received even if the Muxer misbehaves. Frames that cannot be parsed end the capture.
+// Frames are parsed and written in the background; if the output can't keep up, frames are dropped
+// and counted by Dropped. Header blocks that follow a dropped header block can't be decoded, so their
+// HEADERS frames are captured without headers.
+type FrameCapture struct {
+	// dropped is the number of frames dropped because the queue was full.
+	dropped uint64
+	// peerHeaderTableSize is the HPACK table size in the peer's SETTINGS, which limits the table
+	// used to encode the headers we write. Zero until the handshake is done.
+	peerHeaderTableSize uint32
+	// redact replaces header values and DATA payloads in the capture.
+	redact bool
+	// out is where the capture is written.
+	out io.Writer
+
+	sync.Mutex
+	encoder *json.Encoder
+	tees    []*captureTee
+	started bool
+	closed  bool
+	err     error
+	// parsers waits for the goroutines parsing each direction.
+	parsers   sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewFrameCapture returns a FrameCapture that writes to out. If redact is true, the values of all
+// headers except :method, :scheme, :status and content-length are replaced, and DATA payloads are
+// replaced with zeros of the same length, so that the capture shows the shape of the traffic without
+// its content.
+func NewFrameCapture(out io.Writer, redact bool) *FrameCapture {
+	return &FrameCapture{
+		redact:  redact,
+		out:     out,
+		encoder: json.NewEncoder(out),
+	}
+}
+
+// Dropped returns the number of frames left out of the capture because it couldn't keep up.
+func (c *FrameCapture) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// Close stops capturing, and closes the output if it is an io.Closer. It returns the first error
+// writing the capture.
+func (c *FrameCapture) Close() error {
+	c.closeOnce.Do(func() {
+		c.Lock()
+		c.closed = true
+		tees := c.tees
+		c.Unlock()
+		for _, tee := range tees {
+			tee.Close()
+		}
+		c.parsers.Wait()
+		if closer, ok := c.out.(io.Closer); ok {
+			if err := closer.Close(); err != nil && c.err == nil {
+				c.err = err
+			}
+		}
+	})
+	return c.err
+}
+
+// wrap returns a writer and a reader that pass frames to w and from r, and capture them.
+func (c *FrameCapture) wrap(w io.WriteCloser, r io.ReadCloser, config MuxerConfig) (io.WriteCloser, io.ReadCloser) {
+	c.Lock()
+	defer c.Unlock()
+	if c.started || c.closed {
+		return w, r
+	}
+	c.started = true
+	c.encode(CaptureHeader{
+		Version:  CaptureVersion,
+		Name:     config.Name,
+		IsClient: config.IsClient,
+		Redacted: c.redact,
+	})
+	writeTableSize := func() uint32 {
+		if size := atomic.LoadUint32(&c.peerHeaderTableSize); size != 0 {
+			return size
+		}
+		return defaultHeaderTableSize
+	}
+	readTableSize := func() uint32 { return config.HeaderTableSize }
+	return &captureWriter{WriteCloser: w, tee: c.tee(CaptureWrite, writeTableSize)},
+		&captureReader{ReadCloser: r, tee: c.tee(CaptureRead, readTableSize)}
+}
+
+// setPeerHeaderTableSize is called by the Muxer with the HPACK table size in the peer's SETTINGS,
+// before it writes any headers.
+func (c *FrameCapture) setPeerHeaderTableSize(size uint32) {
+	atomic.StoreUint32(&c.peerHeaderTableSize, size)
+}
+
+// tee starts parsing the frames written to the returned tee. Header blocks are decoded with an HPACK
+// table of at most headerTableSize bytes, which is the size advertised by the receiving end. Must be
+// called with the lock held.
+func (c *FrameCapture) tee(dir string, headerTableSize func() uint32) *captureTee {
+	tee := &captureTee{capture: c, frames: make(chan queuedFrame, captureBufferFrames)}
+	c.tees = append(c.tees, tee)
+	c.parsers.Add(1)
+	go c.parse(dir, tee.frames, headerTableSize)
+	return tee
+}
+
+func (c *FrameCapture) parse(dir string, frames <-chan queuedFrame, headerTableSize func() uint32) {
+	defer c.parsers.Done()
+	// Keep draining the queue so that the tee is not left full if the frames cannot be parsed.
+	defer func() {
+		for range frames {
+		}
+	}()
+	decoder := hpack.NewDecoder(headerTableSize(), nil)
+	var headers *CapturedFrame
+	var headerBlock []byte
+	// headersLost is set once a header block has been dropped. The decoder's dynamic table no longer
+	// matches the peer's, so later header blocks are not decoded.
+	headersLost := false
+	for queued := range frames {
+		if queued.headersLost {
+			headersLost = true
+			headers, headerBlock = nil, nil
+		}
+		r := bytes.NewReader(queued.data)
+		fh, err := http2.ReadFrameHeader(r)
+		if err != nil {
+			return
+		}
+		payload := queued.data[frameHeaderLen:]
+		frame := &CapturedFrame{
+			Time:     time.Now(),
+			Dir:      dir,
+			Type:     fh.Type.String(),
+			StreamID: fh.StreamID,
+			Flags:    fh.Flags,
+		}
+		switch fh.Type {
+		case http2.FrameHeaders:
+			fragment, ok := headerBlockFragment(fh, payload)
+			if !ok || headers != nil {
+				return
+			}
+			frame.Flags &= http2.FlagHeadersEndStream
+			headers, headerBlock = frame, fragment
+		case http2.FrameContinuation:
+			if headers == nil && headersLost {
+				// continues a header block whose HEADERS frame was dropped
+				continue
+			}
+			if headers == nil || headers.StreamID != fh.StreamID {
+				return
+			}
+			headerBlock = append(headerBlock, payload...)
+		default:
+			if fh.Type == http2.FrameData && c.redact {
+				payload = make([]byte, len(payload))
+			}
+			frame.Payload = payload
+			c.record(frame)
+			continue
+		}
+		if fh.Flags.Has(http2.FlagHeadersEndHeaders) {
+			if !headersLost {
+				decoder.SetAllowedMaxDynamicTableSize(headerTableSize())
+				fields, err := decoder.DecodeFull(headerBlock)
+				if err != nil {
+					return
+				}
+				for _, field := range fields {
+					headers.Headers = append(headers.Headers, c.redactHeader(Header{Name: field.Name, Value: field.Value}))
+				}
+			}
+			headers.Flags |= http2.FlagHeadersEndHeaders
+			c.record(headers)
+			headers, headerBlock = nil, nil
+		}
+	}
+}
+
+// headerBlockFragment returns the header block fragment in the payload of a HEADERS frame, without
+// padding or priority fields.
+func headerBlockFragment(fh http2.FrameHeader, payload []byte) ([]byte, bool) {
+	if fh.Flags.Has(http2.FlagHeadersPadded) {
+		if len(payload) == 0 || int(payload[0]) >= len(payload) {
+			return nil, false
+		}
+		payload = payload[1 : len(payload)-int(payload[0])]
+	}
+	if fh.Flags.Has(http2.FlagHeadersPriority) {
+		if len(payload) < 5 {
+			return nil, false
+		}
+		payload = payload[5:]
+	}
+	return payload, true
+}
+
+func (c *FrameCapture) redactHeader(header Header) Header {
+	if !c.redact {
+		return header
+	}
+	switch header.Name {
+	case ":method", ":scheme", ":status", "content-length":
+		return header
+	}
+	header.Value = redactedValue
+	return header
+}
+
+func (c *FrameCapture) record(frame *CapturedFrame) {
+	c.Lock()
+	defer c.Unlock()
+	c.encode(frame)
+}
+
+// encode writes a record of the capture. Must be called with the lock held.
+func (c *FrameCapture) encode(v interface{}) {
+	if c.err != nil {
+		return
+	}
+	c.err = c.encoder.Encode(v)
+}
+
+// queuedFrame is a frame waiting to be parsed.
+type queuedFrame struct {
+	// data is the frame header and payload.
+	data []byte
+	// headersLost is true if a HEADERS or CONTINUATION frame was dropped before this one.
+	headersLost bool
+}
+
+// captureTee splits the bytes read or written by a Muxer into frames, and queues them for parsing
+// without blocking.
+type captureTee struct {
+	capture *FrameCapture
+
+	sync.Mutex
+	buffer      []byte
+	frames      chan queuedFrame
+	headersLost bool
+	closed      bool
+}
+
+func (t *captureTee) Write(p []byte) {
+	t.Lock()
+	defer t.Unlock()
+	if t.closed {
+		return
+	}
+	t.buffer = append(t.buffer, p...)
+	for len(t.buffer) >= frameHeaderLen {
+		length := frameHeaderLen + (int(t.buffer[0])<<16 | int(t.buffer[1])<<8 | int(t.buffer[2]))
+		if len(t.buffer) < length {
+			break
+		}
+		data := make([]byte, length)
+		copy(data, t.buffer)
+		t.buffer = t.buffer[length:]
+		t.queue(data)
+	}
+}
+
+// queue passes a frame to the parser, or drops it if the parser is too far behind. Must be called
+// with the lock held.
+func (t *captureTee) queue(data []byte) {
+	select {
+	case t.frames <- queuedFrame{data: data, headersLost: t.headersLost}:
+		t.headersLost = false
+	default:
+		atomic.AddUint64(&t.capture.dropped, 1)
+		switch http2.FrameType(data[3]) {
+		case http2.FrameHeaders, http2.FrameContinuation:
+			t.headersLost = true
+		}
+	}
+}
+
+// Close ends the queue. The frames already queued are still captured.
+func (t *captureTee) Close() {
+	t.Lock()
+	defer t.Unlock()
+	if !t.closed {
+		t.closed = true
+		close(t.frames)
+	}
+}
+
+// captureWriter copies the frames written by a Muxer to a FrameCapture.
+type captureWriter struct {
+	io.WriteCloser
+	tee *captureTee
+}
+
+// Write captures frames before writing them, so that any frame the peer could have received is in
+// the capture once the Muxer stops.
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.tee.Write(p)
+	return w.WriteCloser.Write(p)
+}
+
+func (w *captureWriter) Close() error {
+	w.tee.Close()
+	return w.WriteCloser.Close()
+}
+
+// captureReader copies the frames read by a Muxer to a FrameCapture.
+type captureReader struct {
+	io.ReadCloser
+	tee *captureTee
+}
+
+func (r *captureReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.tee.Write(p[:n])
+	}
+	if err != nil {
+		r.tee.Close()
+	}
+	return n, err
+}
+
+func (r *captureReader) Close() error {
+	r.tee.Close()
+	return r.ReadCloser.Close()
+}
+
+// ReadCapture reads a capture written by FrameCapture.
+func ReadCapture(r io.Reader) (*CaptureHeader, []CapturedFrame, error) {
+	decoder := json.NewDecoder(r)
+	var header CaptureHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, nil, fmt.Errorf("invalid capture header: %s", err)
+	}
+	if header.Version != CaptureVersion {
+		return nil, nil, fmt.Errorf("unsupported capture version %d", header.Version)
+	}
+	var frames []CapturedFrame
+	for {
+		var frame CapturedFrame
+		err := decoder.Decode(&frame)
+		if err == io.EOF {
+			return &header, frames, nil
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("invalid frame %d in capture: %s", len(frames)+1, err)
+		}
+		if frame.Dir != CaptureRead && frame.Dir != CaptureWrite {
+			return nil, nil, fmt.Errorf("invalid direction %#v of frame %d in capture", frame.Dir, len(frames)+1)
+		}
+		if _, ok := frameTypeByName(frame.Type); !ok {
+			return nil, nil, fmt.Errorf("invalid type %#v of frame %d in capture", frame.Type, len(frames)+1)
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// frameTypeByName is the inverse of http2.FrameType.String.
+func frameTypeByName(name string) (http2.FrameType, bool) {
+	for t := http2.FrameData; t <= http2.FrameContinuation; t++ {
+		if strings.EqualFold(t.String(), name) {
+			return t, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,247 @@
+package h2mux
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// captureStream captures the origin end of a muxer pair while the edge sends a request.
+func captureStream(t *testing.T, redact bool) *bytes.Buffer {
+	var captured bytes.Buffer
+	capture := NewFrameCapture(&captured, redact)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Capture = capture
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		ioutil.ReadAll(stream)
+		stream.WriteHeaders([]Header{{Name: ":status", Value: "200"}})
+		stream.Write([]byte("world"))
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+	stream, err := muxPair.EdgeMux.OpenStream([]Header{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: "/secret"},
+		{Name: "x-secret", Value: "swordfish"},
+	}, bytes.NewBufferString("hello"))
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	if body, _ := ioutil.ReadAll(stream); string(body) != "world" {
+		t.Fatalf("unexpected response body %s", body)
+	}
+	// stop capturing before the shutdown, which ends the connection with a race between the last
+	// frames and the GOAWAY
+	if err := capture.Close(); err != nil {
+		t.Fatalf("error closing capture: %s", err)
+	}
+	muxPair.EdgeMux.Shutdown()
+	muxPair.Wait(t)
+	return &captured
+}
+
+func findFrame(frames []CapturedFrame, dir, frameType string, streamID uint32) *CapturedFrame {
+	for i, frame := range frames {
+		if frame.Dir == dir && frame.Type == frameType && frame.StreamID == streamID {
+			return &frames[i]
+		}
+	}
+	return nil
+}
+
+// streamData returns the data captured on a stream in one direction.
+func streamData(frames []CapturedFrame, dir string, streamID uint32) []byte {
+	var data []byte
+	for _, frame := range frames {
+		if frame.Dir == dir && frame.Type == "DATA" && frame.StreamID == streamID {
+			data = append(data, frame.Payload...)
+		}
+	}
+	return data
+}
+
+func TestFrameCapture(t *testing.T) {
+	header, frames, err := ReadCapture(captureStream(t, false))
+	if err != nil {
+		t.Fatalf("error reading capture: %s", err)
+	}
+	if !header.IsClient || header.Name != "origin" || header.Redacted {
+		t.Fatalf("unexpected capture header %+v", header)
+	}
+	if findFrame(frames, CaptureRead, "SETTINGS", 0) == nil || findFrame(frames, CaptureWrite, "SETTINGS", 0) == nil {
+		t.Fatal("handshake was not captured")
+	}
+	request := findFrame(frames, CaptureRead, "HEADERS", 2)
+	if request == nil {
+		t.Fatal("request headers were not captured")
+	}
+	if len(request.Headers) != 3 || request.Headers[2] != (Header{Name: "x-secret", Value: "swordfish"}) {
+		t.Fatalf("unexpected request headers %v", request.Headers)
+	}
+	if !request.Flags.Has(http2.FlagHeadersEndHeaders) {
+		t.Fatal("captured header block is incomplete")
+	}
+	if data := streamData(frames, CaptureRead, 2); string(data) != "hello" {
+		t.Fatalf("unexpected request data %s", data)
+	}
+	if data := streamData(frames, CaptureWrite, 2); string(data) != "world" {
+		t.Fatalf("unexpected response data %s", data)
+	}
+}
+
+func TestFrameCaptureRedacted(t *testing.T) {
+	header, frames, err := ReadCapture(captureStream(t, true))
+	if err != nil {
+		t.Fatalf("error reading capture: %s", err)
+	}
+	if !header.Redacted {
+		t.Fatal("capture is not marked as redacted")
+	}
+	request := findFrame(frames, CaptureRead, "HEADERS", 2)
+	if request == nil {
+		t.Fatal("request headers were not captured")
+	}
+	expectedHeaders := []Header{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: redactedValue},
+		{Name: "x-secret", Value: redactedValue},
+	}
+	for i, header := range expectedHeaders {
+		if request.Headers[i] != header {
+			t.Fatalf("expected header %v, got %v", header, request.Headers[i])
+		}
+	}
+	if data := streamData(frames, CaptureRead, 2); !bytes.Equal(data, make([]byte, 5)) {
+		t.Fatalf("unexpected request data %v", data)
+	}
+}
+
+func TestReadCaptureErrors(t *testing.T) {
+	for _, capture := range []string{
+		``,
+		`{"version":2}`,
+		`{"version":1}` + "\n" + `{"dir":"sideways","type":"DATA"}`,
+		`{"version":1}` + "\n" + `{"dir":"read","type":"BOGUS"}`,
+	} {
+		if _, _, err := ReadCapture(bytes.NewBufferString(capture)); err == nil {
+			t.Fatalf("expected an error reading capture %#v", capture)
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	captured := captureStream(t, false)
+	var replayed bytes.Buffer
+	replayCapture := NewFrameCapture(&replayed, false)
+	err := Replay(captured, MuxerConfig{Timeout: 100 * time.Millisecond, Capture: replayCapture}, false)
+	if err != nil {
+		t.Fatalf("error in Replay: %s", err)
+	}
+	replayCapture.Close()
+	header, frames, err := ReadCapture(&replayed)
+	if err != nil {
+		t.Fatalf("error reading replay capture: %s", err)
+	}
+	if !header.IsClient {
+		t.Fatal("replayed muxer is not a client")
+	}
+	response := findFrame(frames, CaptureWrite, "HEADERS", 2)
+	if response == nil || len(response.Headers) != 1 || response.Headers[0] != (Header{Name: ":status", Value: "200"}) {
+		t.Fatalf("unexpected response headers %v", response)
+	}
+	if data := streamData(frames, CaptureWrite, 2); string(data) != "world" {
+		t.Fatalf("unexpected response data %s", data)
+	}
+}
+
+// blockingWriter holds writes until it is released.
+type blockingWriter struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+func TestFrameCaptureDropsFrames(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	capture := NewFrameCapture(out, false)
+	capture.Lock()
+	tee := capture.tee(CaptureWrite, func() uint32 { return defaultHeaderTableSize })
+	capture.Unlock()
+
+	var frames bytes.Buffer
+	framer := http2.NewFramer(&frames, nil)
+	for i := 0; i < captureBufferFrames+10; i++ {
+		framer.WritePing(false, [8]byte{})
+	}
+	framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: []byte{0x82}, EndHeaders: true})
+	// the capture output is blocked, but the tee must not be
+	tee.Write(frames.Bytes())
+	if capture.Dropped() == 0 {
+		t.Fatal("no frames were dropped")
+	}
+
+	close(out.release)
+	for len(tee.frames) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	frames.Reset()
+	framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 3, BlockFragment: []byte{0x82}, EndHeaders: true})
+	tee.Write(frames.Bytes())
+	tee.Close()
+	capture.Close()
+
+	var captured []CapturedFrame
+	decoder := json.NewDecoder(&out.Buffer)
+	for decoder.More() {
+		var frame CapturedFrame
+		if err := decoder.Decode(&frame); err != nil {
+			t.Fatalf("error reading capture: %s", err)
+		}
+		captured = append(captured, frame)
+	}
+	if uint64(len(captured)) != captureBufferFrames+12-capture.Dropped() {
+		t.Fatalf("%d frames were captured and %d dropped", len(captured), capture.Dropped())
+	}
+	// the header block after the dropped one can't be decoded
+	headers := findFrame(captured, CaptureWrite, "HEADERS", 3)
+	if headers == nil || len(headers.Headers) != 0 {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+}
+
+func TestFrameCaptureHeaderTableSize(t *testing.T) {
+	var out bytes.Buffer
+	capture := NewFrameCapture(&out, false)
+	capture.Lock()
+	tee := capture.tee(CaptureRead, func() uint32 { return 8192 })
+	capture.Unlock()
+
+	// the peer uses the larger table we advertised
+	var headerBlock bytes.Buffer
+	encoder := hpack.NewEncoder(&headerBlock)
+	encoder.SetMaxDynamicTableSizeLimit(8192)
+	encoder.SetMaxDynamicTableSize(8192)
+	encoder.WriteField(hpack.HeaderField{Name: ":status", Value: "200"})
+	var frames bytes.Buffer
+	http2.NewFramer(&frames, nil).WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: headerBlock.Bytes(), EndHeaders: true})
+	tee.Write(frames.Bytes())
+	tee.Close()
+	capture.Close()
+
+	var frame CapturedFrame
+	if err := json.NewDecoder(&out).Decode(&frame); err != nil {
+		t.Fatalf("error reading capture: %s", err)
+	}
+	if len(frame.Headers) != 1 || frame.Headers[0] != (Header{Name: ":status", Value: "200"}) {
+		t.Fatalf("unexpected headers %v", frame.Headers)
+	}
+}
@@ -18,6 +18,7 @@ var (
 	ErrUnexpectedFrameType = MuxerProtocolError{"2001 unexpected frame type", http2.ErrCodeProtocol}
 	ErrUnknownStream       = MuxerProtocolError{"2002 unknown stream", http2.ErrCodeProtocol}
 	ErrInvalidStream       = MuxerProtocolError{"2003 invalid stream", http2.ErrCodeProtocol}
+	ErrInvalidHeaderBlock  = MuxerProtocolError{"2004 invalid header block", http2.ErrCodeProtocol}
 
 	ErrStreamHeadersSent = MuxerApplicationError{"3000 headers already sent"}
 	ErrConnectionClosed  = MuxerApplicationError{"3001 connection closed"}
@@ -59,3 +60,18 @@ type MuxerStreamError struct {
 func (e MuxerStreamError) Error() string {
 	return fmt.Sprintf("Stream error: %s", e.cause)
 }
+
+// StreamResetError is returned from Read and Write on a stream that has been reset, either by the
+// peer or locally. Code is the HTTP/2 error code carried by the RST_STREAM frame.
+type StreamResetError struct {
+	Code http2.ErrCode
+	// Remote is true if the peer reset the stream.
+	Remote bool
+}
+
+func (e StreamResetError) Error() string {
+	if e.Remote {
+		return fmt.Sprintf("Stream error: 4001 stream reset by peer (%s)", e.Code)
+	}
+	return fmt.Sprintf("Stream error: 4002 stream reset (%s)", e.Code)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/hpack"
 )
@@ -17,12 +18,37 @@ const (
 	maxWindowSize     uint32        = (1 << 31) - 1 // 2^31-1 = 2147483647, max window size specified in http2 spec
 	defaultTimeout    time.Duration = 5 * time.Second
 	defaultRetries    uint64        = 5
+	// defaultHeaderTableSize is the initial size of the HPACK dynamic table in RFC 7541.
+	defaultHeaderTableSize uint32 = 4096
+	// defaultMaxHeaderListSize matches the net/http limit on request headers.
+	defaultMaxHeaderListSize uint32 = 1 << 20
+
+	// minHeartbeatRetryInterval is the shortest time to wait for a ping to be acknowledged with
+	// dead peer detection. Like the minimum RTO in RFC 6298.
+	minHeartbeatRetryInterval time.Duration = time.Second
 
 	SettingMuxerMagic http2.SettingID = 0x42db
 	MuxerMagicOrigin  uint32          = 0xa2e43c8b
 	MuxerMagicEdge    uint32          = 0x1088ebf9
 )
 
+// MuxedConnection is a connection that carries MuxedStreams. Muxer multiplexes streams over a single
+// reliable byte stream such as a TLS connection, while QUICMuxer maps each of them to a QUIC stream.
+type MuxedConnection interface {
+	Serve() error
+	Shutdown()
+	// Abort closes the connection at once, resetting the open streams.
+	Abort()
+	OpenStream(headers []Header, body io.Reader) (*MuxedStream, error)
+	// OpenStreamContext is OpenStream, but stops waiting for the response headers and resets the
+	// stream when ctx is done.
+	OpenStreamContext(ctx context.Context, headers []Header, body io.Reader) (*MuxedStream, error)
+	RTT() RTTMeasurement
+	FlowControlMetrics() *FlowControlMetrics
+	StreamResetMetrics() *StreamResetMetrics
+	TimerRetries() uint64
+}
+
 type MuxedStreamHandler interface {
 	ServeStream(*MuxedStream) error
 }
@@ -43,6 +69,34 @@ type MuxerConfig struct {
 	HeartbeatInterval time.Duration
 	// The minimum number of heartbeats to send before terminating the connection.
 	MaxHeartbeats uint64
+	// DeadPeerDetection retries unacknowledged heartbeats after a timeout derived from the smoothed
+	// RTT and its variance, rather than after HeartbeatInterval. A dead peer is then detected after
+	// MaxHeartbeats round trips instead of MaxHeartbeats heartbeat intervals, while slow links get
+	// proportionally longer timeouts.
+	DeadPeerDetection bool
+	// The time a stream can go without sending or receiving data before it is reset. Zero disables
+	// the timeout.
+	StreamIdleTimeout time.Duration
+	// The time a stream can stay open before it is reset. Zero disables the limit.
+	StreamMaxLifetime time.Duration
+	// WebSocketIdleTimeout and WebSocketMaxLifetime are used instead of StreamIdleTimeout and
+	// StreamMaxLifetime for streams upgraded to WebSockets. Zero exempts them from the timeout.
+	WebSocketIdleTimeout time.Duration
+	WebSocketMaxLifetime time.Duration
+	// HeaderTableSize is the size of the HPACK dynamic table used to decode headers from the peer.
+	// It is sent to the peer in SETTINGS_HEADER_TABLE_SIZE, and defaults to 4096 bytes. Smaller
+	// sizes require a peer that honours the setting.
+	HeaderTableSize uint32
+	// MaxHeaderListSize is the largest header list accepted from the peer, as defined for
+	// SETTINGS_MAX_HEADER_LIST_SIZE. Streams with larger header lists are reset, and the connection
+	// is closed if the header block is much larger. Defaults to 1MiB.
+	MaxHeaderListSize uint32
+	// Capture records the frames read and written by the Muxer, if set. It is not used by QUICMuxer.
+	Capture *FrameCapture
+	// GoingAway is called when the peer first sends GOAWAY. The Muxer then drains: Serve returns
+	// once the streams the peer processed finish. It is called by the reader, so it must not block.
+	// It is not called by QUICMuxer, whose peer closes the connection at once.
+	GoingAway func()
 	// Logger to use
 	Logger *log.Logger
 }
@@ -70,9 +124,17 @@ type Muxer struct {
 	readyList *ReadyList
 	// streams tracks currently-open streams.
 	streams *activeStreamMap
+	// streamErrors is used to reset streams.
+	streamErrors *StreamErrorMap
+	// streamResets counts RST_STREAM frames sent and received.
+	streamResets *streamResetCounter
 	// explicitShutdown records whether the Muxer is closing because Shutdown was called, or due to another
 	// error.
 	explicitShutdown *BooleanFuse
+	// peerHeaderTableSize and peerMaxHeaderListSize are the limits in the peer's SETTINGS, or zero if
+	// it didn't send them.
+	peerHeaderTableSize   uint32
+	peerMaxHeaderListSize uint32
 }
 
 type Header struct {
@@ -93,6 +155,15 @@ func Handshake(
 	if config.Logger == nil {
 		config.Logger = log.New()
 	}
+	if config.HeaderTableSize == 0 {
+		config.HeaderTableSize = defaultHeaderTableSize
+	}
+	if config.MaxHeaderListSize == 0 {
+		config.MaxHeaderListSize = defaultMaxHeaderListSize
+	}
+	if config.Capture != nil {
+		w, r = config.Capture.wrap(w, r, config)
+	}
 	// Initialise connection state fields
 	m := &Muxer{
 		f:             http2.NewFramer(w, r), // A framer that writes to w and reads from r
@@ -104,7 +175,8 @@ func Handshake(
 		readyList:     NewReadyList(),
 		streams:       newActiveStreamMap(config.IsClient),
 	}
-	m.f.ReadMetaHeaders = hpack.NewDecoder(4096, func(hpack.HeaderField) {})
+	m.f.ReadMetaHeaders = hpack.NewDecoder(config.HeaderTableSize, func(hpack.HeaderField) {})
+	m.f.MaxHeaderListSize = config.MaxHeaderListSize
 
 	// Initialise the settings to identify this connection and confirm the other end is sane.
 	handshakeSetting := http2.Setting{ID: SettingMuxerMagic, Val: MuxerMagicEdge}
@@ -115,7 +187,13 @@ func Handshake(
 	}
 	errChan := make(chan error, 2)
 	// Simultaneously send our settings and verify the peer's settings.
-	go func() { errChan <- m.f.WriteSettings(handshakeSetting) }()
+	go func() {
+		errChan <- m.f.WriteSettings(
+			handshakeSetting,
+			http2.Setting{ID: http2.SettingHeaderTableSize, Val: config.HeaderTableSize},
+			http2.Setting{ID: http2.SettingMaxHeaderListSize, Val: config.MaxHeaderListSize},
+		)
+	}()
 	go func() { errChan <- m.readPeerSettings(expectedMagic) }()
 	err := joinErrorsWithTimeout(errChan, 2, config.Timeout, ErrHandshakeTimeout)
 	if err != nil {
@@ -131,7 +209,8 @@ func Handshake(
 	}
 
 	// set up reader/writer pair ready for serve
-	streamErrors := NewStreamErrorMap()
+	m.streamErrors = NewStreamErrorMap()
+	m.streamResets = newStreamResetCounter()
 	goAwayChan := make(chan http2.ErrCode, 1)
 	pingTimestamp := NewPingTimestamp()
 	connActive := NewSignal()
@@ -153,29 +232,43 @@ func Handshake(
 		handler:             m.config.Handler,
 		streams:             m.streams,
 		readyList:           m.readyList,
-		streamErrors:        streamErrors,
+		streamErrors:        m.streamErrors,
+		streamResets:        m.streamResets,
 		goAwayChan:          goAwayChan,
 		abortChan:           m.abortChan,
 		pingTimestamp:       pingTimestamp,
 		connActive:          connActive,
 		initialStreamWindow: defaultWindowSize,
 		streamWindowMax:     maxWindowSize,
+		streamTimeouts:      newStreamTimeouts(config),
+		goingAway:           config.GoingAway,
 		r:                   m.r,
 	}
 	m.muxWriter = &MuxWriter{
-		f:               m.f,
-		streams:         m.streams,
-		streamErrors:    streamErrors,
-		readyStreamChan: m.readyList.ReadyChannel(),
-		newStreamChan:   m.newStreamChan,
-		goAwayChan:      goAwayChan,
-		abortChan:       m.abortChan,
-		pingTimestamp:   pingTimestamp,
-		idleTimer:       NewIdleTimer(idleDuration, maxRetries),
-		connActiveChan:  connActive.WaitChannel(),
-		maxFrameSize:    defaultFrameSize,
+		f:                 m.f,
+		streams:           m.streams,
+		streamErrors:      m.streamErrors,
+		streamResets:      m.streamResets,
+		readyStreamChan:   m.readyList.ReadyChannel(),
+		newStreamChan:     m.newStreamChan,
+		goAwayChan:        goAwayChan,
+		abortChan:         m.abortChan,
+		pingTimestamp:     pingTimestamp,
+		idleTimer:         NewIdleTimer(idleDuration, maxRetries),
+		deadPeerDetection: config.DeadPeerDetection,
+		rtt:               m.muxReader.RTT,
+		connActiveChan:    connActive.WaitChannel(),
+		maxFrameSize:      defaultFrameSize,
+		streamTimeouts:    newStreamTimeouts(config),
+		maxHeaderListSize: m.peerMaxHeaderListSize,
 	}
 	m.muxWriter.headerEncoder = hpack.NewEncoder(&m.muxWriter.headerBuffer)
+	if m.peerHeaderTableSize != 0 {
+		m.muxWriter.headerEncoder.SetMaxDynamicTableSizeLimit(m.peerHeaderTableSize)
+	}
+	if config.Capture != nil {
+		config.Capture.setPeerHeaderTableSize(m.peerHeaderTableSize)
+	}
 
 	return m, nil
 }
@@ -199,6 +292,8 @@ func (m *Muxer) readPeerSettings(magic uint32) error {
 	if magic != peerMagic {
 		return ErrBadHandshakeWrongMagic
 	}
+	m.peerHeaderTableSize, _ = settingsFrame.Value(http2.SettingHeaderTableSize)
+	m.peerMaxHeaderListSize, _ = settingsFrame.Value(http2.SettingMaxHeaderListSize)
 	return nil
 }
 
@@ -263,6 +358,12 @@ func (m *Muxer) Shutdown() {
 	m.muxReader.Shutdown()
 }
 
+// Abort closes the connection without waiting for the open streams, which are reset.
+func (m *Muxer) Abort() {
+	m.explicitShutdown.Fuse(true)
+	m.abort()
+}
+
 // IsUnexpectedTunnelError identifies errors that are expected when shutting down the h2mux tunnel.
 // The set of expected errors change depending on whether we initiated shutdown or not.
 func isUnexpectedTunnelError(err error, expectedShutdown bool) bool {
@@ -294,26 +395,87 @@ func isConnectionClosedError(err error) bool {
 // OpenStream opens a new data stream with the given headers.
 // Called by proxy server and tunnel
 func (m *Muxer) OpenStream(headers []Header, body io.Reader) (*MuxedStream, error) {
-	stream := &MuxedStream{
+	return m.OpenStreamContext(context.Background(), headers, body)
+}
+
+// OpenStreamContext opens a new data stream with the given headers, giving up if ctx is done
+// before the peer responds.
+func (m *Muxer) OpenStreamContext(ctx context.Context, headers []Header, body io.Reader) (*MuxedStream, error) {
+	stream := m.newLocalStream(headers)
+	if err := m.requestStream(ctx, stream, body); err != nil {
+		return nil, err
+	}
+	return waitForResponseHeaders(ctx, stream, m.abortChan)
+}
+
+func (m *Muxer) newLocalStream(headers []Header) *MuxedStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MuxedStream{
 		responseHeadersReceived: make(chan struct{}),
+		headersWritten:          make(chan struct{}),
 		readBuffer:              NewSharedBuffer(),
 		receiveWindow:           defaultWindowSize,
 		receiveWindowCurrentMax: defaultWindowSize, // Initial window size limit. exponentially increase it when receiveWindow is exhausted
 		receiveWindowMax:        maxWindowSize,
 		sendWindow:              defaultWindowSize,
 		readyList:               m.readyList,
+		streamErrors:            m.streamErrors,
 		writeHeaders:            headers,
+		ctx:                     ctx,
+		cancel:                  cancel,
 	}
+}
+
+// requestStream passes a new stream to the writer, which assigns the next stream ID to it before
+// accepting another stream. The body is copied to the stream once it has an ID.
+func (m *Muxer) requestStream(ctx context.Context, stream *MuxedStream, body io.Reader) error {
 	select {
 	// Will be received by mux writer
 	case m.newStreamChan <- MuxedStreamRequest{stream: stream, body: body}:
+		return nil
 	case <-m.abortChan:
-		return nil, ErrConnectionClosed
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
 	}
+}
+
+// waitForResponseHeaders blocks until the peer responds on a stream we opened, and returns the
+// stream. It fails if the stream is reset or the connection goes away first. If ctx is done first,
+// the stream is reset with ErrCodeCancel.
+func waitForResponseHeaders(ctx context.Context, stream *MuxedStream, abortChan <-chan struct{}) (*MuxedStream, error) {
 	select {
 	case <-stream.responseHeadersReceived:
 		return stream, nil
-	case <-m.abortChan:
+	case <-ctx.Done():
+		select {
+		case <-stream.responseHeadersReceived:
+			return stream, nil
+		default:
+		}
+		if stream.headersWritten != nil {
+			// The writer assigns the stream ID; resetting before then would reset the wrong stream.
+			select {
+			case <-stream.headersWritten:
+			case <-abortChan:
+				return nil, ErrConnectionClosed
+			}
+		}
+		stream.Reset(http2.ErrCodeCancel)
+		return nil, ctx.Err()
+	case <-stream.Context().Done():
+		select {
+		case <-stream.responseHeadersReceived:
+			// the peer responded before resetting the stream
+			return stream, nil
+		default:
+		}
+		// The stream was reset, or the peer went away without processing it.
+		if err := stream.getResetError(); err != nil {
+			return nil, err
+		}
+		return nil, ErrConnectionClosed
+	case <-abortChan:
 		return nil, ErrConnectionClosed
 	}
 }
@@ -335,6 +497,20 @@ func (m *Muxer) abort() {
 	})
 }
 
+// Return the number of RST_STREAM frames sent and received on this connection, by error code
+func (m *Muxer) StreamResetMetrics() *StreamResetMetrics {
+	return m.streamResets.Metrics()
+}
+
+// SetHeartbeat replaces HeartbeatInterval and MaxHeartbeats of the MuxerConfig, e.g. with settings
+// negotiated with the peer. Zero leaves a setting unchanged.
+func (m *Muxer) SetHeartbeat(interval time.Duration, maxHeartbeats uint64) {
+	if interval != 0 && interval < defaultTimeout {
+		interval = defaultTimeout
+	}
+	m.muxWriter.idleTimer.SetHeartbeat(interval, maxHeartbeats)
+}
+
 // Return how many retries/ticks since the connection was last marked active
 func (m *Muxer) TimerRetries() uint64 {
 	return m.muxWriter.idleTimer.RetryCount()
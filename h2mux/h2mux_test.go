@@ -9,11 +9,14 @@ import (
 	"net"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
 )
 
 func TestMain(m *testing.M) {
@@ -461,6 +464,71 @@ func TestGracefulShutdown(t *testing.T) {
 	muxPair.Wait(t)
 }
 
+func TestGoingAway(t *testing.T) {
+	goingAwayC := make(chan struct{})
+	handlerC := make(chan struct{})
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.GoingAway = func() { close(goingAwayC) }
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{{Name: "response-header", Value: "responseValue"}})
+		<-handlerC
+		stream.Write([]byte("Hello world"))
+		stream.CloseWrite()
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream([]Header{{Name: "test-header", Value: "headerValue"}}, nil)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	muxPair.EdgeMux.Shutdown()
+	select {
+	case <-goingAwayC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("GoingAway wasn't called")
+	}
+	// the stream in flight still completes
+	close(handlerC)
+	body, err := ioutil.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("error reading the stream: %s", err)
+	}
+	if string(body) != "Hello world" {
+		t.Fatalf("unexpected response body %q", body)
+	}
+	stream.Close()
+	muxPair.Wait(t)
+}
+
+func TestAbort(t *testing.T) {
+	handlerC := make(chan *MuxedStream)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{{Name: "response-header", Value: "responseValue"}})
+		handlerC <- stream
+		<-stream.Context().Done()
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream([]Header{{Name: "test-header", Value: "headerValue"}}, nil)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	originStream := <-handlerC
+	// the open streams are reset rather than drained
+	muxPair.OriginMux.Abort()
+	for _, s := range []*MuxedStream{originStream, stream} {
+		select {
+		case <-s.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for stream context to be cancelled")
+		}
+	}
+	muxPair.Wait(t)
+}
+
 func TestUnexpectedShutdown(t *testing.T) {
 	sendC := make(chan struct{})
 	handlerFinishC := make(chan struct{})
@@ -515,6 +583,298 @@ func TestUnexpectedShutdown(t *testing.T) {
 	<-handlerFinishC
 }
 
+func TestStreamContextCancelledOnReset(t *testing.T) {
+	handlerC := make(chan *MuxedStream)
+	cancelledC := make(chan struct{})
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		handlerC <- stream
+		<-stream.Context().Done()
+		close(cancelledC)
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	originStream := <-handlerC
+	select {
+	case <-originStream.Context().Done():
+		t.Fatal("stream context cancelled before reset")
+	default:
+	}
+	muxPair.EdgeMux.muxReader.streamError(stream.streamID, http2.ErrCodeCancel)
+	select {
+	case <-cancelledC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for stream context to be cancelled")
+	}
+	select {
+	case <-stream.Context().Done():
+	default:
+		t.Fatal("expected local stream context to be cancelled after reset")
+	}
+}
+
+func TestStreamContextCancelledOnAbort(t *testing.T) {
+	handlerC := make(chan *MuxedStream)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		handlerC <- stream
+		<-stream.Context().Done()
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	originStream := <-handlerC
+	muxPair.EdgeConn.Close()
+	for _, s := range []*MuxedStream{stream, originStream} {
+		select {
+		case <-s.Context().Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for stream context to be cancelled")
+		}
+	}
+	muxPair.Wait(t)
+}
+
+func TestStreamResetByHandler(t *testing.T) {
+	resetC := make(chan struct{})
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		stream.Write([]byte("Hello world"))
+		<-resetC
+		stream.Reset(http2.ErrCodeInternal)
+		_, err := stream.Write([]byte("Hello world"))
+		if err != (StreamResetError{Code: http2.ErrCodeInternal}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Write: %v", err)
+		}
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	responseBody := make([]byte, 11)
+	if _, err := io.ReadFull(stream, responseBody); err != nil {
+		t.Fatalf("error from (*MuxedStream).Read: %s", err)
+	}
+	close(resetC)
+	_, err = ioutil.ReadAll(stream)
+	if err != (StreamResetError{Code: http2.ErrCodeInternal, Remote: true}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+	}
+	_, err = stream.Write([]byte("Hello world"))
+	if err != (StreamResetError{Code: http2.ErrCodeInternal, Remote: true}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Write: %v", err)
+	}
+	if n := muxPair.EdgeMux.StreamResetMetrics().Received[http2.ErrCodeInternal]; n != 1 {
+		t.Fatalf("expected 1 received reset, got %d", n)
+	}
+	if n := muxPair.OriginMux.StreamResetMetrics().Sent[http2.ErrCodeInternal]; n != 1 {
+		t.Fatalf("expected 1 sent reset, got %d", n)
+	}
+}
+
+func TestStreamResetBeforeResponse(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.Reset(http2.ErrCodeRefusedStream)
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	_, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != (StreamResetError{Code: http2.ErrCodeRefusedStream, Remote: true}) {
+		t.Fatalf("unexpected error in OpenStream: %v", err)
+	}
+}
+
+func TestStreamResetByClient(t *testing.T) {
+	handlerC := make(chan error)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		_, err := ioutil.ReadAll(stream)
+		handlerC <- err
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	stream.Reset(http2.ErrCodeCancel)
+	// resetting twice only sends one RST_STREAM
+	stream.Reset(http2.ErrCodeInternal)
+	_, err = stream.Read([]byte{0})
+	if err != (StreamResetError{Code: http2.ErrCodeCancel}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+	}
+	select {
+	case err := <-handlerC:
+		if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for handler to observe reset")
+	}
+	metrics := muxPair.EdgeMux.StreamResetMetrics()
+	if len(metrics.Sent) != 1 || metrics.Sent[http2.ErrCodeCancel] != 1 {
+		t.Fatalf("unexpected sent resets: %v", metrics.Sent)
+	}
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	handlerErrC := make(chan error, 2)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.StreamIdleTimeout = 100 * time.Millisecond
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		_, err := stream.Read([]byte{0})
+		handlerErrC <- err
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	// WebSocket streams are exempt from the timeout when no WebSocket timeout is set.
+	wsStream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "upgrade", Value: "websocket"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	_, err = stream.Read([]byte{0})
+	if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+	}
+	select {
+	case err := <-handlerErrC:
+		if err != (StreamResetError{Code: http2.ErrCodeCancel}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for handler to observe reset")
+	}
+	select {
+	case err := <-handlerErrC:
+		t.Fatalf("WebSocket stream was reset: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	wsStream.Reset(http2.ErrCodeCancel)
+}
+
+func TestStreamMaxLifetime(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.StreamIdleTimeout = 100 * time.Millisecond
+	muxPair.OriginMuxConfig.StreamMaxLifetime = 300 * time.Millisecond
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		// keep the stream active so that only the lifetime limit applies
+		for {
+			if _, err := stream.Write([]byte("Hello world")); err != nil {
+				return nil
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+	muxPair.HandshakeAndServe(t)
+
+	start := time.Now()
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	_, err = io.Copy(ioutil.Discard, stream)
+	if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("stream reset after %s, before its lifetime expired", elapsed)
+	}
+}
+
+func TestDisableTimeouts(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.EdgeMuxConfig.StreamIdleTimeout = 100 * time.Millisecond
+	muxPair.EdgeMuxConfig.StreamMaxLifetime = 200 * time.Millisecond
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		_, err := io.Copy(stream, stream)
+		return err
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	stream.DisableTimeouts()
+	time.Sleep(300 * time.Millisecond)
+	if _, err := stream.Write([]byte("a")); err != nil {
+		t.Fatalf("error writing after the timeouts: %s", err)
+	}
+	if _, err := io.ReadFull(stream, make([]byte, 1)); err != nil {
+		t.Fatalf("error reading after the timeouts: %s", err)
+	}
+	stream.Close()
+}
+
 func EchoHandler(stream *MuxedStream) error {
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "Hello, world!\n\n# REQUEST HEADERS:\n\n")
@@ -638,3 +998,246 @@ func AssertIfPipeReadable(t *testing.T, pipe io.ReadCloser) {
 		// nothing to read
 	}
 }
+
+// rawEdge is an edge that sends hand-crafted frames to a Muxer.
+type rawEdge struct {
+	f             *http2.Framer
+	headerEncoder *hpack.Encoder
+	headerBuffer  bytes.Buffer
+	frames        chan rawFrame
+}
+
+// rawFrame is a copy of the parts of a frame the tests look at, as the Framer reuses frames.
+type rawFrame struct {
+	http2.FrameHeader
+	errCode http2.ErrCode
+}
+
+// newRawEdge completes the handshake with an origin Muxer, and serves it.
+func newRawEdge(t *testing.T, config MuxerConfig) (*rawEdge, <-chan error) {
+	originConn, edgeConn := net.Pipe()
+	edge := &rawEdge{
+		f:      http2.NewFramer(edgeConn, edgeConn),
+		frames: make(chan rawFrame, 16),
+	}
+	edge.f.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	edge.headerEncoder = hpack.NewEncoder(&edge.headerBuffer)
+	go func() {
+		defer close(edge.frames)
+		for {
+			frame, err := edge.f.ReadFrame()
+			if err != nil {
+				return
+			}
+			copied := rawFrame{FrameHeader: frame.Header()}
+			if rst, ok := frame.(*http2.RSTStreamFrame); ok {
+				copied.errCode = rst.ErrCode
+			}
+			edge.frames <- copied
+		}
+	}()
+	config.IsClient = true
+	muxerC := make(chan *Muxer, 1)
+	go func() {
+		muxer, err := Handshake(originConn, originConn, config)
+		if err != nil {
+			t.Errorf("origin handshake failure: %s", err)
+		}
+		muxerC <- muxer
+	}()
+	edge.f.WriteSettings(http2.Setting{ID: SettingMuxerMagic, Val: MuxerMagicEdge})
+	edge.f.WriteSettingsAck()
+	muxer := <-muxerC
+	if muxer == nil {
+		t.FailNow()
+	}
+	serveErrC := make(chan error, 1)
+	go func() {
+		serveErrC <- muxer.Serve()
+	}()
+	return edge, serveErrC
+}
+
+func (e *rawEdge) writeHeaders(t *testing.T, streamID uint32, headers []Header) {
+	e.headerBuffer.Reset()
+	for _, header := range headers {
+		e.headerEncoder.WriteField(hpack.HeaderField{Name: header.Name, Value: header.Value})
+	}
+	err := e.f.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		EndHeaders:    true,
+		BlockFragment: e.headerBuffer.Bytes(),
+	})
+	if err != nil {
+		t.Fatalf("error writing headers: %s", err)
+	}
+}
+
+// waitForFrame returns the next frame of the given type, skipping others.
+func (e *rawEdge) waitForFrame(t *testing.T, frameType http2.FrameType) rawFrame {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case frame, ok := <-e.frames:
+			if !ok {
+				t.Fatalf("connection closed waiting for %s", frameType)
+			}
+			if frame.Type == frameType {
+				return frame
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %s", frameType)
+		}
+	}
+}
+
+func TestHeaderListTooLarge(t *testing.T) {
+	streamC := make(chan uint32, 2)
+	edge, _ := newRawEdge(t, MuxerConfig{
+		Timeout:           time.Second,
+		MaxHeaderListSize: 1024,
+		Handler: MuxedStreamFunc(func(stream *MuxedStream) error {
+			streamC <- stream.streamID
+			return nil
+		}),
+	})
+	edge.writeHeaders(t, 2, []Header{
+		{Name: ":method", Value: "GET"},
+		{Name: "x-large-1", Value: strings.Repeat("a", 500)},
+		{Name: "x-large-2", Value: strings.Repeat("b", 500)},
+		{Name: "x-large-3", Value: strings.Repeat("c", 500)},
+	})
+	rst := edge.waitForFrame(t, http2.FrameRSTStream)
+	if rst.StreamID != 2 || rst.errCode != http2.ErrCodeProtocol {
+		t.Fatalf("unexpected reset of stream %d with %s", rst.StreamID, rst.errCode)
+	}
+	// the connection is still usable
+	edge.writeHeaders(t, 4, []Header{{Name: ":method", Value: "GET"}})
+	select {
+	case streamID := <-streamC:
+		if streamID != 4 {
+			t.Fatalf("handler called for stream %d", streamID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for handler")
+	}
+}
+
+func TestHeaderCompressionBomb(t *testing.T) {
+	handlerC := make(chan struct{}, 1)
+	edge, serveErrC := newRawEdge(t, MuxerConfig{
+		Timeout:           time.Second,
+		MaxHeaderListSize: 64 * 1024,
+		Handler: MuxedStreamFunc(func(stream *MuxedStream) error {
+			handlerC <- struct{}{}
+			return nil
+		}),
+	})
+	// Each repetition of the header is a one byte reference to the dynamic table, but adds 4KB to
+	// the decoded header list.
+	headers := []Header{{Name: ":method", Value: "GET"}}
+	for i := 0; i < 1000; i++ {
+		headers = append(headers, Header{Name: "x-bomb", Value: strings.Repeat("b", 4000)})
+	}
+	edge.writeHeaders(t, 2, headers)
+	rst := edge.waitForFrame(t, http2.FrameRSTStream)
+	if rst.StreamID != 2 || rst.errCode != http2.ErrCodeProtocol {
+		t.Fatalf("unexpected reset of stream %d with %s", rst.StreamID, rst.errCode)
+	}
+	select {
+	case <-handlerC:
+		t.Fatal("handler called for a stream with too many headers")
+	case err := <-serveErrC:
+		t.Fatalf("connection closed: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestHeaderBlockFarTooLarge(t *testing.T) {
+	edge, serveErrC := newRawEdge(t, MuxerConfig{Timeout: time.Second, MaxHeaderListSize: 1024})
+	// A header block much larger than the limit is not decoded at all, and ends the connection.
+	edge.writeHeaders(t, 2, []Header{
+		{Name: ":method", Value: "GET"},
+		{Name: "x-large", Value: strings.Repeat("a", 4096)},
+	})
+	select {
+	case err := <-serveErrC:
+		if err == nil {
+			t.Fatal("expected an error from Serve")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the connection to close")
+	}
+}
+
+func TestHeaderListTooLargeForPeer(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.MaxHeaderListSize = 1024
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(EchoHandler)
+	muxPair.HandshakeAndServe(t)
+
+	_, err := muxPair.EdgeMux.OpenStream([]Header{{Name: "x-large", Value: strings.Repeat("a", 1500)}}, nil)
+	if err != (StreamResetError{Code: http2.ErrCodeInternal}) {
+		t.Fatalf("unexpected error in OpenStream: %v", err)
+	}
+	stream, err := muxPair.EdgeMux.OpenStream([]Header{{Name: "x-small", Value: "a"}}, nil)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	stream.Close()
+	if resets := muxPair.OriginMux.StreamResetMetrics().Received; len(resets) != 0 {
+		t.Fatalf("unexpected resets received by origin: %v", resets)
+	}
+}
+
+func TestHeaderTableSize(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.HeaderTableSize = 100
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(EchoHandler)
+	muxPair.HandshakeAndServe(t)
+
+	if size := muxPair.EdgeMux.muxWriter.headerEncoder.MaxDynamicTableSize(); size != 100 {
+		t.Fatalf("expected edge table size 100, got %d", size)
+	}
+	if size := muxPair.OriginMux.muxWriter.headerEncoder.MaxDynamicTableSize(); size != defaultHeaderTableSize {
+		t.Fatalf("expected origin table size %d, got %d", defaultHeaderTableSize, size)
+	}
+	// headers larger than the table are not indexed, and smaller ones evict each other
+	for i := 0; i < 3; i++ {
+		stream, err := muxPair.EdgeMux.OpenStream([]Header{
+			{Name: "x-small", Value: strconv.Itoa(i)},
+			{Name: "x-large", Value: strings.Repeat("a", 200)},
+			{Name: "x-medium", Value: strings.Repeat("b", 40)},
+		}, nil)
+		if err != nil {
+			t.Fatalf("error in OpenStream: %s", err)
+		}
+		body, _ := ioutil.ReadAll(stream)
+		if !strings.Contains(string(body), "[x-small] = "+strconv.Itoa(i)) {
+			t.Fatalf("unexpected response %s", body)
+		}
+	}
+}
+
+func TestSensitiveHeadersNeverIndexed(t *testing.T) {
+	w := &MuxWriter{}
+	w.headerEncoder = hpack.NewEncoder(&w.headerBuffer)
+	encoded, err := w.encodeHeaders([]Header{
+		{Name: "authorization", Value: "Bearer token"},
+		{Name: "cookie", Value: "session=1"},
+		{Name: "cf-access-jwt-assertion", Value: "jwt"},
+		{Name: "user-agent", Value: "test"},
+	})
+	if err != nil {
+		t.Fatalf("error encoding headers: %s", err)
+	}
+	fields, err := hpack.NewDecoder(4096, nil).DecodeFull(encoded)
+	if err != nil {
+		t.Fatalf("error decoding headers: %s", err)
+	}
+	for _, field := range fields {
+		if field.Sensitive != (field.Name != "user-agent") {
+			t.Fatalf("header %s has sensitive=%t", field.Name, field.Sensitive)
+		}
+	}
+}
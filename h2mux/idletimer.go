@@ -20,6 +20,8 @@ type IdleTimer struct {
 	idleTimer *time.Timer
 	// The maximum length of time a connection is idle before sending a ping.
 	idleDuration time.Duration
+	// If nonzero, the time to wait before retrying an unacknowledged ping.
+	retryInterval time.Duration
 	// A pseudorandom source used to add jitter to the idle duration.
 	randomSource *rand.Rand
 	// The maximum number of retries allowed.
@@ -74,8 +76,46 @@ func (t *IdleTimer) MarkActive() {
 	t.ResetTimer()
 }
 
-// Reset the idle timer according to the configured duration, with some added jitter.
+// SetRetryInterval sets how long to wait before retrying once a ping has been sent, instead of
+// the idle duration. Zero restores the idle duration.
+func (t *IdleTimer) SetRetryInterval(retryInterval time.Duration) {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	t.retryInterval = retryInterval
+}
+
+// SetHeartbeat changes the idle duration and the maximum number of retries. Zero leaves a value
+// unchanged. The new idle duration applies from the next time the timer is reset.
+func (t *IdleTimer) SetHeartbeat(idleDuration time.Duration, maxRetries uint64) {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	if idleDuration != 0 {
+		t.idleDuration = idleDuration
+	}
+	if maxRetries != 0 {
+		t.maxRetries = maxRetries
+	}
+}
+
+// Reset the idle timer according to the configured duration, with some added jitter. Retries
+// use the retry interval if one is set.
 func (t *IdleTimer) ResetTimer() {
-	jitter := time.Duration(t.randomSource.Int63n(int64(t.idleDuration)))
-	t.idleTimer.Reset(t.idleDuration + jitter)
+	t.stateLock.RLock()
+	retrying := t.retries > 0 && t.retryInterval > 0
+	retryInterval := t.retryInterval
+	idleDuration := t.idleDuration
+	t.stateLock.RUnlock()
+	if retrying {
+		t.idleTimer.Reset(retryInterval)
+		return
+	}
+	jitter := time.Duration(t.randomSource.Int63n(int64(idleDuration)))
+	t.idleTimer.Reset(idleDuration + jitter)
+}
+
+// IdleDuration returns the maximum length of time a connection is idle before sending a ping.
+func (t *IdleTimer) IdleDuration() time.Duration {
+	t.stateLock.RLock()
+	defer t.stateLock.RUnlock()
+	return t.idleDuration
 }
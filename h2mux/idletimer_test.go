@@ -29,3 +29,41 @@ func TestMarkActive(t *testing.T) {
 	timer.MarkActive()
 	assert.Equal(t, uint64(0), timer.RetryCount())
 }
+
+func TestRetryInterval(t *testing.T) {
+	timer := NewIdleTimer(time.Hour, 2)
+	timer.SetRetryInterval(10 * time.Millisecond)
+	// the retry interval only applies once a ping is outstanding
+	timer.ResetTimer()
+	select {
+	case <-timer.C:
+		t.Fatal("idle timer fired before the idle duration")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.True(t, timer.Retry())
+	timer.ResetTimer()
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for retry")
+	}
+}
+
+func TestSetHeartbeat(t *testing.T) {
+	timer := NewIdleTimer(time.Hour, 1)
+	timer.SetHeartbeat(10*time.Millisecond, 2)
+	assert.Equal(t, 10*time.Millisecond, timer.IdleDuration())
+	timer.MarkActive()
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the new idle duration")
+	}
+	assert.True(t, timer.Retry())
+	assert.True(t, timer.Retry())
+	assert.False(t, timer.Retry())
+	// zero leaves the settings unchanged
+	timer.SetHeartbeat(0, 0)
+	assert.Equal(t, 10*time.Millisecond, timer.IdleDuration())
+	assert.False(t, timer.Retry())
+}
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"io"
 	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
 )
 
 type MuxedStream struct {
@@ -12,6 +16,9 @@ type MuxedStream struct {
 	streamID uint32
 
 	responseHeadersReceived chan struct{}
+	// closed by the writer once the stream has an ID and its headers have been sent; nil if the
+	// stream had an ID from the start
+	headersWritten chan struct{}
 
 	readBuffer    *SharedBuffer
 	receiveWindow uint32
@@ -37,6 +44,20 @@ type MuxedStream struct {
 	sentEOF bool
 	// true if the peer sent us an EOF
 	receivedEOF bool
+	// non-nil if the stream has been reset by either side
+	resetErr error
+	// streamErrors is used to ask the writer to send RST_STREAM.
+	streamErrors *StreamErrorMap
+
+	// idleTimer and lifetimeTimer reset the stream when it has been idle for idleTimeout, or open
+	// for too long. They are nil if the timeout is disabled.
+	idleTimeout   time.Duration
+	idleTimer     *time.Timer
+	lifetimeTimer *time.Timer
+
+	// ctx is cancelled when the stream is reset, or the connection is aborted or goes away.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 type flowControlWindow struct {
@@ -44,12 +65,21 @@ type flowControlWindow struct {
 }
 
 func (s *MuxedStream) Read(p []byte) (n int, err error) {
-	return s.readBuffer.Read(p)
+	n, err = s.readBuffer.Read(p)
+	if err == io.EOF {
+		if resetErr := s.readResetError(); resetErr != nil {
+			return n, resetErr
+		}
+	}
+	return n, err
 }
 
 func (s *MuxedStream) Write(p []byte) (n int, err error) {
 	s.writeLock.Lock()
 	defer s.writeLock.Unlock()
+	if s.resetErr != nil {
+		return 0, s.resetErr
+	}
 	if s.writeEOF {
 		return 0, io.EOF
 	}
@@ -94,6 +124,47 @@ func (s *MuxedStream) CloseWrite() error {
 	return nil
 }
 
+// Reset aborts the stream, sending RST_STREAM with the given error code to the peer. Pending and
+// subsequent reads and writes return a StreamResetError. Resetting a stream more than once has no
+// effect.
+func (s *MuxedStream) Reset(code http2.ErrCode) {
+	if s.reset(StreamResetError{Code: code}) {
+		s.streamErrors.RaiseError(s.streamID, code)
+	}
+}
+
+// reset marks the stream as reset, discarding unsent data and closing the read end. It returns
+// false if the stream was already reset.
+func (s *MuxedStream) reset(err StreamResetError) bool {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if s.resetErr != nil {
+		return false
+	}
+	s.resetErr = err
+	s.writeEOF = true
+	s.writeBuffer.Reset()
+	s.cancel()
+	s.readBuffer.Close()
+	return true
+}
+
+func (s *MuxedStream) getResetError() error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	return s.resetErr
+}
+
+// readResetError returns the reset error if the stream was reset before the peer sent EOF.
+func (s *MuxedStream) readResetError() error {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if s.receivedEOF {
+		return nil
+	}
+	return s.resetErr
+}
+
 func (s *MuxedStream) WriteHeaders(headers []Header) error {
 	s.writeLock.Lock()
 	defer s.writeLock.Unlock()
@@ -105,6 +176,13 @@ func (s *MuxedStream) WriteHeaders(headers []Header) error {
 	return nil
 }
 
+// Context returns the context of the stream. It is cancelled when the peer resets the stream,
+// the stream is reset because of an error, or the connection is aborted or goes away before
+// the stream could complete.
+func (s *MuxedStream) Context() context.Context {
+	return s.ctx
+}
+
 func (s *MuxedStream) FlowControlWindow() *flowControlWindow {
 	s.writeLock.Lock()
 	defer s.writeLock.Unlock()
@@ -119,6 +197,64 @@ func (s *MuxedStream) writeNotify() {
 	s.readyList.Signal(s.streamID)
 }
 
+// startTimers arms the idle and lifetime timers of the stream. A zero duration disables the timer.
+func (s *MuxedStream) startTimers(idleTimeout, maxLifetime time.Duration) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if idleTimeout > 0 {
+		s.idleTimeout = idleTimeout
+		s.idleTimer = time.AfterFunc(idleTimeout, s.timeout)
+	}
+	if maxLifetime > 0 {
+		s.lifetimeTimer = time.AfterFunc(maxLifetime, s.timeout)
+	}
+}
+
+// stopTimers disarms the idle and lifetime timers. It should be called once the stream is closed.
+func (s *MuxedStream) stopTimers() {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+	if s.lifetimeTimer != nil {
+		s.lifetimeTimer.Stop()
+	}
+}
+
+// DisableTimeouts exempts the stream from the idle timeout and maximum lifetime in MuxerConfig, for
+// long-lived streams such as RPC connections.
+func (s *MuxedStream) DisableTimeouts() {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	if s.lifetimeTimer != nil {
+		s.lifetimeTimer.Stop()
+		s.lifetimeTimer = nil
+	}
+}
+
+// markActive postpones the idle timeout. It must be called without holding writeLock.
+func (s *MuxedStream) markActive() {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	s.resetIdleTimer()
+}
+
+// resetIdleTimer must happen while holding writeLock.
+func (s *MuxedStream) resetIdleTimer() {
+	if s.idleTimer != nil {
+		s.idleTimer.Reset(s.idleTimeout)
+	}
+}
+
+func (s *MuxedStream) timeout() {
+	s.Reset(http2.ErrCodeCancel)
+}
+
 // Call by muxreader when it gets a WindowUpdateFrame. This is an update of the peer's
 // receive window (how much data we can send).
 func (s *MuxedStream) replenishSendWindow(bytes uint32) {
@@ -128,6 +264,14 @@ func (s *MuxedStream) replenishSendWindow(bytes uint32) {
 	s.writeLock.Unlock()
 }
 
+// restoreSendWindow gives back the send window consumed by a chunk, without waking the writer. It is
+// used when flow control is left to the transport, as with QUICMuxer.
+func (s *MuxedStream) restoreSendWindow(bytes uint32) {
+	s.writeLock.Lock()
+	s.sendWindow += bytes
+	s.writeLock.Unlock()
+}
+
 // Call by muxreader when it receives a data frame
 func (s *MuxedStream) consumeReceiveWindow(bytes uint32) bool {
 	s.writeLock.Lock()
@@ -199,6 +343,11 @@ func (s *MuxedStream) getChunk() *streamChunk {
 	s.writeLock.Lock()
 	defer s.writeLock.Unlock()
 
+	if s.resetErr != nil {
+		// nothing more is sent on a reset stream
+		return &streamChunk{streamID: s.streamID}
+	}
+
 	chunk := &streamChunk{
 		streamID:     s.streamID,
 		sendHeaders:  !s.headersSent,
@@ -213,6 +362,9 @@ func (s *MuxedStream) getChunk() *streamChunk {
 	writeLen, _ := io.CopyN(&chunk.buffer, &s.writeBuffer, int64(s.sendWindow))
 	//log.Infof("writeLen %d stream %d", writeLen, s.streamID)
 	s.sendWindow -= uint32(writeLen)
+	if writeLen > 0 {
+		s.resetIdleTimer()
+	}
 	s.receiveWindow += s.windowUpdate
 	s.windowUpdate = 0
 	s.headersSent = true
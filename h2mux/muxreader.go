@@ -7,6 +7,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
 	"golang.org/x/net/http2"
 )
 
@@ -34,11 +35,18 @@ type MuxReader struct {
 	initialStreamWindow uint32
 	// The max value for the send window of a stream.
 	streamWindowMax uint32
+	// streamTimeouts resets new streams that are idle or open for too long.
+	streamTimeouts streamTimeouts
+	// goingAway is called on the first GOAWAY from the peer, if set.
+	goingAway      func()
+	goAwayReceived bool
 	// windowMetrics keeps track of min/max/average of send/receive windows for all streams
 	flowControlMetrics *FlowControlMetrics
 	metricsMutex       sync.Mutex
 	// r is a reference to the underlying connection used when shutting down.
 	r io.Closer
+	// streamResets counts RST_STREAM frames received by error code.
+	streamResets *streamResetCounter
 	// rttMeasurement measures RTT based on ping timestamps.
 	rttMeasurement RTTMeasurement
 	rttMutex       sync.Mutex
@@ -111,11 +119,7 @@ func (r *MuxReader) run(parentLogger *log.Entry) error {
 		case *http2.MetaHeadersFrame:
 			err = r.receiveHeaderData(f)
 		case *http2.RSTStreamFrame:
-			streamID := f.Header().StreamID
-			if streamID == 0 {
-				return ErrInvalidStream
-			}
-			r.streams.Delete(streamID)
+			err = r.receiveResetStream(f)
 		case *http2.PingFrame:
 			r.receivePingData(f)
 		case *http2.GoAwayFrame:
@@ -133,6 +137,7 @@ func (r *MuxReader) run(parentLogger *log.Entry) error {
 }
 
 func (r *MuxReader) newMuxedStream(streamID uint32) *MuxedStream {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &MuxedStream{
 		streamID:                streamID,
 		readBuffer:              NewSharedBuffer(),
@@ -141,6 +146,9 @@ func (r *MuxReader) newMuxedStream(streamID uint32) *MuxedStream {
 		receiveWindowMax:        r.streamWindowMax,
 		sendWindow:              r.initialStreamWindow,
 		readyList:               r.readyList,
+		streamErrors:            r.streamErrors,
+		ctx:                     ctx,
+		cancel:                  cancel,
 	}
 }
 
@@ -182,6 +190,13 @@ func (r *MuxReader) receiveHeaderData(frame *http2.MetaHeadersFrame) error {
 		return ErrUnexpectedFrameType
 	}
 	newStream := r.streams.IsPeerStreamID(sid)
+	if frame.Truncated {
+		// The header list is larger than MaxHeaderListSize. Refuse the stream, taking its ID if it's new.
+		if newStream {
+			r.streams.AcquirePeerID(sid)
+		}
+		return r.streamError(sid, http2.ErrCodeProtocol)
+	}
 	if newStream {
 		// header request
 		// TODO support trailers (if stream exists)
@@ -210,6 +225,9 @@ func (r *MuxReader) receiveHeaderData(frame *http2.MetaHeadersFrame) error {
 		headers[i].Value = header.Value
 	}
 	stream.Headers = headers
+	if newStream {
+		r.streamTimeouts.start(stream, headers)
+	}
 	if frame.Header().Flags.Has(http2.FlagHeadersEndStream) {
 		stream.receiveEOF()
 		return nil
@@ -236,6 +254,7 @@ func (r *MuxReader) receiveFrameData(frame *http2.DataFrame, parentLogger *log.E
 	}
 	data := frame.Data()
 	if len(data) > 0 {
+		stream.markActive()
 		_, err = stream.readBuffer.Write(data)
 		if err != nil {
 			return r.streamError(stream.streamID, http2.ErrCodeInternal)
@@ -269,16 +288,41 @@ func (r *MuxReader) receivePingData(frame *http2.PingFrame) {
 	r.flowControlMetrics = r.streams.Metrics()
 }
 
+// Receive a RST_STREAM from the peer. The stream is closed, and pending reads and writes on it fail.
+func (r *MuxReader) receiveResetStream(frame *http2.RSTStreamFrame) error {
+	streamID := frame.Header().StreamID
+	if streamID == 0 {
+		return ErrInvalidStream
+	}
+	r.streamResets.incrementReceived(frame.ErrCode)
+	if stream, ok := r.streams.Get(streamID); ok {
+		stream.reset(StreamResetError{Code: frame.ErrCode, Remote: true})
+	}
+	r.streams.Delete(streamID)
+	return nil
+}
+
 // Receive a GOAWAY from the peer. Gracefully shut down our connection.
 func (r *MuxReader) receiveGoAway(frame *http2.GoAwayFrame) error {
+	if !r.goAwayReceived {
+		r.goAwayReceived = true
+		if r.goingAway != nil {
+			r.goingAway()
+		}
+	}
 	r.Shutdown()
 	// Close all streams above the last processed stream
 	lastStream := r.streams.LastLocalStreamID()
 	for i := frame.LastStreamID + 2; i <= lastStream; i++ {
 		if stream, ok := r.streams.Get(i); ok {
+			stream.cancel()
 			stream.Close()
 		}
 	}
+	if frame.ErrCode != http2.ErrCodeNo {
+		// The peer is going away because of an error, so the remaining streams won't complete either.
+		r.streams.Cancel()
+	}
 	return nil
 }
 
@@ -298,6 +342,9 @@ func (r *MuxReader) updateStreamWindow(frame *http2.WindowUpdateFrame) error {
 
 // Raise a stream processing error, closing the stream. Runs on the write thread.
 func (r *MuxReader) streamError(streamID uint32, e http2.ErrCode) error {
+	if stream, ok := r.streams.Get(streamID); ok {
+		stream.reset(StreamResetError{Code: e})
+	}
 	r.streamErrors.RaiseError(streamID, e)
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"io"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -18,6 +19,8 @@ type MuxWriter struct {
 	streams *activeStreamMap
 	// streamErrors receives stream errors raised by the MuxReader.
 	streamErrors *StreamErrorMap
+	// streamResets counts RST_STREAM frames sent by error code.
+	streamResets *streamResetCounter
 	// readyStreamChan is used to multiplex writable streams onto the single connection.
 	// When a stream becomes writable its ID is sent on this channel.
 	readyStreamChan <-chan uint32
@@ -32,10 +35,19 @@ type MuxWriter struct {
 	pingTimestamp *PingTimestamp
 	// A timer used to measure idle connection time. Reset after sending data.
 	idleTimer *IdleTimer
+	// If deadPeerDetection is true, unacknowledged pings are retried after a timeout derived from
+	// the RTT instead of the idle duration.
+	deadPeerDetection bool
+	// rtt returns the current RTT measurement.
+	rtt func() RTTMeasurement
 	// connActiveChan receives a signal that the connection received some (read) activity.
 	connActiveChan <-chan struct{}
 	// Maximum size of all frames that can be sent on this connection.
 	maxFrameSize uint32
+	// Maximum size of a header list accepted by the peer, or zero if there is no limit.
+	maxHeaderListSize uint32
+	// streamTimeouts resets new streams that are idle or open for too long.
+	streamTimeouts streamTimeouts
 	// headerEncoder is the stateful header encoder for this connection
 	headerEncoder *hpack.Encoder
 	// headerBuffer is the temporary buffer used by headerEncoder.
@@ -92,16 +104,21 @@ func (w *MuxWriter) run(parentLogger *log.Entry) error {
 			if err != nil {
 				return err
 			}
+			if w.deadPeerDetection {
+				w.idleTimer.SetRetryInterval(heartbeatRetryInterval(w.rtt(), w.idleTimer.IdleDuration()))
+			}
 			w.idleTimer.ResetTimer()
 		case <-w.connActiveChan:
 			w.idleTimer.MarkActive()
 		case <-w.streamErrors.GetSignalChan():
 			for streamID, errCode := range w.streamErrors.GetErrors() {
 				logger.WithField("stream", streamID).WithField("code", errCode).Debug("resetting stream")
+				w.streamResets.incrementSent(errCode)
 				err := w.f.WriteRSTStream(streamID, errCode)
 				if err != nil {
 					return err
 				}
+				w.streams.Delete(streamID)
 			}
 			w.idleTimer.MarkActive()
 		case streamRequest := <-w.newStreamChan:
@@ -114,6 +131,7 @@ func (w *MuxWriter) run(parentLogger *log.Entry) error {
 				// reasons why you'd call Shutdown anyway.
 				continue
 			}
+			w.streamTimeouts.start(streamRequest.stream, streamRequest.stream.writeHeaders)
 			if streamRequest.body != nil {
 				go streamRequest.flushBody()
 			}
@@ -122,6 +140,7 @@ func (w *MuxWriter) run(parentLogger *log.Entry) error {
 			if err != nil {
 				return err
 			}
+			close(streamRequest.stream.headersWritten)
 			w.idleTimer.MarkActive()
 		case streamID := <-w.readyStreamChan:
 			streamLogger := logger.WithField("stream", streamID)
@@ -138,11 +157,30 @@ func (w *MuxWriter) run(parentLogger *log.Entry) error {
 	}
 }
 
+// heartbeatRetryInterval returns the time to wait for a ping to be acknowledged, derived from the
+// measured RTT. It is bounded below by minHeartbeatRetryInterval so that jitter on fast links does
+// not cause false positives, and above by the idle duration so that it is never slower than
+// heartbeats without dead peer detection.
+func heartbeatRetryInterval(rtt RTTMeasurement, idleDuration time.Duration) time.Duration {
+	interval := rtt.RetransmissionTimeout()
+	if interval == 0 || interval > idleDuration {
+		return idleDuration
+	}
+	if interval < minHeartbeatRetryInterval {
+		return minHeartbeatRetryInterval
+	}
+	return interval
+}
+
 func (w *MuxWriter) writeStreamData(stream *MuxedStream, logger *log.Entry) error {
 	logger.Debug("writable")
 	chunk := stream.getChunk()
 
 	if chunk.sendHeadersFrame() {
+		if w.maxHeaderListSize != 0 && headerListSize(chunk.headers) > w.maxHeaderListSize {
+			logger.WithField("size", headerListSize(chunk.headers)).Warn("header list too large for peer")
+			return w.resetStream(stream, http2.ErrCodeInternal)
+		}
 		err := w.writeHeaders(chunk.streamID, chunk.headers)
 		if err != nil {
 			logger.WithError(err).Warn("error writing headers")
@@ -179,6 +217,7 @@ func (w *MuxWriter) writeStreamData(stream *MuxedStream, logger *log.Entry) erro
 					// the peer may send data that we no longer want to receive. Force them into the
 					// closed state.
 					logger.Debug("resetting stream")
+					w.streamResets.incrementSent(http2.ErrCodeNo)
 					w.f.WriteRSTStream(chunk.streamID, http2.ErrCodeNo)
 				} else {
 					// Half-open stream transitioned into closed
@@ -193,12 +232,25 @@ func (w *MuxWriter) writeStreamData(stream *MuxedStream, logger *log.Entry) erro
 	return nil
 }
 
+// resetStream resets a stream whose headers can't be sent. Local streams are unknown to the peer
+// until their headers are sent, so RST_STREAM is only sent on peer streams.
+func (w *MuxWriter) resetStream(stream *MuxedStream, code http2.ErrCode) error {
+	stream.reset(StreamResetError{Code: code})
+	w.streams.Delete(stream.streamID)
+	if w.streams.IsLocalStreamID(stream.streamID) {
+		return nil
+	}
+	w.streamResets.incrementSent(code)
+	return w.f.WriteRSTStream(stream.streamID, code)
+}
+
 func (w *MuxWriter) encodeHeaders(headers []Header) ([]byte, error) {
 	w.headerBuffer.Reset()
 	for _, header := range headers {
 		err := w.headerEncoder.WriteField(hpack.HeaderField{
-			Name:  header.Name,
-			Value: header.Value,
+			Name:      header.Name,
+			Value:     header.Value,
+			Sensitive: isSensitiveHeader(header.Name),
 		})
 		if err != nil {
 			return nil, err
@@ -236,3 +288,23 @@ func (w *MuxWriter) writeHeaders(streamID uint32, headers []Header) error {
 	}
 	return err
 }
+
+// headerListSize returns the size of a header list as defined for SETTINGS_MAX_HEADER_LIST_SIZE.
+func headerListSize(headers []Header) uint32 {
+	var size uint32
+	for _, header := range headers {
+		size += hpack.HeaderField{Name: header.Name, Value: header.Value}.Size()
+	}
+	return size
+}
+
+// isSensitiveHeader returns true for headers that carry credentials. They are encoded as never
+// indexed, so that they are not added to compression tables that could be probed for them, such as
+// in the CRIME attack.
+func isSensitiveHeader(name string) bool {
+	switch name {
+	case "authorization", "cookie":
+		return true
+	}
+	return strings.HasPrefix(name, "cf-access-")
+}
@@ -0,0 +1,524 @@
+package h2mux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// QUICProtocol is the ALPN protocol negotiated by muxed QUIC connections.
+	QUICProtocol = "h2mux-quic"
+
+	// maxQUICIncomingStreams is the number of concurrent streams the peer may open. h2mux does not
+	// limit concurrent streams, so this is set well above what a connection is expected to carry.
+	maxQUICIncomingStreams int64 = 1 << 16
+)
+
+// QUICMuxer carries MuxedStreams over a QUIC connection. Each MuxedStream is mapped to a bidirectional
+// QUIC stream, so that a lost packet only stalls the stream it belongs to rather than the whole
+// connection.
+//
+// Each direction of a stream starts with a header block, HPACK encoded and prefixed by its length
+// as a 32-bit big-endian integer, followed by the body. A FIN ends the body like END_STREAM, and
+// RST_STREAM is replaced by cancelling both directions of the QUIC stream with the HTTP/2 error code.
+// Flow control and heartbeats are left to QUIC.
+type QUICMuxer struct {
+	// conn is the QUIC connection carrying the streams.
+	conn *quic.Conn
+	// config is the MuxerConfig given in NewQUICMuxer.
+	config MuxerConfig
+	logger *log.Entry
+	// abortChan is closed when the connection goes away.
+	abortChan chan struct{}
+	// abortOnce is used to ensure abortChan is closed once only.
+	abortOnce sync.Once
+	// readyList is used to signal writable streams.
+	readyList *ReadyList
+	// streams tracks currently-open streams.
+	streams *activeStreamMap
+	// quicStreams tracks the QUIC streams of MuxedStreams that are still being read or written.
+	quicStreams     map[uint32]*quicStream
+	quicStreamsLock sync.Mutex
+	// streamErrors is used to reset streams.
+	streamErrors *StreamErrorMap
+	// streamResets counts streams reset by either side.
+	streamResets *streamResetCounter
+	// streamTimeouts resets streams that are idle or open for too long.
+	streamTimeouts streamTimeouts
+	// explicitShutdown records whether the QUICMuxer is closing because Shutdown was called, or due to
+	// another error.
+	explicitShutdown *BooleanFuse
+}
+
+// quicStream is a MuxedStream together with the QUIC stream carrying it.
+type quicStream struct {
+	stream *MuxedStream
+	qs     *quic.Stream
+	// writable is signalled when the stream has data, headers or EOF to send, or is reset.
+	writable Signal
+}
+
+// NewQUICConfig returns the QUIC configuration for a muxed connection. Keep-alives are sent after
+// HeartbeatInterval, and the connection is closed once MaxHeartbeats intervals pass without
+// hearing from the peer.
+func NewQUICConfig(config MuxerConfig) *quic.Config {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	idleDuration := config.HeartbeatInterval
+	if idleDuration < defaultTimeout {
+		idleDuration = defaultTimeout
+	}
+	maxRetries := config.MaxHeartbeats
+	if maxRetries == 0 {
+		maxRetries = defaultRetries
+	}
+	return &quic.Config{
+		HandshakeIdleTimeout: timeout,
+		KeepAlivePeriod:      idleDuration,
+		MaxIdleTimeout:       idleDuration * time.Duration(maxRetries),
+		MaxIncomingStreams:   maxQUICIncomingStreams,
+	}
+}
+
+// NewQUICMuxer returns a QUICMuxer for an established QUIC connection, which should have been
+// configured with NewQUICConfig and negotiated QUICProtocol. Streams can be opened once Serve is
+// running.
+func NewQUICMuxer(conn *quic.Conn, config MuxerConfig) *QUICMuxer {
+	if config.Logger == nil {
+		config.Logger = log.New()
+	}
+	if config.HeaderTableSize == 0 {
+		config.HeaderTableSize = defaultHeaderTableSize
+	}
+	if config.MaxHeaderListSize == 0 {
+		config.MaxHeaderListSize = defaultMaxHeaderListSize
+	}
+	return &QUICMuxer{
+		conn:   conn,
+		config: config,
+		logger: config.Logger.WithFields(log.Fields{
+			"name":      config.Name,
+			"subsystem": "mux",
+		}),
+		abortChan:        make(chan struct{}),
+		readyList:        NewReadyList(),
+		streams:          newActiveStreamMap(config.IsClient),
+		quicStreams:      make(map[uint32]*quicStream),
+		streamErrors:     NewStreamErrorMap(),
+		streamResets:     newStreamResetCounter(),
+		streamTimeouts:   newStreamTimeouts(config),
+		explicitShutdown: NewBooleanFuse(),
+	}
+}
+
+// Serve accepts streams opened by the peer until the connection is closed.
+func (m *QUICMuxer) Serve() error {
+	defer m.logger.Debug("event loop finished")
+	go m.dispatch()
+	var err error
+	for {
+		var qs *quic.Stream
+		qs, err = m.conn.AcceptStream(context.Background())
+		if err != nil {
+			break
+		}
+		streamID := quicStreamID(qs.StreamID())
+		if ok, errCode := m.streams.AcquirePeerID(streamID); !ok {
+			// refuse new streams while shutting down
+			cancelQUICStream(qs, errCode)
+			continue
+		}
+		stream := m.newMuxedStream(streamID)
+		if !m.streams.Set(stream) {
+			cancelQUICStream(qs, http2.ErrCodeInternal)
+			continue
+		}
+		m.startStream(stream, qs, true)
+	}
+	m.explicitShutdown.Fuse(false)
+	m.abort()
+	if m.explicitShutdown.Value() && isQUICConnectionClosedError(err) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting new streams, and closes the connection once the open streams finish.
+func (m *QUICMuxer) Shutdown() {
+	m.explicitShutdown.Fuse(true)
+	done := m.streams.Shutdown()
+	if done == nil {
+		// already shutting down
+		return
+	}
+	go func() {
+		select {
+		case <-done:
+			m.conn.CloseWithError(quic.ApplicationErrorCode(http2.ErrCodeNo), "")
+		case <-m.abortChan:
+		}
+	}()
+}
+
+// Abort closes the connection without waiting for the open streams, which are reset.
+func (m *QUICMuxer) Abort() {
+	m.explicitShutdown.Fuse(true)
+	m.abort()
+}
+
+// isQUICConnectionClosedError is true if we closed the connection without an error.
+func isQUICConnectionClosedError(err error) bool {
+	appErr, ok := err.(*quic.ApplicationError)
+	return ok && !appErr.Remote && appErr.ErrorCode == quic.ApplicationErrorCode(http2.ErrCodeNo)
+}
+
+// OpenStream opens a new data stream with the given headers.
+func (m *QUICMuxer) OpenStream(headers []Header, body io.Reader) (*MuxedStream, error) {
+	return m.OpenStreamContext(context.Background(), headers, body)
+}
+
+// OpenStreamContext opens a new data stream with the given headers, giving up if ctx is done
+// before the peer responds.
+func (m *QUICMuxer) OpenStreamContext(ctx context.Context, headers []Header, body io.Reader) (*MuxedStream, error) {
+	select {
+	case <-m.abortChan:
+		return nil, ErrConnectionClosed
+	default:
+	}
+	qs, err := m.conn.OpenStreamSync(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, ErrConnectionClosed
+	}
+	stream := m.newMuxedStream(quicStreamID(qs.StreamID()))
+	stream.responseHeadersReceived = make(chan struct{})
+	stream.writeHeaders = headers
+	if !m.streams.Set(stream) {
+		// Race between OpenStream and Shutdown, and Shutdown won.
+		cancelQUICStream(qs, http2.ErrCodeRefusedStream)
+		return nil, ErrConnectionClosed
+	}
+	m.streamTimeouts.start(stream, headers)
+	m.startStream(stream, qs, false)
+	if body != nil {
+		streamRequest := MuxedStreamRequest{stream: stream, body: body}
+		go streamRequest.flushBody()
+	}
+	return waitForResponseHeaders(ctx, stream, m.abortChan)
+}
+
+// Return the round-trip time measured by QUIC. The maximum is not tracked.
+func (m *QUICMuxer) RTT() RTTMeasurement {
+	stats := m.conn.ConnectionStats()
+	return RTTMeasurement{
+		Current:  stats.LatestRTT,
+		Min:      stats.MinRTT,
+		Smoothed: stats.SmoothedRTT,
+		Variance: stats.MeanDeviation,
+	}
+}
+
+// Flow control is handled by QUIC, so there are no window sizes to report.
+func (m *QUICMuxer) FlowControlMetrics() *FlowControlMetrics {
+	return &FlowControlMetrics{}
+}
+
+// Return the number of streams reset by either side, by error code
+func (m *QUICMuxer) StreamResetMetrics() *StreamResetMetrics {
+	return m.streamResets.Metrics()
+}
+
+// Heartbeats are QUIC keep-alives, which are not counted.
+func (m *QUICMuxer) TimerRetries() uint64 {
+	return 0
+}
+
+func (m *QUICMuxer) abort() {
+	m.abortOnce.Do(func() {
+		close(m.abortChan)
+		m.streams.Abort()
+		m.conn.CloseWithError(quic.ApplicationErrorCode(http2.ErrCodeInternal), "")
+	})
+}
+
+// quicStreamID maps the ID of a bidirectional QUIC stream to an HTTP/2 stream ID, so that streams
+// opened by the client are odd and streams opened by the server are even.
+func quicStreamID(id quic.StreamID) uint32 {
+	return uint32(id/4)*2 + 1 + uint32(id%2)
+}
+
+func cancelQUICStream(qs *quic.Stream, errCode http2.ErrCode) {
+	qs.CancelWrite(quic.StreamErrorCode(errCode))
+	qs.CancelRead(quic.StreamErrorCode(errCode))
+}
+
+func (m *QUICMuxer) newMuxedStream(streamID uint32) *MuxedStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &MuxedStream{
+		streamID:   streamID,
+		readBuffer: NewSharedBuffer(),
+		// QUIC does its own flow control; writes block when the peer's window is exhausted.
+		receiveWindow:           maxWindowSize,
+		receiveWindowCurrentMax: maxWindowSize,
+		receiveWindowMax:        maxWindowSize,
+		sendWindow:              maxWindowSize,
+		readyList:               m.readyList,
+		streamErrors:            m.streamErrors,
+		ctx:                     ctx,
+		cancel:                  cancel,
+	}
+}
+
+// startStream starts reading and writing a stream. The stream stops being tracked once both
+// directions are done.
+func (m *QUICMuxer) startStream(stream *MuxedStream, qs *quic.Stream, peerStream bool) {
+	s := &quicStream{stream: stream, qs: qs, writable: NewSignal()}
+	m.quicStreamsLock.Lock()
+	m.quicStreams[stream.streamID] = s
+	m.quicStreamsLock.Unlock()
+	logger := m.logger.WithField("stream", stream.streamID)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.readStream(s, peerStream, logger)
+	}()
+	go func() {
+		defer wg.Done()
+		m.writeStream(s, logger)
+	}()
+	go func() {
+		wg.Wait()
+		m.quicStreamsLock.Lock()
+		delete(m.quicStreams, stream.streamID)
+		m.quicStreamsLock.Unlock()
+		m.streams.Delete(stream.streamID)
+		logger.Debug("stream closed")
+	}()
+	if !peerStream {
+		// send the request headers
+		s.writable.Signal()
+	}
+}
+
+func (m *QUICMuxer) getQUICStream(streamID uint32) (*quicStream, bool) {
+	m.quicStreamsLock.Lock()
+	defer m.quicStreamsLock.Unlock()
+	s, ok := m.quicStreams[streamID]
+	return s, ok
+}
+
+// dispatch wakes up the writer of streams that become writable, and cancels reset streams.
+func (m *QUICMuxer) dispatch() {
+	for {
+		select {
+		case <-m.abortChan:
+			return
+		case streamID := <-m.readyList.ReadyChannel():
+			if s, ok := m.getQUICStream(streamID); ok {
+				s.writable.Signal()
+			}
+		case <-m.streamErrors.GetSignalChan():
+			for streamID, errCode := range m.streamErrors.GetErrors() {
+				s, ok := m.getQUICStream(streamID)
+				if !ok {
+					continue
+				}
+				m.logger.WithField("stream", streamID).WithField("code", errCode).Debug("resetting stream")
+				m.streamResets.incrementSent(errCode)
+				cancelQUICStream(s.qs, errCode)
+				s.writable.Signal()
+			}
+		}
+	}
+}
+
+// readStream reads the header block and body of a stream. Streams opened by the peer are passed to
+// the handler once their headers have been read.
+func (m *QUICMuxer) readStream(s *quicStream, peerStream bool, logger *log.Entry) {
+	stream := s.stream
+	headers, err := readHeaderBlock(s.qs, m.config.HeaderTableSize, m.config.MaxHeaderListSize)
+	if err == ErrInvalidHeaderBlock {
+		logger.WithError(err).Debug("invalid header block")
+		stream.Reset(http2.ErrCodeProtocol)
+		return
+	} else if err != nil {
+		m.receiveStreamError(s, err)
+		return
+	}
+	stream.Headers = headers
+	if peerStream {
+		m.streamTimeouts.start(stream, headers)
+		go m.handleStream(stream)
+	} else {
+		close(stream.responseHeadersReceived)
+	}
+	buf := make([]byte, defaultFrameSize)
+	for {
+		n, err := s.qs.Read(buf)
+		if n > 0 {
+			stream.markActive()
+			if _, err := stream.readBuffer.Write(buf[:n]); err != nil {
+				// the read side was closed; the peer should stop sending
+				s.qs.CancelRead(quic.StreamErrorCode(http2.ErrCodeNo))
+				return
+			}
+		}
+		if err == io.EOF {
+			stream.receiveEOF()
+			logger.Debug("shutdown receive side")
+			return
+		} else if err != nil {
+			m.receiveStreamError(s, err)
+			return
+		}
+	}
+}
+
+// receiveStreamError handles an error reading from a QUIC stream. If the peer reset the stream,
+// pending and subsequent reads and writes on it fail. Local resets and connection errors are
+// handled by the goroutine that caused them.
+func (m *QUICMuxer) receiveStreamError(s *quicStream, err error) {
+	streamErr, ok := err.(*quic.StreamError)
+	if !ok || !streamErr.Remote {
+		return
+	}
+	errCode := http2.ErrCode(streamErr.ErrorCode)
+	m.streamResets.incrementReceived(errCode)
+	if s.stream.reset(StreamResetError{Code: errCode, Remote: true}) {
+		s.qs.CancelWrite(streamErr.ErrorCode)
+	}
+	s.writable.Signal()
+}
+
+func (m *QUICMuxer) handleStream(stream *MuxedStream) {
+	defer stream.Close()
+	m.config.Handler.ServeStream(stream)
+}
+
+// writeStream writes the header block and body of a stream as they become available, until EOF is
+// sent or the stream is reset.
+func (m *QUICMuxer) writeStream(s *quicStream, logger *log.Entry) {
+	stream := s.stream
+	for {
+		select {
+		case <-s.writable.WaitChannel():
+		case <-m.abortChan:
+			return
+		}
+		if stream.getResetError() != nil {
+			return
+		}
+		chunk := stream.getChunk()
+		if chunk.sendHeadersFrame() {
+			// a header block is always sent, even if empty, so that the peer can find the body
+			if err := writeHeaderBlock(s.qs, chunk.headers); err != nil {
+				logger.WithError(err).Debug("error writing headers")
+				return
+			}
+			logger.Debug("output headers")
+		}
+		if !chunk.sendDataFrame() {
+			continue
+		}
+		n, err := s.qs.Write(chunk.buffer.Bytes())
+		stream.restoreSendWindow(uint32(n))
+		if err != nil {
+			logger.WithError(err).Debug("error writing data")
+			return
+		}
+		if chunk.eof {
+			s.qs.Close()
+			if stream.readBuffer.Closed() && !stream.gotReceiveEOF() {
+				// we no longer want to receive data the peer may send
+				s.qs.CancelRead(quic.StreamErrorCode(http2.ErrCodeNo))
+			}
+			logger.Debug("closing stream write side")
+			return
+		}
+	}
+}
+
+// writeHeaderBlock writes a length-prefixed header block. Every block is encoded with a new HPACK
+// context, as QUIC streams are delivered independently and cannot share a dynamic table. The
+// dynamic table is left empty, as there are no SETTINGS to learn the size the peer decodes with.
+func writeHeaderBlock(w io.Writer, headers []Header) error {
+	var block bytes.Buffer
+	block.Write([]byte{0, 0, 0, 0})
+	encoder := hpack.NewEncoder(&block)
+	encoder.SetMaxDynamicTableSizeLimit(0)
+	for _, header := range headers {
+		err := encoder.WriteField(hpack.HeaderField{
+			Name:      header.Name,
+			Value:     header.Value,
+			Sensitive: isSensitiveHeader(header.Name),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	encoded := block.Bytes()
+	binary.BigEndian.PutUint32(encoded, uint32(len(encoded)-4))
+	_, err := w.Write(encoded)
+	return err
+}
+
+// readHeaderBlock reads a header block written by writeHeaderBlock, decoding it with a dynamic table
+// of up to headerTableSize. It returns ErrInvalidHeaderBlock if the block is malformed, the stream
+// ends early, or the header list is larger than maxHeaderListSize, as defined for
+// SETTINGS_MAX_HEADER_LIST_SIZE.
+func readHeaderBlock(r io.Reader, headerTableSize, maxHeaderListSize uint32) ([]Header, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, headerBlockReadError(err)
+	}
+	// A block is never larger than the header list it encodes
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxHeaderListSize {
+		return nil, ErrInvalidHeaderBlock
+	}
+	block := make([]byte, size)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, headerBlockReadError(err)
+	}
+	listSize := uint32(0)
+	var fields []hpack.HeaderField
+	decoder := hpack.NewDecoder(headerTableSize, func(field hpack.HeaderField) {
+		listSize += field.Size()
+		if listSize <= maxHeaderListSize {
+			fields = append(fields, field)
+		}
+	})
+	if _, err := decoder.Write(block); err != nil {
+		return nil, ErrInvalidHeaderBlock
+	}
+	if err := decoder.Close(); err != nil || listSize > maxHeaderListSize {
+		return nil, ErrInvalidHeaderBlock
+	}
+	headers := make([]Header, len(fields))
+	for i, field := range fields {
+		headers[i].Name = field.Name
+		headers[i].Value = field.Value
+	}
+	return headers, nil
+}
+
+func headerBlockReadError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrInvalidHeaderBlock
+	}
+	return err
+}
@@ -0,0 +1,345 @@
+package h2mux
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+)
+
+type QUICMuxerPair struct {
+	OriginMuxConfig MuxerConfig
+	OriginMux       *QUICMuxer
+	EdgeMuxConfig   MuxerConfig
+	EdgeMux         *QUICMuxer
+	doneC           chan struct{}
+}
+
+func NewQUICMuxerPair() *QUICMuxerPair {
+	return &QUICMuxerPair{
+		OriginMuxConfig: MuxerConfig{Timeout: time.Second, IsClient: true, Name: "origin"},
+		EdgeMuxConfig:   MuxerConfig{Timeout: time.Second, IsClient: false, Name: "edge"},
+		doneC:           make(chan struct{}),
+	}
+}
+
+// Handshake connects the origin to an edge listening on the loopback interface.
+func (p *QUICMuxerPair) Handshake(t *testing.T) {
+	listener, err := quic.ListenAddr("127.0.0.1:0", loopbackServerTLSConfig(t), NewQUICConfig(p.EdgeMuxConfig))
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer listener.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	edgeConnC := make(chan *quic.Conn, 1)
+	go func() {
+		conn, err := listener.Accept(ctx)
+		if err != nil {
+			t.Errorf("error accepting connection: %s", err)
+		}
+		edgeConnC <- conn
+	}()
+	clientTLSConfig := &tls.Config{InsecureSkipVerify: true, NextProtos: []string{QUICProtocol}}
+	originConn, err := quic.DialAddr(ctx, listener.Addr().String(), clientTLSConfig, NewQUICConfig(p.OriginMuxConfig))
+	if err != nil {
+		t.Fatalf("error dialing: %s", err)
+	}
+	edgeConn := <-edgeConnC
+	if edgeConn == nil {
+		t.FailNow()
+	}
+	p.OriginMux = NewQUICMuxer(originConn, p.OriginMuxConfig)
+	p.EdgeMux = NewQUICMuxer(edgeConn, p.EdgeMuxConfig)
+}
+
+func (p *QUICMuxerPair) HandshakeAndServe(t *testing.T) {
+	p.Handshake(t)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		p.EdgeMux.Serve()
+		p.OriginMux.Shutdown()
+		wg.Done()
+	}()
+	go func() {
+		p.OriginMux.Serve()
+		p.EdgeMux.Shutdown()
+		wg.Done()
+	}()
+	go func() {
+		// notify when both muxes have stopped serving
+		wg.Wait()
+		close(p.doneC)
+	}()
+}
+
+func (p *QUICMuxerPair) Wait(t *testing.T) {
+	select {
+	case <-p.doneC:
+		return
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for shutdown")
+	}
+}
+
+func loopbackServerTLSConfig(t *testing.T) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("error creating certificate: %s", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert}, PrivateKey: key}},
+		NextProtos:   []string{QUICProtocol},
+	}
+}
+
+func TestQUICSingleStream(t *testing.T) {
+	muxPair := NewQUICMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		if len(stream.Headers) != 1 || stream.Headers[0] != (Header{Name: "test-header", Value: "headerValue"}) {
+			t.Fatalf("unexpected request headers: %v", stream.Headers)
+		}
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		requestBody, err := ioutil.ReadAll(stream)
+		if err != nil {
+			t.Fatalf("error reading request body: %s", err)
+		}
+		stream.Write(requestBody)
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		bytes.NewBufferString("Hello world"),
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	if len(stream.Headers) != 1 || stream.Headers[0] != (Header{Name: "response-header", Value: "responseValue"}) {
+		t.Fatalf("unexpected response headers: %v", stream.Headers)
+	}
+	responseBody, err := ioutil.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("error from (*MuxedStream).Read: %s", err)
+	}
+	if string(responseBody) != "Hello world" {
+		t.Fatalf("expected response body %s, got %s", "Hello world", responseBody)
+	}
+	muxPair.EdgeMux.Shutdown()
+	muxPair.Wait(t)
+}
+
+func TestQUICMultipleStreams(t *testing.T) {
+	muxPair := NewQUICMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-token", Value: stream.Headers[0].Value},
+		})
+		stream.Write([]byte(stream.Headers[0].Value))
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	maxStreams := 64
+	errorsC := make(chan error, maxStreams)
+	var wg sync.WaitGroup
+	wg.Add(maxStreams)
+	for i := 0; i < maxStreams; i++ {
+		go func(tokenId int) {
+			defer wg.Done()
+			token := strconv.Itoa(tokenId)
+			stream, err := muxPair.EdgeMux.OpenStream([]Header{Header{Name: "client-token", Value: token}}, nil)
+			if err != nil {
+				errorsC <- err
+				return
+			}
+			if len(stream.Headers) != 1 || stream.Headers[0].Value != token {
+				errorsC <- io.ErrUnexpectedEOF
+				return
+			}
+			stream.CloseWrite()
+			responseBody, err := ioutil.ReadAll(stream)
+			if err != nil {
+				errorsC <- err
+				return
+			}
+			if string(responseBody) != token {
+				errorsC <- io.ErrUnexpectedEOF
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errorsC)
+	for err := range errorsC {
+		t.Fatalf("stream error: %s", err)
+	}
+	muxPair.EdgeMux.Shutdown()
+	muxPair.Wait(t)
+}
+
+func TestQUICStreamResetByHandler(t *testing.T) {
+	resetC := make(chan struct{})
+	muxPair := NewQUICMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		stream.Write([]byte("Hello world"))
+		<-resetC
+		stream.Reset(http2.ErrCodeInternal)
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	responseBody := make([]byte, 11)
+	if _, err := io.ReadFull(stream, responseBody); err != nil {
+		t.Fatalf("error from (*MuxedStream).Read: %s", err)
+	}
+	close(resetC)
+	_, err = ioutil.ReadAll(stream)
+	if err != (StreamResetError{Code: http2.ErrCodeInternal, Remote: true}) {
+		t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+	}
+	if n := muxPair.EdgeMux.StreamResetMetrics().Received[http2.ErrCodeInternal]; n != 1 {
+		t.Fatalf("expected 1 received reset, got %d", n)
+	}
+	if n := muxPair.OriginMux.StreamResetMetrics().Sent[http2.ErrCodeInternal]; n != 1 {
+		t.Fatalf("expected 1 sent reset, got %d", n)
+	}
+}
+
+func TestQUICStreamResetByClient(t *testing.T) {
+	handlerC := make(chan error)
+	muxPair := NewQUICMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		stream.WriteHeaders([]Header{
+			Header{Name: "response-header", Value: "responseValue"},
+		})
+		_, err := ioutil.ReadAll(stream)
+		handlerC <- err
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	stream, err := muxPair.EdgeMux.OpenStream(
+		[]Header{Header{Name: "test-header", Value: "headerValue"}},
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("error in OpenStream: %s", err)
+	}
+	stream.Reset(http2.ErrCodeCancel)
+	select {
+	case err := <-handlerC:
+		if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for handler to observe reset")
+	}
+	select {
+	case <-stream.Context().Done():
+	default:
+		t.Fatal("stream context was not cancelled")
+	}
+}
+
+func TestQUICOpenAfterShutdown(t *testing.T) {
+	muxPair := NewQUICMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(EchoHandler)
+	muxPair.HandshakeAndServe(t)
+	muxPair.EdgeMux.Shutdown()
+	muxPair.Wait(t)
+
+	_, err := muxPair.EdgeMux.OpenStream([]Header{{Name: "test-header", Value: "headerValue"}}, nil)
+	if err != ErrConnectionClosed {
+		t.Fatalf("unexpected error in OpenStream: %v", err)
+	}
+}
+
+func TestHeaderBlock(t *testing.T) {
+	headers := []Header{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: "/"},
+		{Name: "x-empty", Value: ""},
+	}
+	var buf bytes.Buffer
+	if err := writeHeaderBlock(&buf, headers); err != nil {
+		t.Fatalf("error writing header block: %s", err)
+	}
+	encoded := buf.Bytes()
+	decoded, err := readHeaderBlock(bytes.NewReader(encoded), defaultHeaderTableSize, defaultMaxHeaderListSize)
+	if err != nil {
+		t.Fatalf("error reading header block: %s", err)
+	}
+	if len(decoded) != len(headers) {
+		t.Fatalf("expected %d headers, got %d", len(headers), len(decoded))
+	}
+	for i := range headers {
+		if decoded[i] != headers[i] {
+			t.Fatalf("expected header %v, got %v", headers[i], decoded[i])
+		}
+	}
+	if _, err := readHeaderBlock(bytes.NewReader(encoded[:len(encoded)-1]), defaultHeaderTableSize, defaultMaxHeaderListSize); err != ErrInvalidHeaderBlock {
+		t.Fatalf("expected %v for a truncated header block, got %v", ErrInvalidHeaderBlock, err)
+	}
+	if _, err := readHeaderBlock(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff}), defaultHeaderTableSize, defaultMaxHeaderListSize); err != ErrInvalidHeaderBlock {
+		t.Fatalf("expected %v for an oversized header block, got %v", ErrInvalidHeaderBlock, err)
+	}
+	// blocks don't use the dynamic table, so any table size decodes them
+	if _, err := readHeaderBlock(bytes.NewReader(encoded), 0, defaultMaxHeaderListSize); err != nil {
+		t.Fatalf("error reading header block without a dynamic table: %s", err)
+	}
+	// each header counts 32 bytes on top of its name and value
+	listSize := uint32(len(":method") + len("GET") + len(":path") + len("/") + len("x-empty") + 3*32)
+	if _, err := readHeaderBlock(bytes.NewReader(encoded), defaultHeaderTableSize, listSize); err != nil {
+		t.Fatalf("error reading header block at the header list size limit: %s", err)
+	}
+	if _, err := readHeaderBlock(bytes.NewReader(encoded), defaultHeaderTableSize, listSize-1); err != ErrInvalidHeaderBlock {
+		t.Fatalf("expected %v for an oversized header list, got %v", ErrInvalidHeaderBlock, err)
+	}
+}
+
+func TestQUICStreamID(t *testing.T) {
+	// client-initiated bidirectional streams are 0, 4, 8..., server-initiated ones 1, 5, 9...
+	for quicID, streamID := range map[quic.StreamID]uint32{0: 1, 4: 3, 8: 5, 1: 2, 5: 4, 9: 6} {
+		if id := quicStreamID(quicID); id != streamID {
+			t.Fatalf("expected QUIC stream %d to map to %d, got %d", quicID, streamID, id)
+		}
+	}
+}
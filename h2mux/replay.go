@@ -0,0 +1,264 @@
+package h2mux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// replayStreamTimeout is how long to wait for the Muxer to create a stream that frames in the capture
+// refer to.
+const replayStreamTimeout = time.Second
+
+// Replay feeds a capture written by FrameCapture to a new Muxer, to reproduce its behaviour offline.
+// The frames the capturing Muxer read are sent to the new Muxer as they were received. The streams
+// the capturing Muxer opened are opened again, and the headers, data and resets it sent on streams
+// are sent again, so that the new Muxer goes through the same sequence of events as far as possible.
+// Frames the Muxer generates itself, such as pings and window updates, are not replayed.
+//
+// If realtime is true, the frames are replayed with the delays between them in the capture.
+// Otherwise they are replayed as fast as possible. Handler and IsClient in config are ignored. Set
+// config.Capture to record the frames of the new Muxer.
+//
+// Replay returns the error if the Muxer fails before the end of the capture, and nil otherwise.
+func Replay(capture io.Reader, config MuxerConfig, realtime bool) error {
+	header, frames, err := ReadCapture(capture)
+	if err != nil {
+		return err
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	muxerConn, peerConn := net.Pipe()
+	defer peerConn.Close()
+	r := newReplayer(peerConn)
+	defer close(r.done)
+	config.IsClient = header.IsClient
+	config.Handler = MuxedStreamFunc(r.serveStream)
+
+	// The frames written by the Muxer are only of interest in its own capture.
+	go io.Copy(ioutil.Discard, peerConn)
+	feedErrC := make(chan error, 1)
+	go func() {
+		feedErrC <- r.feed(frames, realtime)
+	}()
+	muxer, err := Handshake(muxerConn, muxerConn, config)
+	if err != nil {
+		return err
+	}
+	r.setMuxer(muxer)
+	serveErrC := make(chan error, 1)
+	go func() {
+		serveErrC <- muxer.Serve()
+	}()
+	select {
+	case err := <-serveErrC:
+		return err
+	case err := <-feedErrC:
+		if err != nil {
+			return err
+		}
+	}
+	// Give the Muxer a chance to act on the last frames before closing the connection.
+	select {
+	case err := <-serveErrC:
+		return err
+	case <-time.After(config.Timeout):
+	}
+	muxer.Shutdown()
+	peerConn.Close()
+	<-serveErrC
+	return nil
+}
+
+// replayer plays the peer of the Muxer in Replay, and acts on behalf of the Muxer's user.
+type replayer struct {
+	framer        *http2.Framer
+	headerBuffer  bytes.Buffer
+	headerEncoder *hpack.Encoder
+	// done is closed when the replay is over, to end the handlers of peer streams.
+	done chan struct{}
+
+	sync.Mutex
+	muxer      *Muxer
+	muxerReady chan struct{}
+	streams    map[uint32]*MuxedStream
+}
+
+func newReplayer(peerConn net.Conn) *replayer {
+	r := &replayer{
+		framer:     http2.NewFramer(peerConn, nil),
+		done:       make(chan struct{}),
+		muxerReady: make(chan struct{}),
+		streams:    make(map[uint32]*MuxedStream),
+	}
+	r.headerEncoder = hpack.NewEncoder(&r.headerBuffer)
+	return r
+}
+
+func (r *replayer) setMuxer(muxer *Muxer) {
+	r.Lock()
+	defer r.Unlock()
+	r.muxer = muxer
+	close(r.muxerReady)
+}
+
+// getMuxer waits for the handshake to complete. It returns nil if the replay ends first.
+func (r *replayer) getMuxer() *Muxer {
+	select {
+	case <-r.muxerReady:
+	case <-r.done:
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	return r.muxer
+}
+
+// serveStream keeps peer streams open until the end of the replay, as the frames sent on them are
+// replayed from the capture.
+func (r *replayer) serveStream(stream *MuxedStream) error {
+	select {
+	case <-stream.Context().Done():
+	case <-r.done:
+	}
+	return nil
+}
+
+func (r *replayer) feed(frames []CapturedFrame, realtime bool) error {
+	var last time.Time
+	for _, frame := range frames {
+		if realtime && !last.IsZero() {
+			time.Sleep(frame.Time.Sub(last))
+		}
+		last = frame.Time
+		var err error
+		if frame.Dir == CaptureRead {
+			err = r.writeFrame(frame)
+		} else {
+			err = r.replayWrite(frame)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFrame sends a frame read by the capturing Muxer to the new one.
+func (r *replayer) writeFrame(frame CapturedFrame) error {
+	frameType, _ := frameTypeByName(frame.Type)
+	if frameType != http2.FrameHeaders {
+		return r.framer.WriteRawFrame(frameType, frame.Flags, frame.StreamID, frame.Payload)
+	}
+	r.headerBuffer.Reset()
+	for _, header := range frame.Headers {
+		r.headerEncoder.WriteField(hpack.HeaderField{Name: header.Name, Value: header.Value})
+	}
+	encodedHeaders := r.headerBuffer.Bytes()
+	endStream := frame.Flags.Has(http2.FlagHeadersEndStream)
+	continuation := false
+	for {
+		blockFragment := encodedHeaders
+		endHeaders := len(encodedHeaders) <= int(defaultFrameSize)
+		if !endHeaders {
+			blockFragment = blockFragment[:defaultFrameSize]
+		}
+		encodedHeaders = encodedHeaders[len(blockFragment):]
+		var err error
+		if continuation {
+			err = r.framer.WriteContinuation(frame.StreamID, endHeaders, blockFragment)
+		} else {
+			err = r.framer.WriteHeaders(http2.HeadersFrameParam{
+				StreamID:      frame.StreamID,
+				EndStream:     endStream,
+				EndHeaders:    endHeaders,
+				BlockFragment: blockFragment,
+			})
+		}
+		if err != nil || endHeaders {
+			return err
+		}
+		continuation = true
+	}
+}
+
+// replayWrite repeats the action that led the capturing Muxer to write a frame. Streams that can't
+// be found are skipped, as they may have been closed earlier than in the capture.
+func (r *replayer) replayWrite(frame CapturedFrame) error {
+	frameType, _ := frameTypeByName(frame.Type)
+	switch frameType {
+	case http2.FrameHeaders, http2.FrameData, http2.FrameRSTStream, http2.FrameGoAway:
+	default:
+		return nil
+	}
+	muxer := r.getMuxer()
+	if muxer == nil {
+		return ErrConnectionClosed
+	}
+	switch frameType {
+	case http2.FrameHeaders:
+		isLocal := (frame.StreamID%2 == 1) == muxer.config.IsClient
+		if isLocal && !muxer.streams.IsLocalStreamID(frame.StreamID) {
+			if err := muxer.requestStream(context.Background(), muxer.newLocalStream(frame.Headers), nil); err != nil {
+				return err
+			}
+			r.getStream(frame.StreamID)
+			return nil
+		}
+		if stream := r.getStream(frame.StreamID); stream != nil {
+			stream.WriteHeaders(frame.Headers)
+		}
+	case http2.FrameData:
+		if stream := r.getStream(frame.StreamID); stream != nil {
+			stream.Write(frame.Payload)
+			if frame.Flags.Has(http2.FlagDataEndStream) {
+				stream.CloseWrite()
+			}
+		}
+	case http2.FrameRSTStream:
+		if stream := r.getStream(frame.StreamID); stream != nil && len(frame.Payload) == 4 {
+			stream.Reset(http2.ErrCode(binary.BigEndian.Uint32(frame.Payload)))
+		}
+	case http2.FrameGoAway:
+		go muxer.Shutdown()
+	}
+	return nil
+}
+
+// getStream returns the stream with the given ID, waiting for the Muxer to create it if needed. It
+// returns nil if the stream doesn't appear in time.
+func (r *replayer) getStream(streamID uint32) *MuxedStream {
+	r.Lock()
+	stream, ok := r.streams[streamID]
+	r.Unlock()
+	if ok {
+		return stream
+	}
+	muxer := r.getMuxer()
+	if muxer == nil {
+		return nil
+	}
+	deadline := time.Now().Add(replayStreamTimeout)
+	for time.Now().Before(deadline) {
+		if stream, ok := muxer.streams.Get(streamID); ok {
+			r.Lock()
+			r.streams[streamID] = stream
+			r.Unlock()
+			return stream
+		}
+		time.Sleep(time.Millisecond)
+	}
+	r.Lock()
+	r.streams[streamID] = nil
+	r.Unlock()
+	return nil
+}
@@ -0,0 +1,166 @@
+package h2mux
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// RoundTripper is an http.RoundTripper that sends each request on a new stream of a muxed
+// connection, the way the edge proxies requests into a tunnel.
+type RoundTripper struct {
+	muxer MuxedConnection
+}
+
+// NewRoundTripper returns a RoundTripper that opens streams on muxer.
+func NewRoundTripper(muxer MuxedConnection) *RoundTripper {
+	return &RoundTripper{muxer: muxer}
+}
+
+// RoundTrip sends the request headers and body on a new stream, and returns once the response
+// headers have been received. Cancelling the request context, or closing the response body before
+// it has been read to the end, resets the stream.
+func (t *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	body := req.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	stream, err := t.muxer.OpenStreamContext(req.Context(), H1RequestToH2Request(req), requestBody{body})
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	response := &http.Response{
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Request:    req,
+	}
+	if err := H2ResponseHeadersToH1Response(stream.Headers, response); err != nil {
+		stream.Reset(http2.ErrCodeProtocol)
+		return nil, err
+	}
+	responseBody := &responseBody{stream: stream, done: make(chan struct{})}
+	go responseBody.cancelOnDone(req)
+	response.Body = responseBody
+	return response, nil
+}
+
+// H1RequestToH2Request returns the headers of a stream carrying an HTTP/1 request. It is the inverse
+// of H2RequestHeadersToH1Request in package origin.
+func H1RequestToH2Request(req *http.Request) []Header {
+	scheme := req.URL.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	h2 := []Header{
+		{Name: ":method", Value: req.Method},
+		{Name: ":scheme", Value: scheme},
+		{Name: ":authority", Value: host},
+		{Name: ":path", Value: req.URL.RequestURI()},
+	}
+	if req.ContentLength > 0 && req.Header.Get("Content-Length") == "" {
+		h2 = append(h2, Header{Name: "content-length", Value: strconv.FormatInt(req.ContentLength, 10)})
+	}
+	for headerName, headerValues := range req.Header {
+		for _, headerValue := range headerValues {
+			h2 = append(h2, Header{Name: strings.ToLower(headerName), Value: headerValue})
+		}
+	}
+	return h2
+}
+
+// H2ResponseHeadersToH1Response sets the status and headers of h1 from the response headers of a
+// stream. It is the inverse of H1ResponseToH2Response in package origin.
+func H2ResponseHeadersToH1Response(h2 []Header, h1 *http.Response) error {
+	h1.Header = http.Header{}
+	h1.ContentLength = -1
+	for _, header := range h2 {
+		switch header.Name {
+		case ":status":
+			status, err := strconv.Atoi(header.Value)
+			if err != nil {
+				return fmt.Errorf("invalid status %#v", header.Value)
+			}
+			h1.StatusCode = status
+			h1.Status = fmt.Sprintf("%d %s", status, http.StatusText(status))
+		case "content-length":
+			contentLength, err := strconv.ParseInt(header.Value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid content-length %#v", header.Value)
+			}
+			h1.ContentLength = contentLength
+			h1.Header.Add(http.CanonicalHeaderKey(header.Name), header.Value)
+		default:
+			h1.Header.Add(http.CanonicalHeaderKey(header.Name), header.Value)
+		}
+	}
+	if h1.StatusCode == 0 {
+		return fmt.Errorf("missing status")
+	}
+	return nil
+}
+
+// requestBody closes the request body once it has been sent, as http.RoundTripper requires.
+type requestBody struct {
+	io.ReadCloser
+}
+
+func (b requestBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.ReadCloser.Close()
+	}
+	return n, err
+}
+
+// responseBody is the read end of a stream. The stream is reset if the body is closed before EOF, or
+// if the request is cancelled while the body is being read.
+type responseBody struct {
+	stream    *MuxedStream
+	eof       bool
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.stream.Read(p)
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		if !b.eof {
+			b.stream.Reset(http2.ErrCodeCancel)
+		}
+		b.stream.Close()
+	})
+	return nil
+}
+
+func (b *responseBody) cancelOnDone(req *http.Request) {
+	select {
+	case <-req.Context().Done():
+		b.stream.Reset(http2.ErrCodeCancel)
+	case <-b.done:
+	}
+}
@@ -0,0 +1,148 @@
+package h2mux
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
+)
+
+func TestRoundTripper(t *testing.T) {
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		var method, path, authority, header string
+		for _, h := range stream.Headers {
+			switch h.Name {
+			case ":method":
+				method = h.Value
+			case ":path":
+				path = h.Value
+			case ":authority":
+				authority = h.Value
+			case "x-test":
+				header = h.Value
+			}
+		}
+		requestBody, _ := ioutil.ReadAll(stream)
+		responseBody := method + " " + authority + path + " " + header + " " + string(requestBody)
+		stream.WriteHeaders([]Header{
+			{Name: ":status", Value: "201"},
+			{Name: "content-type", Value: "text/plain"},
+		})
+		stream.Write([]byte(responseBody))
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	client := &http.Client{Transport: NewRoundTripper(muxPair.EdgeMux)}
+	req, _ := http.NewRequest("POST", "https://example.com/path?query=1", bytes.NewBufferString("body"))
+	req.Header.Set("X-Test", "value")
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("error in RoundTrip: %s", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, response.StatusCode)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/plain" {
+		t.Fatalf("expected content type %s, got %s", "text/plain", contentType)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error reading response body: %s", err)
+	}
+	if string(body) != "POST example.com/path?query=1 value body" {
+		t.Fatalf("unexpected response body %s", body)
+	}
+}
+
+func TestRoundTripperResetsOnCancel(t *testing.T) {
+	handlerC := make(chan error)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		// wait for the end of the request before responding
+		ioutil.ReadAll(stream)
+		stream.WriteHeaders([]Header{{Name: ":status", Value: "200"}})
+		<-stream.Context().Done()
+		_, err := stream.Write([]byte("too late"))
+		handlerC <- err
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	response, err := NewRoundTripper(muxPair.EdgeMux).RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		t.Fatalf("error in RoundTrip: %s", err)
+	}
+	defer response.Body.Close()
+	cancel()
+	select {
+	case err := <-handlerC:
+		if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Write: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the stream to be reset")
+	}
+}
+
+func TestRoundTripperCancelledBeforeResponse(t *testing.T) {
+	handlerC := make(chan error)
+	muxPair := NewDefaultMuxerPair()
+	muxPair.OriginMuxConfig.Handler = MuxedStreamFunc(func(stream *MuxedStream) error {
+		// never respond
+		<-stream.Context().Done()
+		_, err := stream.Write([]byte("too late"))
+		handlerC <- err
+		return nil
+	})
+	muxPair.HandshakeAndServe(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", "https://example.com/", nil)
+	_, err := NewRoundTripper(muxPair.EdgeMux).RoundTrip(req.WithContext(ctx))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v from RoundTrip, got %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case err := <-handlerC:
+		if err != (StreamResetError{Code: http2.ErrCodeCancel, Remote: true}) {
+			t.Fatalf("unexpected error from (*MuxedStream).Write: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the stream to be reset")
+	}
+}
+
+func TestH2ResponseHeadersToH1Response(t *testing.T) {
+	var response http.Response
+	err := H2ResponseHeadersToH1Response([]Header{
+		{Name: ":status", Value: "404"},
+		{Name: "content-length", Value: "9"},
+		{Name: "set-cookie", Value: "a=1"},
+		{Name: "set-cookie", Value: "b=2"},
+	}, &response)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if response.StatusCode != http.StatusNotFound || response.Status != "404 Not Found" {
+		t.Fatalf("unexpected status %d %s", response.StatusCode, response.Status)
+	}
+	if response.ContentLength != 9 {
+		t.Fatalf("expected content length %d, got %d", 9, response.ContentLength)
+	}
+	if cookies := response.Header["Set-Cookie"]; len(cookies) != 2 {
+		t.Fatalf("expected 2 cookies, got %v", cookies)
+	}
+	if err := H2ResponseHeadersToH1Response([]Header{{Name: "server", Value: "test"}}, &http.Response{}); err == nil {
+		t.Fatal("expected an error for a response without status")
+	}
+}
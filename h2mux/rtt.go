@@ -31,7 +31,10 @@ func (pt *PingTimestamp) GetUpdateChan() <-chan struct{} {
 
 // RTTMeasurement encapsulates a continuous round trip time measurement.
 type RTTMeasurement struct {
-	Current, Min, Max   time.Duration
+	Current, Min, Max time.Duration
+	// Smoothed and Variance are the smoothed round trip time and its mean deviation, computed like
+	// SRTT and RTTVAR in RFC 6298.
+	Smoothed, Variance  time.Duration
 	lastMeasurementTime time.Time
 }
 
@@ -43,11 +46,38 @@ func (r *RTTMeasurement) Update(outgoingTime time.Time) {
 		return
 	}
 	r.lastMeasurementTime = outgoingTime
-	r.Current = time.Since(outgoingTime)
-	if r.Max < r.Current {
-		r.Max = r.Current
+	r.addSample(time.Since(outgoingTime))
+}
+
+func (r *RTTMeasurement) addSample(rtt time.Duration) {
+	r.Current = rtt
+	if r.Smoothed == 0 {
+		// first measurement
+		r.Min = rtt
+		r.Max = rtt
+		r.Smoothed = rtt
+		r.Variance = rtt / 2
+		return
+	}
+	if r.Max < rtt {
+		r.Max = rtt
 	}
-	if r.Min > r.Current {
-		r.Min = r.Current
+	if r.Min > rtt {
+		r.Min = rtt
+	}
+	delta := r.Smoothed - rtt
+	if delta < 0 {
+		delta = -delta
+	}
+	r.Variance = (3*r.Variance + delta) / 4
+	r.Smoothed = (7*r.Smoothed + rtt) / 8
+}
+
+// RetransmissionTimeout returns how long to wait for the answer to a probe before assuming it
+// was lost, or zero if there is no measurement yet.
+func (r RTTMeasurement) RetransmissionTimeout() time.Duration {
+	if r.Smoothed == 0 {
+		return 0
 	}
+	return r.Smoothed + 4*r.Variance
 }
@@ -0,0 +1,54 @@
+package h2mux
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTTMeasurement(t *testing.T) {
+	var rtt RTTMeasurement
+	assert.Equal(t, time.Duration(0), rtt.RetransmissionTimeout())
+
+	rtt.addSample(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, rtt.Current)
+	assert.Equal(t, 100*time.Millisecond, rtt.Min)
+	assert.Equal(t, 100*time.Millisecond, rtt.Max)
+	assert.Equal(t, 100*time.Millisecond, rtt.Smoothed)
+	assert.Equal(t, 50*time.Millisecond, rtt.Variance)
+	assert.Equal(t, 300*time.Millisecond, rtt.RetransmissionTimeout())
+
+	rtt.addSample(180 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, rtt.Min)
+	assert.Equal(t, 180*time.Millisecond, rtt.Max)
+	// RTTVAR = 3/4 * 50ms + 1/4 * |100ms - 180ms|
+	assert.Equal(t, 57500*time.Microsecond, rtt.Variance)
+	// SRTT = 7/8 * 100ms + 1/8 * 180ms
+	assert.Equal(t, 110*time.Millisecond, rtt.Smoothed)
+
+	rtt.addSample(20 * time.Millisecond)
+	assert.Equal(t, 20*time.Millisecond, rtt.Min)
+	assert.Equal(t, 180*time.Millisecond, rtt.Max)
+}
+
+func TestRTTMeasurementIgnoresStaleProbes(t *testing.T) {
+	var rtt RTTMeasurement
+	now := time.Now()
+	rtt.Update(now.Add(-10 * time.Millisecond))
+	smoothed := rtt.Smoothed
+	rtt.Update(now.Add(-time.Second))
+	assert.Equal(t, smoothed, rtt.Smoothed)
+}
+
+func TestHeartbeatRetryInterval(t *testing.T) {
+	idleDuration := 5 * time.Second
+	// no measurement yet
+	assert.Equal(t, idleDuration, heartbeatRetryInterval(RTTMeasurement{}, idleDuration))
+	// fast link
+	assert.Equal(t, minHeartbeatRetryInterval, heartbeatRetryInterval(RTTMeasurement{Smoothed: 10 * time.Millisecond, Variance: time.Millisecond}, idleDuration))
+	// slow link
+	assert.Equal(t, 3*time.Second, heartbeatRetryInterval(RTTMeasurement{Smoothed: time.Second, Variance: 500 * time.Millisecond}, idleDuration))
+	// never slower than without dead peer detection
+	assert.Equal(t, idleDuration, heartbeatRetryInterval(RTTMeasurement{Smoothed: 4 * time.Second, Variance: time.Second}, idleDuration))
+}
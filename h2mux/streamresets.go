@@ -0,0 +1,54 @@
+package h2mux
+
+import (
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// StreamResetMetrics counts RST_STREAM frames by HTTP/2 error code.
+type StreamResetMetrics struct {
+	Sent, Received map[http2.ErrCode]uint64
+}
+
+// streamResetCounter is shared by the reader and writer to count RST_STREAM frames.
+type streamResetCounter struct {
+	sync.Mutex
+	sent, received map[http2.ErrCode]uint64
+}
+
+func newStreamResetCounter() *streamResetCounter {
+	return &streamResetCounter{
+		sent:     make(map[http2.ErrCode]uint64),
+		received: make(map[http2.ErrCode]uint64),
+	}
+}
+
+func (c *streamResetCounter) incrementSent(code http2.ErrCode) {
+	c.Lock()
+	defer c.Unlock()
+	c.sent[code]++
+}
+
+func (c *streamResetCounter) incrementReceived(code http2.ErrCode) {
+	c.Lock()
+	defer c.Unlock()
+	c.received[code]++
+}
+
+// Metrics returns a snapshot of the counters.
+func (c *streamResetCounter) Metrics() *StreamResetMetrics {
+	c.Lock()
+	defer c.Unlock()
+	metrics := &StreamResetMetrics{
+		Sent:     make(map[http2.ErrCode]uint64, len(c.sent)),
+		Received: make(map[http2.ErrCode]uint64, len(c.received)),
+	}
+	for code, n := range c.sent {
+		metrics.Sent[code] = n
+	}
+	for code, n := range c.received {
+		metrics.Received[code] = n
+	}
+	return metrics
+}
@@ -0,0 +1,41 @@
+package h2mux
+
+import (
+	"strings"
+	"time"
+)
+
+// streamTimeouts holds the idle timeout and maximum lifetime applied to new streams. A zero duration
+// disables the corresponding timeout.
+type streamTimeouts struct {
+	idleTimeout, maxLifetime                   time.Duration
+	webSocketIdleTimeout, webSocketMaxLifetime time.Duration
+}
+
+func newStreamTimeouts(config MuxerConfig) streamTimeouts {
+	return streamTimeouts{
+		idleTimeout:          config.StreamIdleTimeout,
+		maxLifetime:          config.StreamMaxLifetime,
+		webSocketIdleTimeout: config.WebSocketIdleTimeout,
+		webSocketMaxLifetime: config.WebSocketMaxLifetime,
+	}
+}
+
+// start arms the timers of a new stream. headers are the request headers, used to tell whether the
+// stream is a WebSocket upgrade.
+func (t streamTimeouts) start(stream *MuxedStream, headers []Header) {
+	if isWebSocketUpgrade(headers) {
+		stream.startTimers(t.webSocketIdleTimeout, t.webSocketMaxLifetime)
+	} else {
+		stream.startTimers(t.idleTimeout, t.maxLifetime)
+	}
+}
+
+func isWebSocketUpgrade(headers []Header) bool {
+	for _, header := range headers {
+		if strings.ToLower(header.Name) == "upgrade" && strings.ToLower(header.Value) == "websocket" {
+			return true
+		}
+	}
+	return false
+}
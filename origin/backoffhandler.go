@@ -1,17 +1,71 @@
 package origin
 
 import (
+	"fmt"
+	"math/rand"
+	"sync"
 	"time"
 
 	"golang.org/x/net/context"
 )
 
-// Redeclare time functions so they can be overridden in tests.
+// Redeclare time and random functions so they can be overridden in tests.
 var (
-	timeNow   = time.Now
-	timeAfter = time.After
+	timeNow    = time.Now
+	timeAfter  = time.After
+	randInt63n = jitterSource.Int63n
 )
 
+// jitterSource spreads the backoff periods. It is seeded so that clients started together don't
+// pick the same periods, and locked because all the connections share it.
+var jitterSource = &lockedRand{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// lockedRand is a pseudorandom source that is safe for concurrent use.
+type lockedRand struct {
+	sync.Mutex
+	rand *rand.Rand
+}
+
+func (r *lockedRand) Int63n(n int64) int64 {
+	r.Lock()
+	defer r.Unlock()
+	return r.rand.Int63n(n)
+}
+
+// BackoffPolicy selects how the backoff periods of a BackoffHandler are spread, so that clients
+// that failed together don't all retry together.
+type BackoffPolicy int
+
+const (
+	// BackoffExponential doubles the backoff period with each retry.
+	BackoffExponential BackoffPolicy = iota
+	// BackoffFullJitter waits a random period between the base time and the exponential backoff
+	// period, so that a retry never follows a failure right away.
+	BackoffFullJitter
+	// BackoffDecorrelatedJitter waits a random period between the base time and three times the
+	// previous period.
+	BackoffDecorrelatedJitter
+)
+
+var backoffPolicyNames = []string{"exponential", "full-jitter", "decorrelated-jitter"}
+
+// ParseBackoffPolicy parses exponential, full-jitter or decorrelated-jitter.
+func ParseBackoffPolicy(policy string) (BackoffPolicy, error) {
+	for i, name := range backoffPolicyNames {
+		if policy == name {
+			return BackoffPolicy(i), nil
+		}
+	}
+	return BackoffExponential, fmt.Errorf("Unknown backoff policy %s, expected exponential, full-jitter or decorrelated-jitter", policy)
+}
+
+func (p BackoffPolicy) String() string {
+	if p < 0 || int(p) >= len(backoffPolicyNames) {
+		return "unknown"
+	}
+	return backoffPolicyNames[p]
+}
+
 // BackoffHandler manages exponential backoff and limits the maximum number of retries.
 // The base time period is 1 second, doubling with each retry.
 // After initial success, a grace period can be set to reset the backoff timer if
@@ -26,11 +80,20 @@ type BackoffHandler struct {
 	RetryForever bool
 	// BaseTime sets the initial backoff period.
 	BaseTime time.Duration
+	// Policy spreads the backoff periods. The default is BackoffExponential.
+	Policy BackoffPolicy
+	// MaxBackoff caps each backoff period, on top of the cap of MaxRetries doublings, unless 0.
+	MaxBackoff time.Duration
 
-	retries       uint
+	retries uint
+	// previous is the last backoff period, for BackoffDecorrelatedJitter
+	previous      time.Duration
 	resetDeadline time.Time
+	// retryAfter is the minimum duration of the next backoff
+	retryAfter time.Duration
 }
 
+// GetBackoffDuration returns the next backoff period, or its upper bound with a jitter policy.
 func (b BackoffHandler) GetBackoffDuration(ctx context.Context) (time.Duration, bool) {
 	// Follows the same logic as Backoff, but without mutating the receiver.
 	// This select has to happen first to reflect the actual behaviour of the Backoff function.
@@ -41,12 +104,15 @@ func (b BackoffHandler) GetBackoffDuration(ctx context.Context) (time.Duration,
 	}
 	if !b.resetDeadline.IsZero() && timeNow().After(b.resetDeadline) {
 		// b.retries would be set to 0 at this point
-		return time.Second, true
+		return b.atLeastRetryAfter(b.capped(time.Second)), true
 	}
 	if b.retries >= b.MaxRetries && !b.RetryForever {
 		return time.Duration(0), false
 	}
-	return time.Duration(b.GetBaseTime() * 1 << b.retries), true
+	if b.Policy == BackoffDecorrelatedJitter {
+		return b.atLeastRetryAfter(b.capped(b.decorrelatedUpperBound())), true
+	}
+	return b.atLeastRetryAfter(b.capped(time.Duration(b.GetBaseTime() * 1 << b.retries))), true
 }
 
 // BackoffTimer returns a channel that sends the current time when the exponential backoff timeout expires.
@@ -54,6 +120,7 @@ func (b BackoffHandler) GetBackoffDuration(ctx context.Context) (time.Duration,
 func (b *BackoffHandler) BackoffTimer() <-chan time.Time {
 	if !b.resetDeadline.IsZero() && timeNow().After(b.resetDeadline) {
 		b.retries = 0
+		b.previous = 0
 		b.resetDeadline = time.Time{}
 	}
 	if b.retries >= b.MaxRetries {
@@ -63,7 +130,44 @@ func (b *BackoffHandler) BackoffTimer() <-chan time.Time {
 	} else {
 		b.retries++
 	}
-	return timeAfter(time.Duration(b.GetBaseTime() * 1 << (b.retries - 1)))
+	duration := b.atLeastRetryAfter(b.jitter(time.Duration(b.GetBaseTime() * 1 << (b.retries - 1))))
+	b.retryAfter = 0
+	return timeAfter(duration)
+}
+
+// jitter applies the policy to the exponential backoff period.
+func (b *BackoffHandler) jitter(period time.Duration) time.Duration {
+	switch b.Policy {
+	case BackoffFullJitter:
+		return randomDuration(b.GetBaseTime(), b.capped(period))
+	case BackoffDecorrelatedJitter:
+		b.previous = randomDuration(b.GetBaseTime(), b.capped(b.decorrelatedUpperBound()))
+		return b.previous
+	default:
+		return b.capped(period)
+	}
+}
+
+func (b BackoffHandler) decorrelatedUpperBound() time.Duration {
+	if upper := 3 * b.previous; upper > b.GetBaseTime() {
+		return upper
+	}
+	return b.GetBaseTime()
+}
+
+func (b BackoffHandler) capped(d time.Duration) time.Duration {
+	if b.MaxBackoff > 0 && d > b.MaxBackoff {
+		return b.MaxBackoff
+	}
+	return d
+}
+
+// randomDuration returns a random duration in [min, max].
+func randomDuration(min, max time.Duration) time.Duration {
+	if max <= min {
+		return max
+	}
+	return min + time.Duration(randInt63n(int64(max-min)+1))
 }
 
 // Backoff is used to wait according to exponential backoff. Returns false if the
@@ -81,6 +185,19 @@ func (b *BackoffHandler) Backoff(ctx context.Context) bool {
 	}
 }
 
+// SetRetryAfter makes the next backoff last at least d, such as when the server asks the client to
+// wait before retrying.
+func (b *BackoffHandler) SetRetryAfter(d time.Duration) {
+	b.retryAfter = d
+}
+
+func (b BackoffHandler) atLeastRetryAfter(d time.Duration) time.Duration {
+	if d < b.retryAfter {
+		return b.retryAfter
+	}
+	return d
+}
+
 // Sets a grace period within which the the backoff timer is maintained. After the grace
 // period expires, the number of retries & backoff duration is reset.
 func (b *BackoffHandler) SetGracePeriod() {
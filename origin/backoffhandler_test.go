@@ -1,6 +1,7 @@
 package origin
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
@@ -146,3 +147,89 @@ func TestBackoffRetryForever(t *testing.T) {
 		t.Fatalf("backoff returned %v instead of 8 seconds on fifth retry", duration)
 	}
 }
+
+func TestBackoffRetryAfter(t *testing.T) {
+	var durations []time.Duration
+	timeAfter = func(d time.Duration) <-chan time.Time {
+		durations = append(durations, d)
+		return immediateTimeAfter(d)
+	}
+	ctx := context.Background()
+	backoff := BackoffHandler{MaxRetries: 3}
+	backoff.SetRetryAfter(time.Minute)
+	if duration, ok := backoff.GetBackoffDuration(ctx); !ok || duration != time.Minute {
+		t.Fatalf("expected a backoff of 1m, got %s", duration)
+	}
+	backoff.Backoff(ctx)
+	// the retry-after only applies to the next backoff
+	backoff.Backoff(ctx)
+	if durations[0] != time.Minute || durations[1] != 2*time.Second {
+		t.Fatalf("unexpected backoff durations %v", durations)
+	}
+}
+
+func TestBackoffPolicies(t *testing.T) {
+	var durations []time.Duration
+	timeAfter = func(d time.Duration) <-chan time.Time {
+		durations = append(durations, d)
+		return immediateTimeAfter(d)
+	}
+	defer func() { randInt63n = jitterSource.Int63n }()
+	ctx := context.Background()
+
+	// full jitter waits between the base time and the exponential backoff period, capped by MaxBackoff
+	randInt63n = func(n int64) int64 { return n - 1 }
+	backoff := BackoffHandler{MaxRetries: 5, Policy: BackoffFullJitter, MaxBackoff: 3 * time.Second}
+	if duration, _ := backoff.GetBackoffDuration(ctx); duration != time.Second {
+		t.Fatalf("expected an upper bound of 1s, got %s", duration)
+	}
+	for i := 0; i < 3; i++ {
+		backoff.Backoff(ctx)
+	}
+	randInt63n = func(n int64) int64 { return 0 }
+	backoff.Backoff(ctx)
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, time.Second}
+	if !reflect.DeepEqual(durations, expected) {
+		t.Fatalf("expected backoff durations %v, got %v", expected, durations)
+	}
+	// never less than the base time
+	durations = nil
+	backoff = BackoffHandler{MaxRetries: 5, Policy: BackoffFullJitter, BaseTime: 2 * time.Second}
+	for i := 0; i < 5; i++ {
+		backoff.Backoff(ctx)
+	}
+	for _, duration := range durations {
+		if duration < 2*time.Second {
+			t.Fatalf("expected backoff durations of at least 2s, got %v", durations)
+		}
+	}
+
+	// decorrelated jitter waits between the base time and three times the previous period
+	durations = nil
+	randInt63n = func(n int64) int64 { return n - 1 }
+	backoff = BackoffHandler{MaxRetries: 5, Policy: BackoffDecorrelatedJitter, MaxBackoff: 20 * time.Second}
+	for i := 0; i < 4; i++ {
+		backoff.Backoff(ctx)
+	}
+	if duration, _ := backoff.GetBackoffDuration(ctx); duration != 20*time.Second {
+		t.Fatalf("expected an upper bound of 20s, got %s", duration)
+	}
+	randInt63n = func(n int64) int64 { return 0 }
+	backoff.Backoff(ctx)
+	expected = []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 20 * time.Second, time.Second}
+	if !reflect.DeepEqual(durations, expected) {
+		t.Fatalf("expected backoff durations %v, got %v", expected, durations)
+	}
+}
+
+func TestParseBackoffPolicy(t *testing.T) {
+	for _, name := range []string{"exponential", "full-jitter", "decorrelated-jitter"} {
+		policy, err := ParseBackoffPolicy(name)
+		if err != nil || policy.String() != name {
+			t.Fatalf("cannot parse %s: %v", name, err)
+		}
+	}
+	if _, err := ParseBackoffPolicy("linear"); err == nil {
+		t.Fatalf("parsed an unknown policy")
+	}
+}
@@ -0,0 +1,174 @@
+package origin
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConnectionState is a step in the life of an HA connection.
+type ConnectionState int
+
+const (
+	// StateResolving is looking up the edge addresses before connecting.
+	StateResolving ConnectionState = iota
+	// StateDialing is connecting to an edge address. With QUIC, it includes the handshakes.
+	StateDialing
+	// StateHandshaking is running the TLS and muxer handshakes.
+	StateHandshaking
+	// StateRegistering is registering the tunnel with the edge.
+	StateRegistering
+	// StateConnected is serving requests from the edge.
+	StateConnected
+	// StateBackingOff is waiting before connecting again after a failure.
+	StateBackingOff
+	// StateDraining is finishing the requests in flight before closing.
+	StateDraining
+	// StateDisconnected is closed, and not going to connect again unless the supervisor restarts it.
+	StateDisconnected
+)
+
+var connectionStateNames = []string{"resolving", "dialing", "handshaking", "registering", "connected", "backing-off", "draining", "disconnected"}
+
+func (s ConnectionState) String() string {
+	if s < 0 || int(s) >= len(connectionStateNames) {
+		return "unknown"
+	}
+	return connectionStateNames[s]
+}
+
+// ConnectionStatus is the state of an HA connection, and is what subscribers of a
+// ConnectionTracker receive when it changes.
+type ConnectionStatus struct {
+	Index int
+	State ConnectionState
+	// Since is when the connection entered State.
+	Since time.Time
+	// Addr is the edge address the connection last dialed, if any.
+	Addr string
+	// LastError is the last error of the connection, which is kept until it connects again.
+	LastError error
+}
+
+// ConnectionTracker records the state of each HA connection and tells subscribers when it
+// changes. Its methods are safe to call on a nil ConnectionTracker, which tracks nothing.
+type ConnectionTracker struct {
+	// lock serializes changes, so that subscribers see the changes of a connection in order.
+	lock        sync.Mutex
+	connections map[int]ConnectionStatus
+	subscribers map[int]func(ConnectionStatus)
+	nextID      int
+}
+
+func NewConnectionTracker() *ConnectionTracker {
+	return &ConnectionTracker{
+		connections: map[int]ConnectionStatus{},
+		subscribers: map[int]func(ConnectionStatus){},
+	}
+}
+
+// Subscribe calls fn with each change of state, until cancel is called. fn is called by the
+// goroutine changing the state, so it must be quick and must not call the ConnectionTracker.
+func (t *ConnectionTracker) Subscribe(fn func(ConnectionStatus)) (cancel func()) {
+	if t == nil {
+		return func() {}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	id := t.nextID
+	t.nextID++
+	t.subscribers[id] = fn
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		delete(t.subscribers, id)
+	}
+}
+
+// SubscribeChan returns a channel of the changes of state, which is closed when cancel is called.
+// Changes are dropped while the channel's buffer is full; Connections returns the current states.
+func (t *ConnectionTracker) SubscribeChan(buffer int) (changes <-chan ConnectionStatus, cancel func()) {
+	c := make(chan ConnectionStatus, buffer)
+	var once sync.Once
+	unsubscribe := t.Subscribe(func(status ConnectionStatus) {
+		select {
+		case c <- status:
+		default:
+		}
+	})
+	// Subscribers are called with the lock held, so nothing is sent on c once unsubscribe returns
+	return c, func() {
+		once.Do(func() {
+			unsubscribe()
+			close(c)
+		})
+	}
+}
+
+// Connections returns the state of each connection, by index.
+func (t *ConnectionTracker) Connections() []ConnectionStatus {
+	if t == nil {
+		return nil
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	connections := make([]ConnectionStatus, 0, len(t.connections))
+	for _, status := range t.connections {
+		connections = append(connections, status)
+	}
+	sort.Slice(connections, func(i, j int) bool { return connections[i].Index < connections[j].Index })
+	return connections
+}
+
+// setState moves a connection to state. A nil err keeps the last error, except once connected.
+func (t *ConnectionTracker) setState(index int, state ConnectionState, err error) {
+	t.update(index, func(status *ConnectionStatus) {
+		status.State = state
+		if err != nil {
+			status.LastError = err
+		} else if state == StateConnected {
+			status.LastError = nil
+		}
+	})
+}
+
+// setDialing moves a connection to StateDialing an edge address.
+func (t *ConnectionTracker) setDialing(index int, addr string) {
+	t.update(index, func(status *ConnectionStatus) {
+		status.State = StateDialing
+		status.Addr = addr
+	})
+}
+
+// remove forgets a connection the supervisor no longer runs.
+func (t *ConnectionTracker) remove(index int) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.connections, index)
+}
+
+func (t *ConnectionTracker) update(index int, change func(*ConnectionStatus)) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	status, ok := t.connections[index]
+	if !ok {
+		status.Index = index
+	}
+	previous := status.State
+	change(&status)
+	if ok && status.State == previous {
+		t.connections[index] = status
+		return
+	}
+	status.Since = timeNow()
+	t.connections[index] = status
+	for _, fn := range t.subscribers {
+		fn(status)
+	}
+}
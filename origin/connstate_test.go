@@ -0,0 +1,97 @@
+package origin
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionTrackerStates(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	currentTime := time.Now()
+	timeNow = func() time.Time { return currentTime }
+	tracker := NewConnectionTracker()
+	var changes []ConnectionStatus
+	cancel := tracker.Subscribe(func(status ConnectionStatus) {
+		changes = append(changes, status)
+	})
+
+	dialErr := errors.New("dial failed")
+	tracker.setDialing(0, "198.41.200.1:7844")
+	tracker.setState(0, StateBackingOff, dialErr)
+	currentTime = currentTime.Add(time.Second)
+	tracker.setDialing(0, "198.41.200.2:7844")
+	// the last error is kept until the connection connects
+	assert.Equal(t, dialErr, tracker.Connections()[0].LastError)
+	tracker.setState(0, StateHandshaking, nil)
+	tracker.setState(0, StateRegistering, nil)
+	tracker.setState(0, StateConnected, nil)
+	// unchanged states aren't published
+	tracker.setState(0, StateConnected, nil)
+
+	var states []ConnectionState
+	for _, change := range changes {
+		states = append(states, change.State)
+	}
+	assert.Equal(t, []ConnectionState{StateDialing, StateBackingOff, StateDialing, StateHandshaking, StateRegistering, StateConnected}, states)
+	connection := tracker.Connections()[0]
+	assert.Equal(t, StateConnected, connection.State)
+	assert.Equal(t, "198.41.200.2:7844", connection.Addr)
+	assert.Equal(t, currentTime, connection.Since)
+	assert.NoError(t, connection.LastError)
+
+	cancel()
+	tracker.setState(0, StateDraining, nil)
+	assert.Len(t, changes, 6)
+	tracker.remove(0)
+	assert.Empty(t, tracker.Connections())
+}
+
+func TestConnectionTrackerNil(t *testing.T) {
+	var tracker *ConnectionTracker
+	tracker.setState(0, StateConnected, nil)
+	tracker.Subscribe(func(ConnectionStatus) {})()
+	assert.Nil(t, tracker.Connections())
+}
+
+func TestConnectionTrackerConcurrent(t *testing.T) {
+	tracker := NewConnectionTracker()
+	changes, cancel := tracker.SubscribeChan(1000)
+	const connections = 8
+	var received sync.WaitGroup
+	received.Add(1)
+	lastState := map[int]ConnectionState{}
+	go func() {
+		defer received.Done()
+		for change := range changes {
+			// the changes of each connection arrive in order
+			assert.True(t, change.State > lastState[change.Index] || change.State == StateDialing)
+			lastState[change.Index] = change.State
+		}
+	}()
+	var wg sync.WaitGroup
+	wg.Add(connections)
+	for i := 0; i < connections; i++ {
+		go func(index int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				tracker.setDialing(index, "198.41.200.1:7844")
+				tracker.setState(index, StateHandshaking, nil)
+				tracker.setState(index, StateRegistering, nil)
+				tracker.setState(index, StateConnected, nil)
+				tracker.Connections()
+			}
+		}(i)
+	}
+	wg.Wait()
+	cancel()
+	cancel()
+	received.Wait()
+	assert.Len(t, tracker.Connections(), connections)
+	for _, connection := range tracker.Connections() {
+		assert.Equal(t, StateConnected, connection.State)
+	}
+}
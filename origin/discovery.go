@@ -12,40 +12,87 @@ const (
 	srvName    = "cloudflarewarp.com"
 )
 
-func ResolveEdgeIPs(addresses []string) ([]*net.TCPAddr, error) {
-	if len(addresses) > 0 {
-		var tcpAddrs []*net.TCPAddr
-		for _, address := range addresses {
-			// Addresses specified (for testing, usually)
-			tcpAddr, err := net.ResolveTCPAddr("tcp", address)
-			if err != nil {
-				return nil, err
+// IPVersion restricts the edge addresses to an IP version, or lets the edgeSelector prefer the
+// version that works.
+type IPVersion int
+
+const (
+	IPVersionAuto IPVersion = 0
+	IPv4          IPVersion = 4
+	IPv6          IPVersion = 6
+)
+
+// ParseIPVersion parses auto, 4 or 6.
+func ParseIPVersion(version string) (IPVersion, error) {
+	switch version {
+	case "auto", "":
+		return IPVersionAuto, nil
+	case "4":
+		return IPv4, nil
+	case "6":
+		return IPv6, nil
+	}
+	return IPVersionAuto, fmt.Errorf("Unknown IP version %s, expected auto, 4 or 6", version)
+}
+
+func (v IPVersion) String() string {
+	if v == IPVersionAuto {
+		return "auto"
+	}
+	return fmt.Sprintf("%d", int(v))
+}
+
+func ipVersionOf(addr *net.TCPAddr) IPVersion {
+	if addr.IP.To4() != nil {
+		return IPv4
+	}
+	return IPv6
+}
+
+// filterIPVersion keeps the addresses of an IP version, and drops the SRV targets left without
+// addresses.
+func filterIPVersion(addrsByTarget [][]*net.TCPAddr, version IPVersion) [][]*net.TCPAddr {
+	if version == IPVersionAuto {
+		return addrsByTarget
+	}
+	var filtered [][]*net.TCPAddr
+	for _, addrs := range addrsByTarget {
+		var kept []*net.TCPAddr
+		for _, addr := range addrs {
+			if ipVersionOf(addr) == version {
+				kept = append(kept, addr)
 			}
-			tcpAddrs = append(tcpAddrs, tcpAddr)
 		}
-		return tcpAddrs, nil
+		if len(kept) > 0 {
+			filtered = append(filtered, kept)
+		}
 	}
-	// HA service discovery lookup
-	_, addrs, err := net.LookupSRV(srvService, srvProto, srvName)
+	return filtered
+}
+
+// resolveEdgeIPs finds the edge addresses with the EdgeAddrs, EdgeDiscovery and EdgeIPVersion
+// of config.
+func resolveEdgeIPs(config *TunnelConfig) ([][]*net.TCPAddr, error) {
+	addrsByTarget, err := ResolveEdgeIPsWith(config.EdgeAddrs, config.EdgeDiscovery)
 	if err != nil {
 		return nil, err
 	}
-	var resolvedIPsPerCNAME [][]*net.TCPAddr
-	var lookupErr error
-	for _, addr := range addrs {
-		ips, err := ResolveSRVToTCP(addr)
-		if err != nil || len(ips) == 0 {
-			// don't return early, we might be able to resolve other addresses
-			lookupErr = err
-			continue
-		}
-		resolvedIPsPerCNAME = append(resolvedIPsPerCNAME, ips)
-	}
-	ips := FlattenServiceIPs(resolvedIPsPerCNAME)
-	if lookupErr == nil && len(ips) == 0 {
-		return nil, fmt.Errorf("Unknown service discovery error")
+	addrsByTarget = filterIPVersion(addrsByTarget, config.EdgeIPVersion)
+	if len(addrsByTarget) == 0 {
+		return nil, fmt.Errorf("No IPv%s edge addresses found", config.EdgeIPVersion)
 	}
-	return ips, lookupErr
+	return addrsByTarget, nil
+}
+
+func ResolveEdgeIPs(addresses []string) ([]*net.TCPAddr, error) {
+	ipsByTarget, err := ResolveEdgeIPsByTarget(addresses)
+	return FlattenServiceIPs(ipsByTarget), err
+}
+
+// ResolveEdgeIPsByTarget resolves the edge addresses with the system resolver, grouped by the SRV
+// target they belong to. Addresses that are specified each form their own group.
+func ResolveEdgeIPsByTarget(addresses []string) ([][]*net.TCPAddr, error) {
+	return ResolveEdgeIPsWith(addresses, nil)
 }
 
 func ResolveSRVToTCP(srv *net.SRV) ([]*net.TCPAddr, error) {
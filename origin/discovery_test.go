@@ -43,3 +43,20 @@ func TestFlattenServiceIPs(t *testing.T) {
 		&net.TCPAddr{Port: 25},
 	}, result)
 }
+
+func TestFilterIPVersion(t *testing.T) {
+	v4 := &net.TCPAddr{IP: net.ParseIP("198.41.200.1"), Port: 7844}
+	v6 := &net.TCPAddr{IP: net.ParseIP("2606:4700::1"), Port: 7844}
+	addrsByTarget := [][]*net.TCPAddr{{v4, v6}, {v6}}
+	assert.Equal(t, addrsByTarget, filterIPVersion(addrsByTarget, IPVersionAuto))
+	assert.Equal(t, [][]*net.TCPAddr{{v4}}, filterIPVersion(addrsByTarget, IPv4))
+	assert.Equal(t, [][]*net.TCPAddr{{v6}, {v6}}, filterIPVersion(addrsByTarget, IPv6))
+
+	for _, version := range []string{"auto", "4", "6"} {
+		parsed, err := ParseIPVersion(version)
+		assert.NoError(t, err)
+		assert.Equal(t, version, parsed.String())
+	}
+	_, err := ParseIPVersion("5")
+	assert.Error(t, err)
+}
@@ -0,0 +1,245 @@
+package origin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+
+	"github.com/cloudflare/cloudflare-warp/tunneldns"
+)
+
+// dohTimeout bounds each DNS over HTTPS query of DoHDiscovery. Redeclared so it can be shortened in
+// tests.
+var dohTimeout = 5 * time.Second
+
+// EdgeDiscovery finds the addresses of the edge servers, grouped by SRV target.
+type EdgeDiscovery interface {
+	DiscoverEdge() ([][]*net.TCPAddr, error)
+	String() string
+}
+
+// SystemDiscovery looks up the edge SRV record with the system resolver.
+type SystemDiscovery struct{}
+
+func (SystemDiscovery) DiscoverEdge() ([][]*net.TCPAddr, error) {
+	_, addrs, err := net.LookupSRV(srvService, srvProto, srvName)
+	if err != nil {
+		return nil, err
+	}
+	var resolvedIPsPerCNAME [][]*net.TCPAddr
+	var lookupErr error
+	for _, addr := range addrs {
+		ips, err := ResolveSRVToTCP(addr)
+		if err != nil || len(ips) == 0 {
+			// don't return early, we might be able to resolve other addresses
+			lookupErr = err
+			continue
+		}
+		resolvedIPsPerCNAME = append(resolvedIPsPerCNAME, ips)
+	}
+	if lookupErr == nil && len(resolvedIPsPerCNAME) == 0 {
+		return nil, fmt.Errorf("Unknown service discovery error")
+	}
+	return resolvedIPsPerCNAME, lookupErr
+}
+
+func (SystemDiscovery) String() string {
+	return "system resolver"
+}
+
+// DoHDiscovery looks up the edge SRV record, and the A and AAAA records of its targets, with DNS
+// over HTTPS. It works on hosts whose DNS resolver is broken or filtered.
+type DoHDiscovery struct {
+	upstream tunneldns.Upstream
+	endpoint string
+}
+
+// NewDoHDiscovery queries a DNS over HTTPS endpoint, connecting to the bootstrap addresses if its
+// hostname can't be resolved.
+func NewDoHDiscovery(endpoint string, bootstrap []string) (*DoHDiscovery, error) {
+	upstream, err := tunneldns.NewUpstreamHTTPS(endpoint, bootstrap)
+	if err != nil {
+		return nil, err
+	}
+	return &DoHDiscovery{upstream: upstream, endpoint: endpoint}, nil
+}
+
+func (d *DoHDiscovery) DiscoverEdge() ([][]*net.TCPAddr, error) {
+	answer, err := d.query(fmt.Sprintf("_%s._%s.%s", srvService, srvProto, srvName), dns.TypeSRV)
+	if err != nil {
+		return nil, err
+	}
+	var srvs []*dns.SRV
+	for _, rr := range answer {
+		if srv, ok := rr.(*dns.SRV); ok {
+			srvs = append(srvs, srv)
+		}
+	}
+	// Like net.LookupSRV, prefer lower priorities, then higher weights
+	sort.SliceStable(srvs, func(i, j int) bool {
+		if srvs[i].Priority != srvs[j].Priority {
+			return srvs[i].Priority < srvs[j].Priority
+		}
+		return srvs[i].Weight > srvs[j].Weight
+	})
+	var resolvedIPsPerCNAME [][]*net.TCPAddr
+	var lookupErr error
+	for _, srv := range srvs {
+		var ips []*net.TCPAddr
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			answer, err := d.query(srv.Target, qtype)
+			if err != nil {
+				lookupErr = err
+				continue
+			}
+			for _, rr := range answer {
+				switch rr := rr.(type) {
+				case *dns.A:
+					ips = append(ips, &net.TCPAddr{IP: rr.A, Port: int(srv.Port)})
+				case *dns.AAAA:
+					ips = append(ips, &net.TCPAddr{IP: rr.AAAA, Port: int(srv.Port)})
+				}
+			}
+		}
+		if len(ips) > 0 {
+			resolvedIPsPerCNAME = append(resolvedIPsPerCNAME, ips)
+		}
+	}
+	if lookupErr == nil && len(resolvedIPsPerCNAME) == 0 {
+		return nil, fmt.Errorf("No edge addresses found over DNS over HTTPS")
+	}
+	return resolvedIPsPerCNAME, lookupErr
+}
+
+func (d *DoHDiscovery) query(name string, qtype uint16) ([]dns.RR, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dohTimeout)
+	defer cancel()
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	resp, err := d.upstream.Exchange(ctx, request.Request{Req: msg})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return nil, fmt.Errorf("DNS over HTTPS query for %s returned %s", name, dns.RcodeToString[resp.Rcode])
+	}
+	return resp.Answer, nil
+}
+
+func (d *DoHDiscovery) String() string {
+	return "DNS over HTTPS " + d.endpoint
+}
+
+// CacheDiscovery reads the edge addresses saved after the last successful lookup.
+type CacheDiscovery struct {
+	Path string
+}
+
+func (d *CacheDiscovery) DiscoverEdge() ([][]*net.TCPAddr, error) {
+	content, err := ioutil.ReadFile(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	var addrStrings [][]string
+	if err := json.Unmarshal(content, &addrStrings); err != nil {
+		return nil, fmt.Errorf("Cannot parse edge addresses in %s: %s", d.Path, err)
+	}
+	var addrsByTarget [][]*net.TCPAddr
+	for _, target := range addrStrings {
+		var addrs []*net.TCPAddr
+		for _, addrString := range target {
+			host, port, err := net.SplitHostPort(addrString)
+			if err != nil {
+				return nil, err
+			}
+			portNum, err := strconv.Atoi(port)
+			ip := net.ParseIP(host)
+			if err != nil || ip == nil {
+				return nil, fmt.Errorf("Invalid edge address %s in %s", addrString, d.Path)
+			}
+			addrs = append(addrs, &net.TCPAddr{IP: ip, Port: portNum})
+		}
+		if len(addrs) > 0 {
+			addrsByTarget = append(addrsByTarget, addrs)
+		}
+	}
+	if len(addrsByTarget) == 0 {
+		return nil, fmt.Errorf("%s doesn't contain edge addresses", d.Path)
+	}
+	return addrsByTarget, nil
+}
+
+// Save replaces the saved edge addresses.
+func (d *CacheDiscovery) Save(addrsByTarget [][]*net.TCPAddr) error {
+	addrStrings := make([][]string, len(addrsByTarget))
+	for i, addrs := range addrsByTarget {
+		for _, addr := range addrs {
+			addrStrings[i] = append(addrStrings[i], addr.String())
+		}
+	}
+	content, err := json.Marshal(addrStrings)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(d.Path, content, 0600)
+}
+
+func (d *CacheDiscovery) String() string {
+	return "cached edge addresses in " + d.Path
+}
+
+// ResolveEdgeIPsWith returns the specified addresses, or else the addresses found by the first of
+// discoveries that finds any, grouped by SRV target. The SystemDiscovery is used if discoveries
+// is empty. Addresses that were looked up are saved to any CacheDiscovery.
+func ResolveEdgeIPsWith(addresses []string, discoveries []EdgeDiscovery) ([][]*net.TCPAddr, error) {
+	if len(addresses) > 0 {
+		var tcpAddrs [][]*net.TCPAddr
+		for _, address := range addresses {
+			// Addresses specified (for testing, usually)
+			tcpAddr, err := net.ResolveTCPAddr("tcp", address)
+			if err != nil {
+				return nil, err
+			}
+			tcpAddrs = append(tcpAddrs, []*net.TCPAddr{tcpAddr})
+		}
+		return tcpAddrs, nil
+	}
+	if len(discoveries) == 0 {
+		discoveries = []EdgeDiscovery{SystemDiscovery{}}
+	}
+	var lastErr error
+	for _, discovery := range discoveries {
+		addrsByTarget, err := discovery.DiscoverEdge()
+		if len(addrsByTarget) == 0 {
+			Log.WithError(err).Warnf("Edge discovery with %s failed", discovery)
+			lastErr = err
+			continue
+		}
+		if err != nil {
+			Log.WithError(err).Warnf("Edge discovery with %s found some edge addresses only", discovery)
+		}
+		if _, isCache := discovery.(*CacheDiscovery); !isCache {
+			saveEdgeIPs(discoveries, addrsByTarget)
+		}
+		return addrsByTarget, nil
+	}
+	return nil, lastErr
+}
+
+func saveEdgeIPs(discoveries []EdgeDiscovery, addrsByTarget [][]*net.TCPAddr) {
+	for _, discovery := range discoveries {
+		if cache, ok := discovery.(*CacheDiscovery); ok {
+			if err := cache.Save(addrsByTarget); err != nil {
+				Log.WithError(err).Warnf("Cannot save edge addresses to %s", cache.Path)
+			}
+		}
+	}
+}
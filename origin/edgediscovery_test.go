@@ -0,0 +1,132 @@
+package origin
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type fakeDiscovery struct {
+	addrsByTarget [][]*net.TCPAddr
+	err           error
+	calls         int
+}
+
+func (d *fakeDiscovery) DiscoverEdge() ([][]*net.TCPAddr, error) {
+	d.calls++
+	return d.addrsByTarget, d.err
+}
+
+func (d *fakeDiscovery) String() string {
+	return "fake"
+}
+
+func TestResolveEdgeIPsWith(t *testing.T) {
+	Log = logrus.New()
+	dir, err := ioutil.TempDir("", "edgediscovery")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache := &CacheDiscovery{Path: filepath.Join(dir, "edge-addrs.json")}
+	addrsByTarget := [][]*net.TCPAddr{
+		{{IP: net.ParseIP("198.41.200.1"), Port: 7844}, {IP: net.ParseIP("198.41.200.2"), Port: 7844}},
+		{{IP: net.ParseIP("2606:4700::1"), Port: 7844}},
+	}
+	failing := &fakeDiscovery{err: errors.New("lookup failed")}
+	working := &fakeDiscovery{addrsByTarget: addrsByTarget}
+
+	// specified addresses skip discovery
+	resolved, err := ResolveEdgeIPsWith([]string{"127.0.0.1:7844"}, []EdgeDiscovery{failing})
+	assert.NoError(t, err)
+	assert.Equal(t, 7844, resolved[0][0].Port)
+	assert.Equal(t, 0, failing.calls)
+
+	// the cache is empty until a lookup succeeds
+	_, err = ResolveEdgeIPsWith(nil, []EdgeDiscovery{failing, cache})
+	assert.Error(t, err)
+	resolved, err = ResolveEdgeIPsWith(nil, []EdgeDiscovery{failing, working, cache})
+	assert.NoError(t, err)
+	assert.Equal(t, addrsByTarget, resolved)
+
+	// then it is the fallback
+	resolved, err = ResolveEdgeIPsWith(nil, []EdgeDiscovery{failing, cache})
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 2)
+	assert.Equal(t, "198.41.200.2:7844", resolved[0][1].String())
+	assert.Equal(t, "[2606:4700::1]:7844", resolved[1][0].String())
+}
+
+type fakeUpstream map[string][]dns.RR
+
+func (u fakeUpstream) Exchange(ctx context.Context, state request.Request) (*dns.Msg, error) {
+	resp := new(dns.Msg)
+	resp.SetReply(state.Req)
+	question := state.Req.Question[0]
+	for _, rr := range u[question.Name] {
+		if rr.Header().Rrtype == question.Qtype {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	return resp, nil
+}
+
+func TestDoHDiscovery(t *testing.T) {
+	rr := func(s string) dns.RR {
+		rr, err := dns.NewRR(s)
+		assert.NoError(t, err)
+		return rr
+	}
+	discovery := &DoHDiscovery{upstream: fakeUpstream{
+		"_warp._tcp.cloudflarewarp.com.": {
+			rr("_warp._tcp.cloudflarewarp.com. 300 IN SRV 2 1 7844 b.cloudflarewarp.com."),
+			rr("_warp._tcp.cloudflarewarp.com. 300 IN SRV 1 1 7844 a.cloudflarewarp.com."),
+		},
+		"a.cloudflarewarp.com.": {
+			rr("a.cloudflarewarp.com. 300 IN A 198.41.200.1"),
+			rr("a.cloudflarewarp.com. 300 IN AAAA 2606:4700::1"),
+		},
+		"b.cloudflarewarp.com.": {
+			rr("b.cloudflarewarp.com. 300 IN A 198.41.200.2"),
+		},
+	}}
+	addrsByTarget, err := discovery.DiscoverEdge()
+	assert.NoError(t, err)
+	// targets are ordered by priority
+	assert.Len(t, addrsByTarget, 2)
+	assert.Equal(t, "198.41.200.1:7844", addrsByTarget[0][0].String())
+	assert.Equal(t, "[2606:4700::1]:7844", addrsByTarget[0][1].String())
+	assert.Equal(t, "198.41.200.2:7844", addrsByTarget[1][0].String())
+}
+
+func TestDoHDiscoveryTimeout(t *testing.T) {
+	// a DNS over HTTPS server that never answers
+	stopC := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stopC:
+		}
+	}))
+	defer server.Close()
+	defer close(stopC)
+	defer func(timeout time.Duration) { dohTimeout = timeout }(dohTimeout)
+	dohTimeout = 100 * time.Millisecond
+
+	discovery, err := NewDoHDiscovery(server.URL, nil)
+	assert.NoError(t, err)
+	start := time.Now()
+	_, err = discovery.DiscoverEdge()
+	assert.Error(t, err)
+	assert.True(t, time.Since(start) < time.Second, "query took %s", time.Since(start))
+}
@@ -0,0 +1,379 @@
+package origin
+
+import (
+	"crypto/tls"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// probeTimeout bounds how long probing edge addresses can delay connecting to them.
+	probeTimeout = 3 * time.Second
+	// quarantineBase is how long an edge address is avoided after a connection to it fails. It
+	// doubles with each consecutive failure, up to resolveTTL.
+	quarantineBase = time.Minute
+	// familyDemoteFailures is the number of consecutive failed dials to the addresses of an IP
+	// version after which the other version is preferred.
+	familyDemoteFailures = 3
+)
+
+// edgeHealth is what an edgeSelector knows about an edge address.
+type edgeHealth struct {
+	addr *net.TCPAddr
+	// order is the position of the address in the service discovery results, to break ties.
+	order int
+	// target is the index of the SRV target the address belongs to.
+	target int
+	// location is the location of the edge server, once a connection to it registered.
+	location string
+	// latency is the TCP and TLS connect time measured by the last probe, or zero if unknown.
+	latency time.Duration
+	// failures is the number of consecutive failed connections to the address.
+	failures         uint
+	quarantinedUntil time.Time
+}
+
+// edgeSelector chooses the edge addresses to connect to. Addresses with fewer recent failures
+// come first, then addresses that connect faster. Addresses are quarantined after a connection
+// to them fails.
+//
+// Like happy eyeballs, IPv4 and IPv6 addresses alternate, starting with the version of the best
+// address, so that a broken IP version doesn't fail all connections, and each dial races the best
+// address of the other version. An IP version whose dials keep failing or losing is demoted after
+// the other, until a dial or probe to it succeeds.
+type edgeSelector struct {
+	// probe measures the time to connect to an address.
+	probe func(ctx context.Context, addr *net.TCPAddr) (time.Duration, error)
+
+	sync.Mutex
+	edges map[string]*edgeHealth
+	// dialFailures counts the consecutive failed dials of each IP version.
+	dialFailures map[IPVersion]int
+}
+
+func newEdgeSelector(tlsConfig *tls.Config) *edgeSelector {
+	return &edgeSelector{
+		probe: func(ctx context.Context, addr *net.TCPAddr) (time.Duration, error) {
+			return probeEdge(ctx, addr, tlsConfig)
+		},
+		edges:        map[string]*edgeHealth{},
+		dialFailures: map[IPVersion]int{},
+	}
+}
+
+// probeEdge returns the time to establish a TCP connection and TLS session with an edge server.
+func probeEdge(ctx context.Context, addr *net.TCPAddr, tlsConfig *tls.Config) (time.Duration, error) {
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", addr.String())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if err := tls.Client(conn, tlsConfig).Handshake(); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// update replaces the candidate addresses, grouped by SRV target, keeping what is known about
+// addresses that were candidates already.
+func (s *edgeSelector) update(addrsByTarget [][]*net.TCPAddr) {
+	s.Lock()
+	defer s.Unlock()
+	targets := map[string]int{}
+	for target, addrs := range addrsByTarget {
+		for _, addr := range addrs {
+			targets[addr.String()] = target
+		}
+	}
+	// FlattenServiceIPs reuses the outer slice
+	addrs := FlattenServiceIPs(append([][]*net.TCPAddr(nil), addrsByTarget...))
+	edges := make(map[string]*edgeHealth, len(addrs))
+	for i, addr := range addrs {
+		health, ok := s.edges[addr.String()]
+		if !ok {
+			health = &edgeHealth{addr: addr}
+		}
+		health.order = i
+		health.target = targets[addr.String()]
+		edges[addr.String()] = health
+	}
+	s.edges = edges
+}
+
+// probeAll probes all candidates concurrently, for at most probeTimeout. The latency of addresses
+// that can't be probed becomes unknown.
+func (s *edgeSelector) probeAll(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	s.Lock()
+	var addrs []*net.TCPAddr
+	for _, health := range s.edges {
+		addrs = append(addrs, health.addr)
+	}
+	s.Unlock()
+	var wg sync.WaitGroup
+	wg.Add(len(addrs))
+	for _, addr := range addrs {
+		go func(addr *net.TCPAddr) {
+			defer wg.Done()
+			latency, err := s.probe(ctx, addr)
+			if err != nil {
+				latency = 0
+			}
+			s.Lock()
+			defer s.Unlock()
+			if health, ok := s.edges[addr.String()]; ok {
+				health.latency = latency
+			}
+			if err == nil {
+				delete(s.dialFailures, ipVersionOf(addr))
+			}
+		}(addr)
+	}
+	wg.Wait()
+}
+
+// reportFailure quarantines an address after a connection to it failed, and returns for how long.
+func (s *edgeSelector) reportFailure(addr *net.TCPAddr) time.Duration {
+	s.Lock()
+	defer s.Unlock()
+	health, ok := s.edges[addr.String()]
+	if !ok {
+		return 0
+	}
+	health.failures++
+	quarantine := resolveTTL
+	if health.failures <= 10 {
+		if d := quarantineBase << (health.failures - 1); d < quarantine {
+			quarantine = d
+		}
+	}
+	health.quarantinedUntil = timeNow().Add(quarantine)
+	return quarantine
+}
+
+// reportSuccess clears the failures of an address once a connection to it registered.
+func (s *edgeSelector) reportSuccess(addr *net.TCPAddr) {
+	s.Lock()
+	defer s.Unlock()
+	if health, ok := s.edges[addr.String()]; ok {
+		health.failures = 0
+		health.quarantinedUntil = time.Time{}
+	}
+	delete(s.dialFailures, ipVersionOf(addr))
+}
+
+// reportDialFailure counts a failed dial to the IP version of an address.
+func (s *edgeSelector) reportDialFailure(addr *net.TCPAddr) {
+	s.Lock()
+	defer s.Unlock()
+	s.dialFailures[ipVersionOf(addr)]++
+}
+
+// demoted returns the IP version whose dials keep failing while the other version's don't, or
+// IPVersionAuto. Must be called with the lock held.
+func (s *edgeSelector) demoted() IPVersion {
+	v4Failing := s.dialFailures[IPv4] >= familyDemoteFailures
+	v6Failing := s.dialFailures[IPv6] >= familyDemoteFailures
+	switch {
+	case v4Failing && !v6Failing:
+		return IPv4
+	case v6Failing && !v4Failing:
+		return IPv6
+	}
+	return IPVersionAuto
+}
+
+// quarantined returns whether an address should be avoided for now.
+func (s *edgeSelector) quarantined(addr *net.TCPAddr) bool {
+	s.Lock()
+	defer s.Unlock()
+	health, ok := s.edges[addr.String()]
+	return ok && timeNow().Before(health.quarantinedUntil)
+}
+
+// ranked returns the addresses that aren't quarantined, best first.
+func (s *edgeSelector) ranked() []*net.TCPAddr {
+	s.Lock()
+	defer s.Unlock()
+	now := timeNow()
+	var healthy []*edgeHealth
+	for _, health := range s.edges {
+		if !now.Before(health.quarantinedUntil) {
+			healthy = append(healthy, health)
+		}
+	}
+	sort.Slice(healthy, func(i, j int) bool {
+		a, b := healthy[i], healthy[j]
+		if a.failures != b.failures {
+			return a.failures < b.failures
+		}
+		if a.latency != b.latency {
+			// unknown latencies come last
+			return b.latency == 0 || (a.latency != 0 && a.latency < b.latency)
+		}
+		return a.order < b.order
+	})
+	byVersion := map[IPVersion][]*net.TCPAddr{}
+	for _, health := range healthy {
+		version := ipVersionOf(health.addr)
+		byVersion[version] = append(byVersion[version], health.addr)
+	}
+	// the version of the best address comes first, unless it is demoted
+	firstVersion, secondVersion := IPv4, IPv6
+	if len(healthy) > 0 && ipVersionOf(healthy[0].addr) == IPv6 {
+		firstVersion, secondVersion = IPv6, IPv4
+	}
+	demoted := s.demoted()
+	if demoted == firstVersion {
+		firstVersion, secondVersion = secondVersion, firstVersion
+	}
+	first, second := byVersion[firstVersion], byVersion[secondVersion]
+	if demoted != IPVersionAuto {
+		return append(first, second...)
+	}
+	addrs := make([]*net.TCPAddr, 0, len(healthy))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			addrs = append(addrs, first[i])
+		}
+		if i < len(second) {
+			addrs = append(addrs, second[i])
+		}
+	}
+	return addrs
+}
+
+// next returns the best address that isn't quarantined or in use, or nil if there is none.
+func (s *edgeSelector) next(inUse []*net.TCPAddr) *net.TCPAddr {
+	for _, addr := range s.ranked() {
+		if !containsAddr(inUse, addr) {
+			return addr
+		}
+	}
+	return nil
+}
+
+// fallback returns the best address of the other IP version than addr that isn't quarantined or
+// in use, for dials to addr to race against. There is none while an IP version is demoted.
+func (s *edgeSelector) fallback(addr *net.TCPAddr, inUse []*net.TCPAddr) *net.TCPAddr {
+	s.Lock()
+	demoted := s.demoted()
+	s.Unlock()
+	if demoted != IPVersionAuto {
+		return nil
+	}
+	for _, candidate := range s.ranked() {
+		if ipVersionOf(candidate) != ipVersionOf(addr) && !containsAddr(inUse, candidate) {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func containsAddr(addrs []*net.TCPAddr, addr *net.TCPAddr) bool {
+	for _, a := range addrs {
+		if a.String() == addr.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// setLocation records the location of the edge server at an address.
+func (s *edgeSelector) setLocation(addr *net.TCPAddr, location string) {
+	s.Lock()
+	defer s.Unlock()
+	if health, ok := s.edges[addr.String()]; ok {
+		health.location = location
+	}
+}
+
+// nextInNewLocation returns the best address that isn't quarantined or in use, from a SRV target
+// that no address in use belongs to and that isn't known to be in one of locations. Returns nil if
+// there is none.
+func (s *edgeSelector) nextInNewLocation(inUse []*net.TCPAddr, locations []string) *net.TCPAddr {
+	ranked := s.ranked()
+	s.Lock()
+	defer s.Unlock()
+	avoidTargets := map[int]bool{}
+	for _, addr := range inUse {
+		if health, ok := s.edges[addr.String()]; ok {
+			avoidTargets[health.target] = true
+		}
+	}
+	for _, health := range s.edges {
+		for _, location := range locations {
+			if health.location != "" && health.location == location {
+				avoidTargets[health.target] = true
+			}
+		}
+	}
+	for _, addr := range ranked {
+		if health, ok := s.edges[addr.String()]; ok && !avoidTargets[health.target] {
+			return addr
+		}
+	}
+	return nil
+}
+
+// health returns the health of each address, to be remembered across restarts.
+func (s *edgeSelector) health() []EdgeState {
+	s.Lock()
+	defer s.Unlock()
+	edges := make([]EdgeState, 0, len(s.edges))
+	for addr, health := range s.edges {
+		edges = append(edges, EdgeState{
+			Addr:             addr,
+			Failures:         health.failures,
+			QuarantinedUntil: health.quarantinedUntil,
+			Latency:          health.latency,
+		})
+	}
+	sort.Slice(edges, func(i, j int) bool { return edges[i].Addr < edges[j].Addr })
+	return edges
+}
+
+// seed restores the health of the addresses known to the selector, as remembered before a restart.
+// Quarantines are only restored if they leave an address to connect to.
+func (s *edgeSelector) seed(edges []EdgeState) {
+	s.Lock()
+	defer s.Unlock()
+	now := timeNow()
+	quarantined := 0
+	for _, edge := range edges {
+		if _, ok := s.edges[edge.Addr]; ok && now.Before(edge.QuarantinedUntil) {
+			quarantined++
+		}
+	}
+	for _, edge := range edges {
+		if health, ok := s.edges[edge.Addr]; ok {
+			health.failures = edge.Failures
+			health.latency = edge.Latency
+			if quarantined < len(s.edges) {
+				health.quarantinedUntil = edge.QuarantinedUntil
+			}
+		}
+	}
+}
+
+// isEdgeFailure returns whether a tunnel error suggests the edge address is unhealthy, rather than
+// a problem with the tunnel's configuration or account.
+func isEdgeFailure(err error) bool {
+	switch err.(type) {
+	case nil:
+		return false
+	case printableRegisterTunnelError:
+		return shouldSwitchEdge(err)
+	default:
+		return true
+	}
+}
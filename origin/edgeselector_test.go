@@ -0,0 +1,150 @@
+package origin
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEdgeSelector(latencies map[int]time.Duration) (*edgeSelector, []*net.TCPAddr) {
+	selector := &edgeSelector{
+		probe: func(ctx context.Context, addr *net.TCPAddr) (time.Duration, error) {
+			if latency, ok := latencies[addr.Port]; ok {
+				return latency, nil
+			}
+			return 0, errors.New("unreachable")
+		},
+		edges:        map[string]*edgeHealth{},
+		dialFailures: map[IPVersion]int{},
+	}
+	addrs := []*net.TCPAddr{{Port: 1}, {Port: 2}, {Port: 3}, {Port: 4}}
+	selector.update([][]*net.TCPAddr{{addrs[0]}, {addrs[1]}, {addrs[2]}, {addrs[3]}})
+	return selector, addrs
+}
+
+func ports(addrs []*net.TCPAddr) []int {
+	var result []int
+	for _, addr := range addrs {
+		result = append(result, addr.Port)
+	}
+	return result
+}
+
+func TestEdgeSelectorLatency(t *testing.T) {
+	selector, _ := newTestEdgeSelector(map[int]time.Duration{2: 30 * time.Millisecond, 3: 10 * time.Millisecond, 4: 20 * time.Millisecond})
+	// before probing, addrs keep the service discovery order
+	assert.Equal(t, []int{1, 2, 3, 4}, ports(selector.ranked()))
+	selector.probeAll(context.Background())
+	// addrs that can't be probed come last
+	assert.Equal(t, []int{3, 4, 2, 1}, ports(selector.ranked()))
+}
+
+func TestEdgeSelectorQuarantine(t *testing.T) {
+	defer func() { timeNow = time.Now }()
+	currentTime := time.Now()
+	timeNow = func() time.Time { return currentTime }
+	selector, addrs := newTestEdgeSelector(nil)
+
+	assert.Equal(t, quarantineBase, selector.reportFailure(addrs[0]))
+	assert.True(t, selector.quarantined(addrs[0]))
+	assert.Equal(t, []int{2, 3, 4}, ports(selector.ranked()))
+	// next skips addrs in use
+	assert.Equal(t, 3, selector.next([]*net.TCPAddr{addrs[0], addrs[1]}).Port)
+	assert.Nil(t, selector.next(addrs))
+
+	// once the quarantine ends, the addr comes after addrs without failures
+	currentTime = currentTime.Add(quarantineBase)
+	assert.False(t, selector.quarantined(addrs[0]))
+	assert.Equal(t, []int{2, 3, 4, 1}, ports(selector.ranked()))
+
+	// consecutive failures double the quarantine up to the resolve TTL
+	assert.Equal(t, 2*quarantineBase, selector.reportFailure(addrs[0]))
+	for i := 0; i < 20; i++ {
+		selector.reportFailure(addrs[0])
+	}
+	assert.Equal(t, resolveTTL, selector.reportFailure(addrs[0]))
+
+	selector.reportSuccess(addrs[0])
+	assert.False(t, selector.quarantined(addrs[0]))
+	assert.Equal(t, []int{1, 2, 3, 4}, ports(selector.ranked()))
+}
+
+func TestEdgeSelectorUpdate(t *testing.T) {
+	selector, addrs := newTestEdgeSelector(nil)
+	selector.reportFailure(addrs[1])
+	// what is known about addrs is kept across service discovery refreshes
+	selector.update([][]*net.TCPAddr{{{Port: 2}}, {{Port: 5}}})
+	assert.True(t, selector.quarantined(addrs[1]))
+	assert.Equal(t, []int{5}, ports(selector.ranked()))
+}
+
+func TestEdgeSelectorNextInNewLocation(t *testing.T) {
+	selector, addrs := newTestEdgeSelector(nil)
+	// addrs 1 and 2 belong to the same target
+	selector.update([][]*net.TCPAddr{{addrs[0], addrs[1]}, {addrs[2]}, {addrs[3]}})
+	assert.Equal(t, []int{1, 3, 4, 2}, ports(selector.ranked()))
+
+	// the other addr of the target in use is skipped
+	assert.Equal(t, 3, selector.nextInNewLocation(addrs[:1], []string{"LHR"}).Port)
+	// targets known to be in a location in use are skipped
+	selector.setLocation(addrs[2], "LHR")
+	assert.Equal(t, 4, selector.nextInNewLocation(addrs[:1], []string{"LHR"}).Port)
+	selector.setLocation(addrs[3], "LHR")
+	assert.Nil(t, selector.nextInNewLocation(addrs[:1], []string{"LHR"}))
+	// but not once the location isn't in use anymore
+	assert.Equal(t, 3, selector.nextInNewLocation(addrs[:1], []string{"AMS"}).Port)
+}
+
+func TestEdgeSelectorIPVersions(t *testing.T) {
+	v4 := []*net.TCPAddr{{IP: net.ParseIP("198.41.200.1"), Port: 1}, {IP: net.ParseIP("198.41.200.2"), Port: 2}, {IP: net.ParseIP("198.41.200.3"), Port: 3}}
+	v6 := []*net.TCPAddr{{IP: net.ParseIP("2606:4700::1"), Port: 4}, {IP: net.ParseIP("2606:4700::2"), Port: 5}}
+	selector, _ := newTestEdgeSelector(map[int]time.Duration{1: 30 * time.Millisecond, 2: 40 * time.Millisecond, 3: 50 * time.Millisecond, 4: 10 * time.Millisecond, 5: 20 * time.Millisecond})
+	selector.update([][]*net.TCPAddr{v4, v6})
+	selector.probeAll(context.Background())
+	// versions alternate, starting with the version of the fastest addr
+	assert.Equal(t, []int{4, 1, 5, 2, 3}, ports(selector.ranked()))
+
+	// a version whose dials keep failing comes after the other
+	for i := 0; i < familyDemoteFailures; i++ {
+		selector.reportDialFailure(v6[0])
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ports(selector.ranked()))
+	// unless both versions fail
+	for i := 0; i < familyDemoteFailures; i++ {
+		selector.reportDialFailure(v4[0])
+	}
+	assert.Equal(t, []int{4, 1, 5, 2, 3}, ports(selector.ranked()))
+	// a successful probe promotes the version again
+	selector.probe = func(ctx context.Context, addr *net.TCPAddr) (time.Duration, error) {
+		if ipVersionOf(addr) == IPv6 {
+			return 0, errors.New("unreachable")
+		}
+		return time.Millisecond, nil
+	}
+	selector.probeAll(context.Background())
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ports(selector.ranked()))
+}
+
+func TestEdgeSelectorFallback(t *testing.T) {
+	v4 := []*net.TCPAddr{{IP: net.ParseIP("198.41.200.1"), Port: 1}, {IP: net.ParseIP("198.41.200.2"), Port: 2}}
+	v6 := []*net.TCPAddr{{IP: net.ParseIP("2606:4700::1"), Port: 3}}
+	selector, _ := newTestEdgeSelector(map[int]time.Duration{1: 20 * time.Millisecond, 2: 10 * time.Millisecond, 3: 30 * time.Millisecond})
+	selector.update([][]*net.TCPAddr{v4, v6})
+	selector.probeAll(context.Background())
+	// the best addr of the other version that isn't in use
+	assert.Equal(t, 2, selector.fallback(v6[0], nil).Port)
+	assert.Equal(t, 1, selector.fallback(v6[0], v4[1:]).Port)
+	assert.Equal(t, 3, selector.fallback(v4[0], nil).Port)
+	assert.Nil(t, selector.fallback(v4[0], v6))
+	// no racing once a version is demoted
+	for i := 0; i < familyDemoteFailures; i++ {
+		selector.reportDialFailure(v6[0])
+	}
+	assert.Nil(t, selector.fallback(v4[0], nil))
+	assert.Nil(t, selector.fallback(v6[0], nil))
+}
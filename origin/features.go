@@ -0,0 +1,56 @@
+package origin
+
+import (
+	"time"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+)
+
+// clientFeatures are the optional features of the tunnel RPC protocol this client supports.
+var clientFeatures = []string{
+	tunnelpogs.FeatureUnregisterTunnel,
+	tunnelpogs.FeatureTunnelClient,
+	tunnelpogs.FeatureRegistrationErrorCodes,
+	tunnelpogs.FeatureServerHeartbeat,
+	tunnelpogs.FeatureNamedTunnels,
+}
+
+// featureSet is the set of optional features both ends of a tunnel connection support.
+type featureSet map[string]bool
+
+// negotiateFeatures returns the features of clientFeatures that the server supports too. If the
+// server info is unavailable, the server is assumed to support none.
+func negotiateFeatures(clientFeatures []string, serverInfo *tunnelpogs.ServerInfo) featureSet {
+	features := featureSet{}
+	if serverInfo == nil {
+		return features
+	}
+	for _, serverFeature := range serverInfo.SupportedFeatures {
+		for _, clientFeature := range clientFeatures {
+			if serverFeature == clientFeature {
+				features[serverFeature] = true
+			}
+		}
+	}
+	return features
+}
+
+func (f featureSet) has(feature string) bool {
+	return f[feature]
+}
+
+// applyServerHeartbeat switches the muxer to the heartbeat settings the server prefers, if it has
+// any. The keepalive of a QUIC connection can't be changed once it is established.
+func applyServerHeartbeat(muxer h2mux.MuxedConnection, serverInfo *tunnelpogs.ServerInfo) {
+	if serverInfo.HeartbeatIntervalMs == 0 && serverInfo.MaxHeartbeats == 0 {
+		return
+	}
+	m, ok := muxer.(*h2mux.Muxer)
+	if !ok {
+		return
+	}
+	interval := time.Duration(serverInfo.HeartbeatIntervalMs) * time.Millisecond
+	Log.Debugf("Using heartbeat interval %s and %d heartbeats preferred by the server", interval, serverInfo.MaxHeartbeats)
+	m.SetHeartbeat(interval, uint64(serverInfo.MaxHeartbeats))
+}
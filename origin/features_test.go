@@ -0,0 +1,22 @@
+package origin
+
+import (
+	"testing"
+
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+)
+
+func TestNegotiateFeatures(t *testing.T) {
+	client := []string{"a", "b", "c"}
+	features := negotiateFeatures(client, &tunnelpogs.ServerInfo{SupportedFeatures: []string{"b", "c", "d"}})
+	if features.has("a") || !features.has("b") || !features.has("c") || features.has("d") {
+		t.Fatalf("unexpected features %v", features)
+	}
+	// servers that predate features support none of them
+	if features := negotiateFeatures(client, &tunnelpogs.ServerInfo{LocationName: "LHR"}); len(features) != 0 {
+		t.Fatalf("unexpected features %v", features)
+	}
+	if features := negotiateFeatures(client, nil); len(features) != 0 {
+		t.Fatalf("unexpected features %v", features)
+	}
+}
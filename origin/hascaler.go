@@ -0,0 +1,97 @@
+package origin
+
+import "time"
+
+const (
+	// How often the concurrent requests of HA connections are sampled to scale them
+	haScaleInterval = 30 * time.Second
+	// Number of consecutive samples above the threshold, or idle, before scaling
+	haScaleSamples = 4
+	// Connection IDs are a uint8
+	maxHAConnections = 255
+)
+
+// connectionLoad is a sample of the load of a connection.
+type connectionLoad struct {
+	// concurrent is the number of requests the connection is proxying
+	concurrent uint64
+	// requests is the number of requests the connection started since the previous sample
+	requests uint64
+}
+
+// haScaler decides when to add or remove HA connections, from samples of the concurrent requests
+// and throughput of each connection.
+type haScaler struct {
+	minConnections int
+	maxConnections int
+	// upRequests is the average number of concurrent requests per connection above which a
+	// connection is added, and upRequestRate the average requests per second, unless 0
+	upRequests    int
+	upRequestRate int
+	// busySamples and idleSamples count the consecutive samples above a threshold and without
+	// concurrent requests
+	busySamples int
+	idleSamples int
+	// idleRequests is the number of requests each connection started over the idle samples
+	idleRequests []uint64
+}
+
+// newHAScaler returns nil if the bounds don't allow scaling.
+func newHAScaler(minConnections, maxConnections, upRequests, upRequestRate int) *haScaler {
+	if maxConnections <= minConnections || (upRequests <= 0 && upRequestRate <= 0) {
+		return nil
+	}
+	return &haScaler{
+		minConnections: minConnections,
+		maxConnections: maxConnections,
+		upRequests:     upRequests,
+		upRequestRate:  upRequestRate,
+	}
+}
+
+// sample records the load of each connection over interval, and returns 1 to add a connection,
+// -1 to remove one, or 0 to keep them. The connection to remove is the one that started the
+// fewest requests while they were idle, the last one if several did.
+func (h *haScaler) sample(loads []connectionLoad, interval time.Duration) (int, int) {
+	var concurrent, requests uint64
+	for _, load := range loads {
+		concurrent += load.concurrent
+		requests += load.requests
+	}
+	busy := h.upRequests > 0 && concurrent > uint64(h.upRequests*len(loads))
+	if h.upRequestRate > 0 && float64(requests)/interval.Seconds() > float64(h.upRequestRate*len(loads)) {
+		busy = true
+	}
+	switch {
+	case busy:
+		h.busySamples++
+		h.idleSamples = 0
+	case concurrent == 0:
+		if h.idleSamples == 0 || len(h.idleRequests) != len(loads) {
+			h.idleSamples = 0
+			h.idleRequests = make([]uint64, len(loads))
+		}
+		h.idleSamples++
+		h.busySamples = 0
+		for i, load := range loads {
+			h.idleRequests[i] += load.requests
+		}
+	default:
+		h.busySamples, h.idleSamples = 0, 0
+	}
+	if h.busySamples >= haScaleSamples && len(loads) < h.maxConnections {
+		h.busySamples = 0
+		return 1, len(loads)
+	}
+	if h.idleSamples >= haScaleSamples && len(loads) > h.minConnections {
+		h.idleSamples = 0
+		index := 0
+		for i, requests := range h.idleRequests {
+			if requests <= h.idleRequests[index] {
+				index = i
+			}
+		}
+		return -1, index
+	}
+	return 0, -1
+}
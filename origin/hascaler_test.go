@@ -0,0 +1,93 @@
+package origin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHAScalerBounds(t *testing.T) {
+	assert.Nil(t, newHAScaler(4, 4, 10, 0))
+	assert.Nil(t, newHAScaler(2, 8, 0, 0))
+	assert.NotNil(t, newHAScaler(2, 8, 10, 0))
+	assert.NotNil(t, newHAScaler(2, 8, 0, 10))
+}
+
+// loads returns samples with the given concurrent requests and no throughput.
+func loads(concurrent ...uint64) []connectionLoad {
+	loads := make([]connectionLoad, len(concurrent))
+	for i, c := range concurrent {
+		loads[i].concurrent = c
+	}
+	return loads
+}
+
+func TestHAScaler(t *testing.T) {
+	scaler := newHAScaler(2, 3, 10, 0)
+	sample := func(loads []connectionLoad) int {
+		change, _ := scaler.sample(loads, haScaleInterval)
+		return change
+	}
+	busy := loads(15, 20)
+	for i := 1; i < haScaleSamples; i++ {
+		assert.Equal(t, 0, sample(busy))
+	}
+	// a sample below the threshold starts over
+	assert.Equal(t, 0, sample(loads(5, 10)))
+	for i := 1; i < haScaleSamples; i++ {
+		assert.Equal(t, 0, sample(busy))
+	}
+	assert.Equal(t, 1, sample(busy))
+	// not above the max
+	busy = loads(15, 20, 30)
+	for i := 0; i < 2*haScaleSamples; i++ {
+		assert.Equal(t, 0, sample(busy))
+	}
+
+	idle := loads(0, 0, 0)
+	for i := 1; i < haScaleSamples; i++ {
+		assert.Equal(t, 0, sample(idle))
+	}
+	assert.Equal(t, -1, sample(idle))
+	// not below the min
+	idle = loads(0, 0)
+	for i := 0; i < 2*haScaleSamples; i++ {
+		assert.Equal(t, 0, sample(idle))
+	}
+}
+
+func TestHAScalerRequestRate(t *testing.T) {
+	scaler := newHAScaler(2, 3, 0, 1)
+	// short requests that are rarely in flight when sampled
+	busy := []connectionLoad{{requests: 40}, {requests: 30}}
+	for i := 1; i < haScaleSamples; i++ {
+		change, _ := scaler.sample(busy, 10*time.Second)
+		assert.Equal(t, 0, change)
+	}
+	change, index := scaler.sample(busy, 10*time.Second)
+	assert.Equal(t, 1, change)
+	assert.Equal(t, 2, index)
+}
+
+func TestHAScalerRemovesLeastLoaded(t *testing.T) {
+	scaler := newHAScaler(2, 4, 10, 0)
+	idle := []connectionLoad{{requests: 3}, {requests: 0}, {requests: 5}}
+	for i := 1; i < haScaleSamples; i++ {
+		change, _ := scaler.sample(idle, haScaleInterval)
+		assert.Equal(t, 0, change)
+	}
+	change, index := scaler.sample(idle, haScaleInterval)
+	assert.Equal(t, -1, change)
+	assert.Equal(t, 1, index)
+
+	// the last one of those that started the fewest requests
+	scaler = newHAScaler(1, 4, 10, 0)
+	idle = loads(0, 0, 0)
+	for i := 1; i < haScaleSamples; i++ {
+		scaler.sample(idle, haScaleInterval)
+	}
+	change, index = scaler.sample(idle, haScaleInterval)
+	assert.Equal(t, -1, change)
+	assert.Equal(t, 2, index)
+}
@@ -2,6 +2,7 @@ package origin
 
 import (
 	"sync"
+	"time"
 
 	"github.com/cloudflare/cloudflare-warp/h2mux"
 
@@ -12,7 +13,8 @@ type TunnelMetrics struct {
 	haConnections     prometheus.Gauge
 	totalRequests     prometheus.Counter
 	requestsPerTunnel *prometheus.CounterVec
-	// concurrentRequestsLock is a mutex for concurrentRequests and maxConcurrentRequests
+	// concurrentRequestsLock is a mutex for concurrentRequests, maxConcurrentRequests and
+	// requestCounts
 	concurrentRequestsLock      sync.Mutex
 	concurrentRequestsPerTunnel *prometheus.GaugeVec
 	// concurrentRequests records count of concurrent requests for each tunnel
@@ -20,9 +22,12 @@ type TunnelMetrics struct {
 	maxConcurrentRequestsPerTunnel *prometheus.GaugeVec
 	// concurrentRequests records max count of concurrent requests for each tunnel
 	maxConcurrentRequests map[string]uint64
+	// requestCounts records count of requests for each tunnel
+	requestCounts         map[string]uint64
 	rtt                   prometheus.Gauge
 	rttMin                prometheus.Gauge
 	rttMax                prometheus.Gauge
+	rttHistogram          *prometheus.HistogramVec
 	timerRetries          prometheus.Gauge
 	receiveWindowSizeAve  prometheus.Gauge
 	sendWindowSizeAve     prometheus.Gauge
@@ -33,10 +38,19 @@ type TunnelMetrics struct {
 	responseByCode        *prometheus.CounterVec
 	responseCodePerTunnel *prometheus.CounterVec
 	serverLocations       *prometheus.GaugeVec
-	// locationLock is a mutex for oldServerLocations
+	haLocations           prometheus.Gauge
+	dialFailures          *prometheus.CounterVec
+	connectionState       *prometheus.GaugeVec
+	streamResets          *prometheus.CounterVec
+	drainingConnections   prometheus.Gauge
+	drainOverlap          prometheus.Histogram
+	drainTimeouts         prometheus.Counter
+	// locationLock is a mutex for oldServerLocations and connectedLocations
 	locationLock sync.Mutex
 	// oldServerLocations stores the last server the tunnel was connected to
 	oldServerLocations map[string]string
+	// connectedLocations stores the server location of each connected tunnel
+	connectedLocations map[string]string
 }
 
 // Metrics that can be collected without asking the edge
@@ -103,6 +117,16 @@ func NewTunnelMetrics() *TunnelMetrics {
 		})
 	prometheus.MustRegister(rttMax)
 
+	rttHistogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "rtt_seconds",
+			Help:    "Distribution of round-trip times measured by heartbeats for each tunnel",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"connection_id"},
+	)
+	prometheus.MustRegister(rttHistogram)
+
 	timerRetries := prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "timer_retries",
@@ -179,6 +203,62 @@ func NewTunnelMetrics() *TunnelMetrics {
 	)
 	prometheus.MustRegister(serverLocations)
 
+	haLocations := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ha_locations",
+			Help: "Number of distinct server locations the ha connections are connected to",
+		})
+	prometheus.MustRegister(haLocations)
+
+	dialFailures := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "edge_dial_failures",
+			Help: "Failed connections to edge addresses, by IP version",
+		},
+		[]string{"ip_version"},
+	)
+	prometheus.MustRegister(dialFailures)
+
+	connectionState := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "connection_state",
+			Help: "State of each tunnel, which is 1 for its current state and 0 for others",
+		},
+		[]string{"connection_id", "state"},
+	)
+	prometheus.MustRegister(connectionState)
+
+	streamResets := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "stream_resets",
+			Help: "Count of RST_STREAM frames sent and received by HTTP/2 error code for each tunnel",
+		},
+		[]string{"connection_id", "direction", "code"},
+	)
+	prometheus.MustRegister(streamResets)
+
+	drainingConnections := prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "draining_connections",
+			Help: "Number of connections finishing their requests in flight after the edge sent GOAWAY, alongside their replacements",
+		})
+	prometheus.MustRegister(drainingConnections)
+
+	drainOverlap := prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "drain_overlap_seconds",
+			Help:    "How long connections kept serving their requests in flight after the edge sent GOAWAY",
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		})
+	prometheus.MustRegister(drainOverlap)
+
+	drainTimeouts := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "drain_timeouts",
+			Help: "Number of connections closed with requests still in flight, once the edge had sent GOAWAY for longer than the drain overlap",
+		})
+	prometheus.MustRegister(drainTimeouts)
+
 	return &TunnelMetrics{
 		haConnections:                  haConnections,
 		totalRequests:                  totalRequests,
@@ -187,9 +267,11 @@ func NewTunnelMetrics() *TunnelMetrics {
 		concurrentRequests:             make(map[string]uint64),
 		maxConcurrentRequestsPerTunnel: maxConcurrentRequestsPerTunnel,
 		maxConcurrentRequests:          make(map[string]uint64),
+		requestCounts:                  make(map[string]uint64),
 		rtt:                   rtt,
 		rttMin:                rttMin,
 		rttMax:                rttMax,
+		rttHistogram:          rttHistogram,
 		timerRetries:          timerRetries,
 		receiveWindowSizeAve:  receiveWindowSizeAve,
 		sendWindowSizeAve:     sendWindowSizeAve,
@@ -200,7 +282,15 @@ func NewTunnelMetrics() *TunnelMetrics {
 		responseByCode:        responseByCode,
 		responseCodePerTunnel: responseCodePerTunnel,
 		serverLocations:       serverLocations,
+		haLocations:           haLocations,
+		dialFailures:          dialFailures,
+		connectionState:       connectionState,
+		streamResets:          streamResets,
+		drainingConnections:   drainingConnections,
+		drainOverlap:          drainOverlap,
+		drainTimeouts:         drainTimeouts,
 		oldServerLocations:    make(map[string]string),
+		connectedLocations:    make(map[string]string),
 	}
 }
 
@@ -212,6 +302,34 @@ func (t *TunnelMetrics) decrementHaConnections() {
 	t.haConnections.Dec()
 }
 
+func (t *TunnelMetrics) incrementDrainingConnections() {
+	t.drainingConnections.Inc()
+}
+
+// decrementDrainingConnections records how long a connection drained, and whether it timed out.
+func (t *TunnelMetrics) decrementDrainingConnections(overlap time.Duration, timedOut bool) {
+	t.drainingConnections.Dec()
+	t.drainOverlap.Observe(overlap.Seconds())
+	if timedOut {
+		t.drainTimeouts.Inc()
+	}
+}
+
+func (t *TunnelMetrics) incrementDialFailures(ipVersion string) {
+	t.dialFailures.WithLabelValues(ipVersion).Inc()
+}
+
+func (t *TunnelMetrics) setConnectionState(status ConnectionStatus) {
+	connectionID := uint8ToString(uint8(status.Index))
+	for state := StateResolving; state <= StateDisconnected; state++ {
+		value := 0.0
+		if state == status.State {
+			value = 1
+		}
+		t.connectionState.WithLabelValues(connectionID, state.String()).Set(value)
+	}
+}
+
 func (t *TunnelMetrics) updateTunnelFlowControlMetrics(metrics *h2mux.FlowControlMetrics) {
 	t.receiveWindowSizeAve.Set(float64(metrics.AverageReceiveWindowSize))
 	t.sendWindowSizeAve.Set(float64(metrics.AverageSendWindowSize))
@@ -221,6 +339,20 @@ func (t *TunnelMetrics) updateTunnelFlowControlMetrics(metrics *h2mux.FlowContro
 	t.sendWindowSizeMax.Set(float64(metrics.MaxSendWindowSize))
 }
 
+func (t *TunnelMetrics) observeRTT(connectionID string, rtt time.Duration) {
+	t.rttHistogram.WithLabelValues(connectionID).Observe(rtt.Seconds())
+}
+
+// updateStreamResetMetrics adds the resets counted by the muxer since the previous snapshot.
+func (t *TunnelMetrics) updateStreamResetMetrics(connectionID string, prev, cur *h2mux.StreamResetMetrics) {
+	for code, n := range cur.Sent {
+		t.streamResets.WithLabelValues(connectionID, "sent", code.String()).Add(float64(n - prev.Sent[code]))
+	}
+	for code, n := range cur.Received {
+		t.streamResets.WithLabelValues(connectionID, "received", code.String()).Add(float64(n - prev.Received[code]))
+	}
+}
+
 func (t *TunnelMetrics) incrementRequests(connectionID string) {
 	t.concurrentRequestsLock.Lock()
 	var concurrentRequests uint64
@@ -236,6 +368,7 @@ func (t *TunnelMetrics) incrementRequests(connectionID string) {
 		t.maxConcurrentRequests[connectionID] = concurrentRequests
 		t.maxConcurrentRequestsPerTunnel.WithLabelValues(connectionID).Set(float64(concurrentRequests))
 	}
+	t.requestCounts[connectionID]++
 	t.concurrentRequestsLock.Unlock()
 
 	t.totalRequests.Inc()
@@ -255,6 +388,20 @@ func (t *TunnelMetrics) decrementConcurrentRequests(connectionID string) {
 	t.concurrentRequestsPerTunnel.WithLabelValues(connectionID).Dec()
 }
 
+// getConcurrentRequests returns the number of requests a tunnel is proxying.
+func (t *TunnelMetrics) getConcurrentRequests(connectionID string) uint64 {
+	t.concurrentRequestsLock.Lock()
+	defer t.concurrentRequestsLock.Unlock()
+	return t.concurrentRequests[connectionID]
+}
+
+// getRequestCount returns the number of requests a tunnel has proxied.
+func (t *TunnelMetrics) getRequestCount(connectionID string) uint64 {
+	t.concurrentRequestsLock.Lock()
+	defer t.concurrentRequestsLock.Unlock()
+	return t.requestCounts[connectionID]
+}
+
 func (t *TunnelMetrics) incrementResponses(connectionID, code string) {
 	t.responseByCode.WithLabelValues(code).Inc()
 	t.responseCodePerTunnel.WithLabelValues(connectionID, code).Inc()
@@ -264,6 +411,8 @@ func (t *TunnelMetrics) incrementResponses(connectionID, code string) {
 func (t *TunnelMetrics) registerServerLocation(connectionID, loc string) {
 	t.locationLock.Lock()
 	defer t.locationLock.Unlock()
+	t.connectedLocations[connectionID] = loc
+	t.updateHaLocations()
 	if oldLoc, ok := t.oldServerLocations[connectionID]; ok && oldLoc == loc {
 		return
 	} else if ok {
@@ -272,3 +421,28 @@ func (t *TunnelMetrics) registerServerLocation(connectionID, loc string) {
 	t.serverLocations.WithLabelValues(connectionID, loc).Inc()
 	t.oldServerLocations[connectionID] = loc
 }
+
+// unregisterServerLocation forgets the location of a tunnel that disconnected.
+func (t *TunnelMetrics) unregisterServerLocation(connectionID string) {
+	t.locationLock.Lock()
+	defer t.locationLock.Unlock()
+	delete(t.connectedLocations, connectionID)
+	t.updateHaLocations()
+}
+
+// serverLocation returns the location of the server a tunnel is connected to, or "" if it isn't
+// connected.
+func (t *TunnelMetrics) serverLocation(connectionID string) string {
+	t.locationLock.Lock()
+	defer t.locationLock.Unlock()
+	return t.connectedLocations[connectionID]
+}
+
+// updateHaLocations must be called with locationLock held.
+func (t *TunnelMetrics) updateHaLocations() {
+	distinct := map[string]bool{}
+	for _, loc := range t.connectedLocations {
+		distinct[loc] = true
+	}
+	t.haLocations.Set(float64(len(distinct)))
+}
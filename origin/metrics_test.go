@@ -5,7 +5,11 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
 )
 
 // can only be called once
@@ -119,3 +123,28 @@ func TestRegisterServerLocation(t *testing.T) {
 	}
 
 }
+
+func TestStreamResetMetrics(t *testing.T) {
+	resetCount := func(direction, code string) float64 {
+		var metric dto.Metric
+		m.streamResets.WithLabelValues("0", direction, code).Write(&metric)
+		return metric.GetCounter().GetValue()
+	}
+	prev := &h2mux.StreamResetMetrics{}
+	cur := &h2mux.StreamResetMetrics{
+		Sent:     map[http2.ErrCode]uint64{http2.ErrCodeInternal: 2},
+		Received: map[http2.ErrCode]uint64{http2.ErrCodeCancel: 1},
+	}
+	m.updateStreamResetMetrics("0", prev, cur)
+	assert.Equal(t, float64(2), resetCount("sent", "INTERNAL_ERROR"))
+	assert.Equal(t, float64(1), resetCount("received", "CANCEL"))
+
+	next := &h2mux.StreamResetMetrics{
+		Sent:     map[http2.ErrCode]uint64{http2.ErrCodeInternal: 3, http2.ErrCodeNo: 1},
+		Received: map[http2.ErrCode]uint64{http2.ErrCodeCancel: 1},
+	}
+	m.updateStreamResetMetrics("0", cur, next)
+	assert.Equal(t, float64(3), resetCount("sent", "INTERNAL_ERROR"))
+	assert.Equal(t, float64(1), resetCount("sent", "NO_ERROR"))
+	assert.Equal(t, float64(1), resetCount("received", "CANCEL"))
+}
@@ -0,0 +1,84 @@
+package origin
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+
+	rpc "zombiezen.com/go/capnproto2/rpc"
+)
+
+// NamedTunnelClient manages the named tunnels of a zone, authorized by the zone's origin
+// certificate. Each tunnel has its own credentials, which only register its own hostnames.
+type NamedTunnelClient struct {
+	muxer      h2mux.MuxedConnection
+	conn       *rpc.Conn
+	server     tunnelpogs.TunnelServer_PogsClient
+	originCert []byte
+}
+
+// DialNamedTunnelClient connects to an edge server with the EdgeAddrs, EdgeDiscovery,
+// EdgeIPVersion, TlsConfig, Protocol, OriginCert and loggers of config.
+func DialNamedTunnelClient(ctx context.Context, config *TunnelConfig) (*NamedTunnelClient, error) {
+	Log = config.Logger
+	addrsByTarget, err := resolveEdgeIPs(config)
+	addrs := FlattenServiceIPs(addrsByTarget)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no edge servers found")
+	}
+	transport, err := NewEdgeTransport(config.Protocol, config.TlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	muxer, _, err := transport.Dial(ctx, addrs[0].String(), h2mux.MuxerConfig{
+		Timeout: 5 * time.Second,
+		// The edge doesn't send requests on connections that haven't registered a tunnel
+		Handler:  h2mux.MuxedStreamFunc(func(*h2mux.MuxedStream) error { return nil }),
+		IsClient: true,
+		Logger:   config.ProtocolLogger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	go muxer.Serve()
+	conn, err := openRPCConn(muxer, config.Logger.WithField("subsystem", "rpc"), nil)
+	if err != nil {
+		muxer.Shutdown()
+		return nil, err
+	}
+	return &NamedTunnelClient{
+		muxer:      muxer,
+		conn:       conn,
+		server:     tunnelpogs.TunnelServer_PogsClient{Client: conn.Bootstrap(ctx)},
+		originCert: config.OriginCert,
+	}, nil
+}
+
+// CreateTunnel creates a named tunnel that can register hostnames, and returns its credentials.
+func (c *NamedTunnelClient) CreateTunnel(ctx context.Context, name string, hostnames []string) (*tunnelpogs.TunnelCredentials, error) {
+	return c.server.CreateTunnel(ctx, c.originCert, name, hostnames)
+}
+
+// ListTunnels returns the named tunnels of the zone, without their secrets.
+func (c *NamedTunnelClient) ListTunnels(ctx context.Context) ([]tunnelpogs.NamedTunnel, error) {
+	return c.server.ListTunnels(ctx, c.originCert)
+}
+
+// DeleteTunnel deletes a named tunnel. Its credentials can't register hostnames anymore.
+func (c *NamedTunnelClient) DeleteTunnel(ctx context.Context, tunnelID string) error {
+	return c.server.DeleteTunnel(ctx, c.originCert, tunnelID)
+}
+
+// Close closes the connection to the edge.
+func (c *NamedTunnelClient) Close() error {
+	err := c.conn.Close()
+	c.muxer.Shutdown()
+	return err
+}
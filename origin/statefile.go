@@ -0,0 +1,210 @@
+package origin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxReconnectHistory bounds the reconnects remembered in the state file
+	maxReconnectHistory = 50
+	// reconnectHistoryWindow is how far back reconnects count towards the backoff at startup
+	reconnectHistoryWindow = 10 * time.Minute
+)
+
+// ClientState is what the client remembers across restarts, in its state file. The last known good
+// edge addresses are not part of it, as CacheDiscovery keeps them.
+type ClientState struct {
+	ClientID string
+	// Edges is the health of the edge addresses, as last known by the edge selector
+	Edges []EdgeState
+	// RegistrationURLs is the URL each hostname was last registered at
+	RegistrationURLs map[string]string
+	// Reconnects are the most recent connection failures, oldest first
+	Reconnects []ReconnectRecord
+	UpdatedAt  time.Time
+}
+
+// EdgeState is the health of an edge address.
+type EdgeState struct {
+	Addr             string
+	Failures         uint
+	QuarantinedUntil time.Time
+	Latency          time.Duration
+}
+
+// ReconnectRecord is a connection failure, after which the connection reconnected.
+type ReconnectRecord struct {
+	Time       time.Time
+	Connection int
+	Addr       string
+	Error      string
+}
+
+// ReadClientState reads a state file written by StateFile.
+func ReadClientState(path string) (*ClientState, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state ClientState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// StateFile keeps the ClientState in a file, which is replaced atomically on each change so that
+// a crash never leaves it partially written. Its methods are safe to call on a nil StateFile.
+type StateFile struct {
+	path  string
+	lock  sync.Mutex
+	state ClientState
+}
+
+// LoadStateFile reads the state file at path. A missing file starts an empty state. A file that
+// can't be read also starts an empty state, which replaces it on the next change, but the error
+// is returned along with the StateFile.
+func LoadStateFile(path string) (*StateFile, error) {
+	file := &StateFile{path: path}
+	state, err := ReadClientState(path)
+	if os.IsNotExist(err) {
+		return file, nil
+	} else if err != nil {
+		return file, err
+	}
+	file.state = *state
+	return file, nil
+}
+
+// State returns a copy of the state.
+func (f *StateFile) State() ClientState {
+	if f == nil {
+		return ClientState{}
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	state := f.state
+	state.Edges = append([]EdgeState(nil), f.state.Edges...)
+	state.Reconnects = append([]ReconnectRecord(nil), f.state.Reconnects...)
+	state.RegistrationURLs = make(map[string]string, len(f.state.RegistrationURLs))
+	for hostname, url := range f.state.RegistrationURLs {
+		state.RegistrationURLs[hostname] = url
+	}
+	return state
+}
+
+// recentReconnects returns the number of reconnects within reconnectHistoryWindow.
+func (f *StateFile) recentReconnects() int {
+	since := timeNow().Add(-reconnectHistoryWindow)
+	count := 0
+	for _, reconnect := range f.State().Reconnects {
+		if reconnect.Time.After(since) {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *StateFile) setClientID(clientID string) {
+	f.update(func(state *ClientState) {
+		state.ClientID = clientID
+	})
+}
+
+func (f *StateFile) recordRegistration(hostname, url string) {
+	f.update(func(state *ClientState) {
+		if state.RegistrationURLs == nil {
+			state.RegistrationURLs = map[string]string{}
+		}
+		state.RegistrationURLs[hostname] = url
+	})
+}
+
+// update changes the state and saves it. Errors are logged, as the client works without it.
+func (f *StateFile) update(change func(*ClientState)) {
+	if f == nil {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	change(&f.state)
+	f.state.UpdatedAt = timeNow()
+	content, err := json.MarshalIndent(f.state, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(f.path, content, 0600)
+	}
+	if err != nil {
+		Log.WithError(err).Warnf("Cannot save the client state to %s", f.path)
+	}
+}
+
+func recordReconnect(state *ClientState, status ConnectionStatus) {
+	reconnect := ReconnectRecord{Time: status.Since, Connection: status.Index, Addr: status.Addr}
+	if status.LastError != nil {
+		reconnect.Error = status.LastError.Error()
+	}
+	state.Reconnects = append(state.Reconnects, reconnect)
+	if len(state.Reconnects) > maxReconnectHistory {
+		state.Reconnects = state.Reconnects[len(state.Reconnects)-maxReconnectHistory:]
+	}
+}
+
+// persistState records the changes of connection state and the health of the edges of selector
+// in the state file, until ctx is done.
+func persistState(ctx context.Context, config *TunnelConfig, selector *edgeSelector) {
+	if config.StateFile == nil {
+		return
+	}
+	changes, cancel := config.ConnectionTracker.SubscribeChan(64)
+	defer cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case status := <-changes:
+			if status.State != StateConnected && status.State != StateBackingOff {
+				continue
+			}
+			config.StateFile.update(func(state *ClientState) {
+				if status.State == StateBackingOff {
+					recordReconnect(state, status)
+				}
+				state.Edges = selector.health()
+			})
+		}
+	}
+}
+
+// writeFileAtomic replaces a file by renaming a temporary file, so that it is never partially
+// written.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = tmpFile.Write(content)
+	if err == nil {
+		err = tmpFile.Sync()
+	}
+	if err == nil {
+		err = tmpFile.Chmod(perm)
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), path)
+}
@@ -0,0 +1,97 @@
+package origin
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateFile(t *testing.T) {
+	Log = logrus.New()
+	dir, err := ioutil.TempDir("", "statefile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config", "state.json")
+
+	file, err := LoadStateFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "", file.State().ClientID)
+
+	file.setClientID("client")
+	file.recordRegistration("example.com", "https://example.com")
+	file.update(func(state *ClientState) {
+		recordReconnect(state, ConnectionStatus{Index: 1, Since: timeNow(), Addr: "198.41.200.2:7844", LastError: errors.New("dial failed")})
+	})
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	reloaded, err := LoadStateFile(path)
+	assert.NoError(t, err)
+	state := reloaded.State()
+	assert.Equal(t, "client", state.ClientID)
+	assert.Equal(t, map[string]string{"example.com": "https://example.com"}, state.RegistrationURLs)
+	if assert.Len(t, state.Reconnects, 1) {
+		assert.Equal(t, "dial failed", state.Reconnects[0].Error)
+	}
+	assert.Equal(t, 1, reloaded.recentReconnects())
+}
+
+func TestLoadCorruptStateFile(t *testing.T) {
+	Log = logrus.New()
+	dir, err := ioutil.TempDir("", "statefile")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"ClientID": "cli`), 0600))
+
+	file, err := LoadStateFile(path)
+	assert.Error(t, err)
+	assert.NotNil(t, file)
+	file.setClientID("client")
+	state, err := ReadClientState(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "client", state.ClientID)
+}
+
+func TestStateHistoryLimits(t *testing.T) {
+	var state ClientState
+	start := time.Unix(0, 0)
+	for i := 0; i < maxReconnectHistory+5; i++ {
+		recordReconnect(&state, ConnectionStatus{Index: i, Since: start.Add(time.Duration(i) * time.Second)})
+	}
+	assert.Len(t, state.Reconnects, maxReconnectHistory)
+	assert.Equal(t, 5, state.Reconnects[0].Connection)
+}
+
+func TestEdgeSelectorSeed(t *testing.T) {
+	selector := newEdgeSelector(nil)
+	a := &net.TCPAddr{IP: net.ParseIP("198.41.200.1"), Port: 7844}
+	b := &net.TCPAddr{IP: net.ParseIP("198.41.200.2"), Port: 7844}
+	selector.update([][]*net.TCPAddr{{a}, {b}})
+	quarantinedUntil := timeNow().Add(time.Hour)
+
+	selector.seed([]EdgeState{{Addr: a.String(), Failures: 2, QuarantinedUntil: quarantinedUntil}})
+	assert.True(t, selector.quarantined(a))
+	assert.Equal(t, []*net.TCPAddr{b}, selector.ranked())
+	assert.Equal(t, []EdgeState{
+		{Addr: a.String(), Failures: 2, QuarantinedUntil: quarantinedUntil},
+		{Addr: b.String()},
+	}, selector.health())
+
+	// quarantining every address would leave nothing to connect to
+	selector = newEdgeSelector(nil)
+	selector.update([][]*net.TCPAddr{{a}, {b}})
+	selector.seed([]EdgeState{
+		{Addr: a.String(), Failures: 1, QuarantinedUntil: quarantinedUntil},
+		{Addr: b.String(), Failures: 2, QuarantinedUntil: quarantinedUntil},
+	})
+	assert.Equal(t, []*net.TCPAddr{a, b}, selector.ranked())
+}
@@ -15,21 +15,40 @@ const (
 	tunnelRetryLimit = 5
 	// SRV record resolution TTL
 	resolveTTL = time.Hour
+	// How often the locations of HA connections are checked for diversity
+	locationCheckInterval = time.Minute
 )
 
 type Supervisor struct {
-	config  *TunnelConfig
+	config *TunnelConfig
+	// edgeIPs is the address of each HA connection
 	edgeIPs []*net.TCPAddr
-	// nextUnusedEdgeIP is the index of the next addr k edgeIPs to try
-	nextUnusedEdgeIP  int
+	// selector chooses the addresses that replace those of failed connections
+	selector          *edgeSelector
 	lastResolve       time.Time
 	resolverC         chan resolveResult
 	tunnelErrors      chan tunnelError
 	tunnelsConnecting map[int]chan struct{}
+	// tunnelCancels stops each connection, and tunnelsMoving marks the connections stopped to
+	// reconnect to another location
+	tunnelCancels map[int]context.CancelFunc
+	tunnelsMoving map[int]bool
+	// tunnelFallbacks is the addr of the other IP version that each connection's dials race against
+	tunnelFallbacks map[int]*edgeFallback
+	// scaler adds and removes connections with the load, if the HA connections bounds allow it.
+	// tunnelsRemoving marks the connections it stopped, and requestCounts is the number of
+	// requests each connection ID had proxied when last sampled.
+	scaler          *haScaler
+	tunnelsRemoving map[int]bool
+	requestCounts   map[uint8]uint64
 	// nextConnectedIndex and nextConnectedSignal are used to wait for all
 	// currently-connecting tunnels to finish connecting so we can reset backoff timer
 	nextConnectedIndex  int
 	nextConnectedSignal chan struct{}
+	// connectionIDs is the ID each connection registers with, if it isn't its index. drains runs
+	// the connections the edge went away from, whose replacements get another ID.
+	connectionIDs map[int]uint8
+	drains        drainingTunnels
 }
 
 type resolveResult struct {
@@ -37,33 +56,72 @@ type resolveResult struct {
 	err     error
 }
 
+// tunnelError is sent when the connection with connectionID returns. Its index may have changed
+// since it started, when a connection with a lower index was removed.
 type tunnelError struct {
-	index int
-	err   error
+	connectionID uint8
+	err          error
 }
 
 func NewSupervisor(config *TunnelConfig) *Supervisor {
 	return &Supervisor{
 		config:            config,
+		selector:          newEdgeSelector(config.TlsConfig),
 		tunnelErrors:      make(chan tunnelError),
 		tunnelsConnecting: map[int]chan struct{}{},
+		tunnelCancels:     map[int]context.CancelFunc{},
+		tunnelsMoving:     map[int]bool{},
+		tunnelFallbacks:   map[int]*edgeFallback{},
+		tunnelsRemoving:   map[int]bool{},
+		requestCounts:     map[uint8]uint64{},
+		connectionIDs:     map[int]uint8{},
 	}
 }
 
 func (s *Supervisor) Run(ctx context.Context, connectedSignal chan struct{}) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		// connections still draining are closed once ctx is done
+		s.drains.wait()
+	}()
 	if err := s.initialize(ctx, connectedSignal); err != nil {
 		return err
 	}
 	tunnelsActive := s.config.HAConnections
 	tunnelsWaiting := []int{}
-	backoff := BackoffHandler{MaxRetries: s.config.Retries, BaseTime: tunnelRetryDuration, RetryForever: true}
+	backoff := BackoffHandler{
+		MaxRetries:   s.config.Retries,
+		BaseTime:     tunnelRetryDuration,
+		RetryForever: true,
+		Policy:       s.config.BackoffPolicy,
+		MaxBackoff:   s.config.MaxBackoff,
+	}
+	// Carry on backing off from where a client that kept reconnecting before a restart left off
+	if recent := uint(s.config.StateFile.recentReconnects()); recent < backoff.MaxRetries {
+		backoff.retries = recent
+	} else {
+		backoff.retries = backoff.MaxRetries
+	}
 	var backoffTimer <-chan time.Time
+	var locationCheckC <-chan time.Time
+	if s.config.HAMinLocations > 1 {
+		ticker := time.NewTicker(locationCheckInterval)
+		defer ticker.Stop()
+		locationCheckC = ticker.C
+	}
+	var scaleC <-chan time.Time
+	if s.scaler != nil {
+		ticker := time.NewTicker(haScaleInterval)
+		defer ticker.Stop()
+		scaleC = ticker.C
+	}
 	for tunnelsActive > 0 {
 		select {
 		// Context cancelled
 		case <-ctx.Done():
 			for tunnelsActive > 0 {
-				<-s.tunnelErrors
+				s.drains.start(ctx, (<-s.tunnelErrors).err)
 				tunnelsActive--
 			}
 			return nil
@@ -71,19 +129,72 @@ func (s *Supervisor) Run(ctx context.Context, connectedSignal chan struct{}) err
 		// (note that this may also be caused by context cancellation)
 		case tunnelError := <-s.tunnelErrors:
 			tunnelsActive--
+			index := s.connectionIndex(tunnelError.connectionID)
+			goingAway := s.drains.start(ctx, tunnelError.err)
+			if goingAway {
+				// The connection drains in the background with its ID
+				s.assignConnectionID(index)
+			}
+			if s.tunnelsMoving[index] && ctx.Err() == nil {
+				// Stopped by spreadLocations, reconnect right away
+				delete(s.tunnelsMoving, index)
+				go s.startTunnel(s.newTunnelContext(ctx, index), index, s.connectionID(index), s.newConnectedTunnelSignal(index))
+				tunnelsActive++
+				continue
+			}
+			if s.tunnelsRemoving[index] {
+				// Stopped by scaleConnections, the connection is no longer needed
+				delete(s.tunnelsRemoving, index)
+				delete(s.tunnelCancels, index)
+				s.waitForNextTunnel(index)
+				if !goingAway {
+					s.config.ConnectionTracker.remove(int(tunnelError.connectionID))
+				}
+				delete(s.requestCounts, tunnelError.connectionID)
+				s.removeConnection(index)
+				for i := range tunnelsWaiting {
+					if tunnelsWaiting[i] > index {
+						tunnelsWaiting[i]--
+					}
+				}
+				continue
+			}
+			if goingAway && ctx.Err() == nil {
+				// Replace the connection right away, while it drains
+				s.replaceEdgeIP(index)
+				go s.startTunnel(s.newTunnelContext(ctx, index), index, s.connectionID(index), s.newConnectedTunnelSignal(index))
+				tunnelsActive++
+				continue
+			}
+			if isFatalRegistrationError(tunnelError.err) {
+				// No other connection would be accepted either
+				cancel()
+				for tunnelsActive > 0 {
+					s.drains.start(ctx, (<-s.tunnelErrors).err)
+					tunnelsActive--
+				}
+				return tunnelError.err
+			}
 			if tunnelError.err != nil {
 				Log.WithError(tunnelError.err).Warn("Tunnel disconnected due to error")
-				tunnelsWaiting = append(tunnelsWaiting, tunnelError.index)
-				s.waitForNextTunnel(tunnelError.index)
+				tunnelsWaiting = append(tunnelsWaiting, index)
+				s.config.ConnectionTracker.setState(int(s.connectionID(index)), StateBackingOff, tunnelError.err)
+				s.waitForNextTunnel(index)
 				if backoffTimer == nil {
+					if e, ok := tunnelError.err.(printableRegisterTunnelError); ok {
+						_, retryAfter := e.retryPolicy()
+						backoff.SetRetryAfter(retryAfter)
+					}
 					backoffTimer = backoff.BackoffTimer()
 				}
-				// If the error is a dial error, the problem is likely to be network related
-				// try another addr before refreshing since we are likely to get back the
-				// same IPs in the same order. Same problem with duplicate connection error.
-				if s.unusedIPs() {
-					s.replaceEdgeIP(tunnelError.index)
-				} else {
+				if isEdgeFailure(tunnelError.err) {
+					addr := s.getEdgeIP(index)
+					quarantine := s.reportEdgeFailure(addr, tunnelError.err)
+					Log.Debugf("Avoiding edge %s for %s", addr, quarantine)
+				}
+				// Try another addr before refreshing since we are likely to get back the
+				// same IPs. Quarantined addrs are tried again once their quarantine ends.
+				if !s.replaceEdgeIP(index) {
 					s.refreshEdgeIPs()
 				}
 			}
@@ -91,23 +202,32 @@ func (s *Supervisor) Run(ctx context.Context, connectedSignal chan struct{}) err
 		case <-backoffTimer:
 			backoffTimer = nil
 			for _, index := range tunnelsWaiting {
-				go s.startTunnel(ctx, index, s.newConnectedTunnelSignal(index))
+				if s.selector.quarantined(s.getEdgeIP(index)) {
+					s.replaceEdgeIP(index)
+				}
+				go s.startTunnel(s.newTunnelContext(ctx, index), index, s.connectionID(index), s.newConnectedTunnelSignal(index))
 			}
 			tunnelsActive += len(tunnelsWaiting)
 			tunnelsWaiting = nil
 		// Tunnel successfully connected
 		case <-s.nextConnectedSignal:
+			s.selector.reportSuccess(s.connectedEdgeIP(s.nextConnectedIndex))
 			if !s.waitForNextTunnel(s.nextConnectedIndex) && len(tunnelsWaiting) == 0 {
 				// No more tunnels outstanding, clear backoff timer
 				backoff.SetGracePeriod()
 			}
+		// Time to check the HA connections are spread across enough locations
+		case <-locationCheckC:
+			s.spreadLocations()
+		// Time to check whether the HA connections match the load
+		case <-scaleC:
+			tunnelsActive += s.scaleConnections(ctx, len(tunnelsWaiting) > 0)
 		// DNS resolution returned
 		case result := <-s.resolverC:
 			s.lastResolve = time.Now()
 			s.resolverC = nil
 			if result.err == nil {
-				Log.Debug("Service discovery refresh complete")
-				s.edgeIPs = result.edgeIPs
+				Log.Debugf("Service discovery refresh complete, found %d edge addresses", len(result.edgeIPs))
 			} else {
 				Log.WithError(result.err).Error("Service discovery error")
 			}
@@ -117,32 +237,50 @@ func (s *Supervisor) Run(ctx context.Context, connectedSignal chan struct{}) err
 }
 
 func (s *Supervisor) initialize(ctx context.Context, connectedSignal chan struct{}) error {
-	edgeIPs, err := ResolveEdgeIPs(s.config.EdgeAddrs)
+	s.config.ConnectionTracker.setState(0, StateResolving, nil)
+	edgeIPsByTarget, err := resolveEdgeIPs(s.config)
 	if err != nil {
 		Log.Infof("ResolveEdgeIPs err")
 		return err
 	}
-	s.edgeIPs = edgeIPs
+	s.selector.update(edgeIPsByTarget)
+	s.selector.seed(s.config.StateFile.State().Edges)
+	s.selector.probeAll(ctx)
+	go persistState(ctx, s.config, s.selector)
+	// connect to the best addrs first
+	edgeIPs := s.selector.ranked()
+	if s.config.HAConnections < 1 {
+		s.config.HAConnections = 1
+	}
 	if s.config.HAConnections > len(edgeIPs) {
 		Log.Warnf("You requested %d HA connections but I can give you at most %d.", s.config.HAConnections, len(edgeIPs))
 		s.config.HAConnections = len(edgeIPs)
 	}
 	s.lastResolve = time.Now()
+	s.edgeIPs = edgeIPs[:s.config.HAConnections]
+	s.scaler = newHAScaler(s.haConnectionsBounds(len(edgeIPs)))
 	// check entitlement and version too old error before attempting to register more tunnels
-	s.nextUnusedEdgeIP = s.config.HAConnections
-	go s.startFirstTunnel(ctx, connectedSignal)
+	go s.startFirstTunnel(s.newTunnelContext(ctx, 0), connectedSignal)
 	select {
 	case <-ctx.Done():
-		<-s.tunnelErrors
+		s.drains.start(ctx, (<-s.tunnelErrors).err)
 		// Error can't be nil. A nil error signals that initialization succeed
 		return fmt.Errorf("Context was canceled")
 	case tunnelError := <-s.tunnelErrors:
-		return tunnelError.err
+		if !s.drains.start(ctx, tunnelError.err) {
+			return tunnelError.err
+		}
+		// The first connection registered before the edge went away. Replace it while it drains.
+		s.selector.reportSuccess(s.connectedEdgeIP(0))
+		s.assignConnectionID(0)
+		s.replaceEdgeIP(0)
+		go s.startTunnel(s.newTunnelContext(ctx, 0), 0, s.connectionID(0), s.newConnectedTunnelSignal(0))
 	case <-connectedSignal:
+		s.selector.reportSuccess(s.connectedEdgeIP(0))
 	}
 	// At least one successful connection, so start the rest
 	for i := 1; i < s.config.HAConnections; i++ {
-		go s.startTunnel(ctx, i, make(chan struct{}))
+		go s.startTunnel(s.newTunnelContext(ctx, i), i, s.connectionID(i), make(chan struct{}))
 		// TODO: Add artificial delay between HA connections to make sure all origins
 		// are registered in LB pool. Temporary fix until we fix LB
 		time.Sleep(time.Millisecond * 500)
@@ -155,10 +293,10 @@ func (s *Supervisor) initialize(ctx context.Context, connectedSignal chan struct
 func (s *Supervisor) startFirstTunnel(ctx context.Context, connectedSignal chan struct{}) {
 	err := ServeTunnelLoop(ctx, s.config, s.getEdgeIP(0), 0, connectedSignal)
 	defer func() {
-		s.tunnelErrors <- tunnelError{index: 0, err: err}
+		s.tunnelErrors <- tunnelError{connectionID: 0, err: err}
 	}()
 
-	for s.unusedIPs() {
+	for {
 		select {
 		case <-ctx.Done():
 			return
@@ -167,22 +305,145 @@ func (s *Supervisor) startFirstTunnel(ctx context.Context, connectedSignal chan
 		switch err.(type) {
 		case nil:
 			return
-		// try the next address if it was a dialError(network problem) or
-		// dupConnRegisterTunnelError
-		case dialError, dupConnRegisterTunnelError:
-			s.replaceEdgeIP(0)
+		// try the next address if it was a dialError(network problem),
+		// dupConnRegisterTunnelError or the edge went away before registering
+		case dialError, dupConnRegisterTunnelError, earlyGoAwayError:
+		// or if the server is overloaded
+		case printableRegisterTunnelError:
+			if !shouldSwitchEdge(err) {
+				return
+			}
 		default:
 			return
 		}
-		err = ServeTunnelLoop(ctx, s.config, s.getEdgeIP(0), 0, connectedSignal)
+		s.reportEdgeFailure(s.getEdgeIP(0), err)
+		if !s.replaceEdgeIP(0) {
+			return
+		}
+		err = ServeTunnelLoop(s.withEdgeFallback(ctx, 0), s.config, s.getEdgeIP(0), 0, connectedSignal)
 	}
 }
 
 // startTunnel starts a new tunnel connection. The resulting error will be sent on
 // s.tunnelErrors.
-func (s *Supervisor) startTunnel(ctx context.Context, index int, connectedSignal chan struct{}) {
-	err := ServeTunnelLoop(ctx, s.config, s.getEdgeIP(index), uint8(index), connectedSignal)
-	s.tunnelErrors <- tunnelError{index: index, err: err}
+func (s *Supervisor) startTunnel(ctx context.Context, index int, connectionID uint8, connectedSignal chan struct{}) {
+	err := ServeTunnelLoop(ctx, s.config, s.getEdgeIP(index), connectionID, connectedSignal)
+	s.tunnelErrors <- tunnelError{connectionID: connectionID, err: err}
+}
+
+// connectionID returns the ID the connection at index registers with.
+func (s *Supervisor) connectionID(index int) uint8 {
+	if id, ok := s.connectionIDs[index]; ok {
+		return id
+	}
+	return uint8(index)
+}
+
+// connectionIndex returns the index of the connection with connectionID, or -1.
+func (s *Supervisor) connectionIndex(connectionID uint8) int {
+	for i := range s.edgeIPs {
+		if s.connectionID(i) == connectionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeConnection removes the connection at index once it has returned. The connections after it
+// move down one index, keeping their connection IDs.
+func (s *Supervisor) removeConnection(index int) {
+	last := len(s.edgeIPs) - 1
+	for i := index; i < last; i++ {
+		s.connectionIDs[i] = s.connectionID(i + 1)
+		moveIndex(i+1, i, s.tunnelCancels, s.tunnelsMoving, s.tunnelsConnecting, s.tunnelFallbacks)
+	}
+	delete(s.connectionIDs, last)
+	moveIndex(last, -1, s.tunnelCancels, s.tunnelsMoving, s.tunnelsConnecting, s.tunnelFallbacks)
+	if s.nextConnectedIndex > index {
+		s.nextConnectedIndex--
+	}
+	s.edgeIPs = append(s.edgeIPs[:index], s.edgeIPs[index+1:]...)
+}
+
+// moveIndex moves the entries of a connection to another index, or deletes them if to is negative.
+func moveIndex(from, to int, cancels map[int]context.CancelFunc, moving map[int]bool, connecting map[int]chan struct{}, fallbacks map[int]*edgeFallback) {
+	if to >= 0 {
+		delete(cancels, to)
+		delete(moving, to)
+		delete(connecting, to)
+		delete(fallbacks, to)
+		if cancel, ok := cancels[from]; ok {
+			cancels[to] = cancel
+		}
+		if moving[from] {
+			moving[to] = true
+		}
+		if signal, ok := connecting[from]; ok {
+			connecting[to] = signal
+		}
+		if fallback, ok := fallbacks[from]; ok {
+			fallbacks[to] = fallback
+		}
+	}
+	delete(cancels, from)
+	delete(moving, from)
+	delete(connecting, from)
+	delete(fallbacks, from)
+}
+
+// assignConnectionID gives the connection at index the lowest ID that no other connection, running
+// or draining, has. It keeps its ID if there is none.
+func (s *Supervisor) assignConnectionID(index int) {
+	id, ok := s.drains.freeConnectionID(func(id uint8) bool {
+		for i := range s.edgeIPs {
+			if i != index && s.connectionID(i) == id {
+				return true
+			}
+		}
+		return false
+	})
+	if ok {
+		s.connectionIDs[index] = id
+	}
+}
+
+// newTunnelContext returns the context of a connection, which spreadLocations cancels to move
+// the connection to another location.
+func (s *Supervisor) newTunnelContext(ctx context.Context, index int) context.Context {
+	if cancel, ok := s.tunnelCancels[index]; ok {
+		// the previous connection at this index has returned
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.tunnelCancels[index] = cancel
+	return s.withEdgeFallback(ctx, index)
+}
+
+// withEdgeFallback picks the addr of the other IP version that the dials of the connection at
+// index race against.
+func (s *Supervisor) withEdgeFallback(ctx context.Context, index int) context.Context {
+	fallback := &edgeFallback{addr: s.selector.fallback(s.getEdgeIP(index), s.edgeIPs)}
+	s.tunnelFallbacks[index] = fallback
+	return withEdgeFallback(ctx, fallback)
+}
+
+// connectedEdgeIP returns the addr the connection at index registered with. If its fallback addr
+// won the dial, the connection switches to it and the addr that lost counts as a failed dial of
+// its IP version.
+func (s *Supervisor) connectedEdgeIP(index int) *net.TCPAddr {
+	addr := s.getEdgeIP(index)
+	fallback, ok := s.tunnelFallbacks[index]
+	if !ok || !fallback.Won() {
+		return addr
+	}
+	s.selector.reportDialFailure(addr)
+	for _, inUse := range s.edgeIPs {
+		if inUse.String() == fallback.addr.String() {
+			return fallback.addr
+		}
+	}
+	s.edgeIPs[index%len(s.edgeIPs)] = fallback.addr
+	return fallback.addr
 }
 
 func (s *Supervisor) newConnectedTunnelSignal(index int) chan struct{} {
@@ -217,16 +478,140 @@ func (s *Supervisor) refreshEdgeIPs() {
 	}
 	s.resolverC = make(chan resolveResult)
 	go func() {
-		edgeIPs, err := ResolveEdgeIPs(s.config.EdgeAddrs)
-		s.resolverC <- resolveResult{edgeIPs: edgeIPs, err: err}
+		edgeIPsByTarget, err := resolveEdgeIPs(s.config)
+		if err == nil {
+			s.selector.update(edgeIPsByTarget)
+			s.selector.probeAll(context.Background())
+		}
+		s.resolverC <- resolveResult{edgeIPs: FlattenServiceIPs(edgeIPsByTarget), err: err}
 	}()
 }
 
-func (s *Supervisor) unusedIPs() bool {
-	return s.nextUnusedEdgeIP < len(s.edgeIPs)
+// reportEdgeFailure quarantines an addr after a connection to it failed, and counts failed dials
+// against its IP version. Returns how long the addr is quarantined.
+func (s *Supervisor) reportEdgeFailure(addr *net.TCPAddr, err error) time.Duration {
+	if _, ok := err.(dialError); ok {
+		s.selector.reportDialFailure(addr)
+	}
+	return s.selector.reportFailure(addr)
+}
+
+// replaceEdgeIP assigns the best addr that isn't quarantined or used by another connection to a
+// connection. Returns false if there is none, in which case the connection keeps its addr.
+func (s *Supervisor) replaceEdgeIP(badIPIndex int) bool {
+	addr := s.selector.next(s.edgeIPs)
+	if addr == nil {
+		return false
+	}
+	s.edgeIPs[badIPIndex] = addr
+	return true
 }
 
-func (s *Supervisor) replaceEdgeIP(badIPIndex int) {
-	s.edgeIPs[badIPIndex] = s.edgeIPs[s.nextUnusedEdgeIP]
-	s.nextUnusedEdgeIP++
+// spreadLocations moves a connection that shares its location with another connection to a SRV
+// target that may be in another location, while the connections reach fewer than HAMinLocations
+// distinct locations. One connection moves at a time.
+func (s *Supervisor) spreadLocations() {
+	if len(s.tunnelsMoving) > 0 || len(s.tunnelsRemoving) > 0 {
+		return
+	}
+	locations := make([]string, len(s.edgeIPs))
+	for i, addr := range s.edgeIPs {
+		locations[i] = s.config.Metrics.serverLocation(uint8ToString(s.connectionID(i)))
+		if locations[i] != "" {
+			s.selector.setLocation(addr, locations[i])
+		}
+	}
+	index := duplicateLocation(locations, s.config.HAMinLocations)
+	if index < 0 {
+		return
+	}
+	addr := s.selector.nextInNewLocation(s.edgeIPs, locations)
+	if addr == nil {
+		return
+	}
+	Log.Infof("Moving connection %d from %s to edge %s to reach more locations", index, locations[index], addr)
+	s.edgeIPs[index] = addr
+	s.tunnelsMoving[index] = true
+	s.tunnelCancels[index]()
+}
+
+// duplicateLocation returns the last connection whose location another connection has too, if
+// the connections reach fewer than minLocations distinct locations, or -1. Connections that
+// aren't connected have no location.
+func duplicateLocation(locations []string, minLocations int) int {
+	seen := map[string]bool{}
+	duplicate := -1
+	for i, location := range locations {
+		if location == "" {
+			continue
+		}
+		if seen[location] {
+			duplicate = i
+		}
+		seen[location] = true
+	}
+	if len(seen) >= minLocations {
+		return -1
+	}
+	return duplicate
+}
+
+// haConnectionsBounds returns the bounds of the number of HA connections, and the concurrent
+// requests and requests per second per connection above which connections are added. Unset bounds
+// default to HAConnections, which disables scaling.
+func (s *Supervisor) haConnectionsBounds(availableAddrs int) (int, int, int, int) {
+	minConnections, maxConnections := s.config.HAMinConnections, s.config.HAMaxConnections
+	if minConnections <= 0 || minConnections > s.config.HAConnections {
+		minConnections = s.config.HAConnections
+	}
+	if maxConnections > availableAddrs {
+		maxConnections = availableAddrs
+	}
+	if maxConnections > maxHAConnections {
+		maxConnections = maxHAConnections
+	}
+	if maxConnections < s.config.HAConnections {
+		maxConnections = s.config.HAConnections
+	}
+	return minConnections, maxConnections, s.config.HAScaleUpRequests, s.config.HAScaleUpRequestRate
+}
+
+// scaleConnections adds or removes a connection when the scaler decides to, and returns the
+// change in active connections. Connections are removed one at a time, the least loaded first, and
+// not while other connections are reconnecting.
+func (s *Supervisor) scaleConnections(ctx context.Context, tunnelsWaiting bool) int {
+	if len(s.tunnelsRemoving) > 0 {
+		return 0
+	}
+	loads := make([]connectionLoad, len(s.edgeIPs))
+	for i := range s.edgeIPs {
+		connectionID := s.connectionID(i)
+		loads[i].concurrent = s.config.Metrics.getConcurrentRequests(uint8ToString(connectionID))
+		count := s.config.Metrics.getRequestCount(uint8ToString(connectionID))
+		if last, ok := s.requestCounts[connectionID]; ok && count >= last {
+			loads[i].requests = count - last
+		}
+		s.requestCounts[connectionID] = count
+	}
+	change, index := s.scaler.sample(loads, haScaleInterval)
+	switch change {
+	case 1:
+		addr := s.selector.next(s.edgeIPs)
+		if addr == nil {
+			return 0
+		}
+		s.edgeIPs = append(s.edgeIPs, addr)
+		s.assignConnectionID(index)
+		Log.Infof("Adding HA connection %d to edge %s", index, addr)
+		go s.startTunnel(s.newTunnelContext(ctx, index), index, s.connectionID(index), s.newConnectedTunnelSignal(index))
+		return 1
+	case -1:
+		if tunnelsWaiting || len(s.tunnelsMoving) > 0 {
+			return 0
+		}
+		Log.Infof("Removing idle HA connection %d", index)
+		s.tunnelsRemoving[index] = true
+		s.tunnelCancels[index]()
+	}
+	return 0
 }
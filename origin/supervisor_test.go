@@ -0,0 +1,58 @@
+package origin
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuplicateLocation(t *testing.T) {
+	// the last connection sharing a location moves
+	assert.Equal(t, 3, duplicateLocation([]string{"LHR", "AMS", "LHR", "AMS"}, 3))
+	assert.Equal(t, 2, duplicateLocation([]string{"LHR", "LHR", "LHR", ""}, 2))
+	// enough locations already
+	assert.Equal(t, -1, duplicateLocation([]string{"LHR", "AMS", "LHR", "AMS"}, 2))
+	// connections that aren't connected don't count
+	assert.Equal(t, -1, duplicateLocation([]string{"LHR", "", "", "AMS"}, 4))
+	assert.Equal(t, -1, duplicateLocation(nil, 2))
+}
+
+func TestRemoveConnection(t *testing.T) {
+	s := NewSupervisor(&TunnelConfig{})
+	for i := 0; i < 4; i++ {
+		s.edgeIPs = append(s.edgeIPs, &net.TCPAddr{IP: net.IPv4(192, 0, 2, byte(i)), Port: 7844})
+	}
+	// connection 3 replaced a connection the edge went away from, which had ID 3
+	s.connectionIDs[3] = 4
+	s.tunnelsMoving[2] = true
+	s.tunnelsConnecting[3] = make(chan struct{})
+	s.nextConnectedIndex = 3
+
+	s.removeConnection(1)
+	assert.Equal(t, 3, len(s.edgeIPs))
+	assert.Equal(t, "192.0.2.2:7844", s.edgeIPs[1].String())
+	// the connections after it keep their IDs
+	assert.Equal(t, []uint8{0, 2, 4}, []uint8{s.connectionID(0), s.connectionID(1), s.connectionID(2)})
+	assert.Equal(t, 2, s.connectionIndex(4))
+	assert.Equal(t, -1, s.connectionIndex(1))
+	assert.Equal(t, map[int]bool{1: true}, s.tunnelsMoving)
+	assert.Contains(t, s.tunnelsConnecting, 2)
+	assert.NotContains(t, s.tunnelsConnecting, 3)
+	assert.Equal(t, 2, s.nextConnectedIndex)
+}
+
+func TestConnectedEdgeIPFallback(t *testing.T) {
+	s := NewSupervisor(&TunnelConfig{})
+	v6 := &net.TCPAddr{IP: net.ParseIP("2606:4700::1"), Port: 7844}
+	v4 := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 7844}
+	s.edgeIPs = []*net.TCPAddr{v6}
+	s.tunnelFallbacks[0] = &edgeFallback{addr: v4}
+	assert.Equal(t, v6, s.connectedEdgeIP(0))
+
+	// the connection switches to the fallback that won, and the version that lost counts a failure
+	s.tunnelFallbacks[0].setWon(true)
+	assert.Equal(t, v4, s.connectedEdgeIP(0))
+	assert.Equal(t, []*net.TCPAddr{v4}, s.edgeIPs)
+	assert.Equal(t, 1, s.selector.dialFailures[IPv6])
+}
@@ -0,0 +1,249 @@
+package origin
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+
+	"github.com/pkg/errors"
+	"github.com/quic-go/quic-go"
+)
+
+const (
+	// ProtocolH2Mux multiplexes streams over a TLS connection to the edge.
+	ProtocolH2Mux = "h2mux"
+	// ProtocolQUIC carries each stream on its own QUIC stream, so that packet loss only stalls the
+	// affected stream.
+	ProtocolQUIC = "quic"
+)
+
+// EdgeTransport establishes muxed connections to the edge.
+type EdgeTransport interface {
+	// Dial connects to the edge server at addr, and returns the connection along with its local
+	// address. Errors connecting to the edge are returned as dialError.
+	Dial(ctx context.Context, addr string, config h2mux.MuxerConfig) (h2mux.MuxedConnection, string, error)
+}
+
+// NewEdgeTransport returns the EdgeTransport for protocol, which is ProtocolH2Mux if empty.
+func NewEdgeTransport(protocol string, tlsConfig *tls.Config) (EdgeTransport, error) {
+	switch protocol {
+	case "", ProtocolH2Mux:
+		return &h2muxTransport{tlsConfig: tlsConfig}, nil
+	case ProtocolQUIC:
+		return &quicTransport{tlsConfig: tlsConfig}, nil
+	default:
+		return nil, fmt.Errorf("Unknown protocol %#v", protocol)
+	}
+}
+
+// fallbackDelay is how long a dial waits for the edge address to connect before also dialing the
+// fallback address, like happy eyeballs (RFC 8305).
+const fallbackDelay = 300 * time.Millisecond
+
+var dialer = net.Dialer{DualStack: true}
+
+// dialTrace is called as an EdgeTransport dial progresses, like httptrace.ClientTrace.
+type dialTrace struct {
+	// Handshaking is called once the connection to the edge is open, before the handshakes.
+	Handshaking func()
+}
+
+type dialTraceKey struct{}
+
+func withDialTrace(ctx context.Context, trace *dialTrace) context.Context {
+	return context.WithValue(ctx, dialTraceKey{}, trace)
+}
+
+func traceHandshaking(ctx context.Context) {
+	if trace, ok := ctx.Value(dialTraceKey{}).(*dialTrace); ok && trace.Handshaking != nil {
+		trace.Handshaking()
+	}
+}
+
+// edgeFallback is an edge address of the other IP version that a dial races against the edge
+// address, once the edge address failed or hasn't connected after fallbackDelay. The first
+// connection to finish its handshake is used.
+type edgeFallback struct {
+	addr *net.TCPAddr
+
+	lock sync.Mutex
+	won  bool
+}
+
+// Won returns whether the last dial connected to the fallback address.
+func (f *edgeFallback) Won() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.won
+}
+
+func (f *edgeFallback) setWon(won bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.won = won
+}
+
+type edgeFallbackKey struct{}
+
+func withEdgeFallback(ctx context.Context, fallback *edgeFallback) context.Context {
+	return context.WithValue(ctx, edgeFallbackKey{}, fallback)
+}
+
+// dialEdge dials addr, racing it against the fallback address of ctx if there is one, and returns
+// the connection that won.
+func dialEdge(ctx context.Context, addr string, dial func(context.Context, string) (io.Closer, error)) (io.Closer, error) {
+	fallback, ok := ctx.Value(edgeFallbackKey{}).(*edgeFallback)
+	if !ok || fallback.addr == nil {
+		return dial(ctx, addr)
+	}
+	conn, connAddr, err := dialRace(ctx, addr, fallback.addr.String(), dial)
+	if err == nil {
+		fallback.setWon(connAddr != addr)
+	}
+	return conn, err
+}
+
+// dialRace dials addr, and fallback once addr failed or hasn't connected after fallbackDelay. It
+// returns the first connection established along with the address it was dialed at, and closes
+// the other. If both fail, the error dialing addr is returned.
+func dialRace(ctx context.Context, addr, fallback string, dial func(context.Context, string) (io.Closer, error)) (io.Closer, string, error) {
+	if fallback == addr {
+		conn, err := dial(ctx, addr)
+		return conn, addr, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		conn io.Closer
+		addr string
+		err  error
+	}
+	results := make(chan result, 2)
+	pending := 0
+	start := func(addr string) {
+		pending++
+		go func() {
+			conn, err := dial(ctx, addr)
+			results <- result{conn: conn, addr: addr, err: err}
+		}()
+	}
+	start(addr)
+	fallbackTimer := time.NewTimer(fallbackDelay)
+	defer fallbackTimer.Stop()
+	fallbackStarted := false
+	var addrErr error
+	for {
+		select {
+		case <-fallbackTimer.C:
+			if !fallbackStarted {
+				fallbackStarted = true
+				start(fallback)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				if pending > 0 {
+					// The other dial is cancelled, but may have connected already
+					go func() {
+						if r := <-results; r.err == nil {
+							r.conn.Close()
+						}
+					}()
+				}
+				return r.conn, r.addr, nil
+			}
+			if r.addr == addr {
+				addrErr = r.err
+			}
+			if !fallbackStarted {
+				fallbackStarted = true
+				start(fallback)
+			} else if pending == 0 {
+				return nil, addr, addrErr
+			}
+		}
+	}
+}
+
+// h2muxTransport runs h2mux over TCP and TLS.
+type h2muxTransport struct {
+	tlsConfig *tls.Config
+}
+
+func (t *h2muxTransport) Dial(ctx context.Context, addr string, config h2mux.MuxerConfig) (h2mux.MuxedConnection, string, error) {
+	conn, err := dialEdge(ctx, addr, t.dialTLS)
+	if err != nil {
+		return nil, "", err
+	}
+	edgeConn := conn.(*tls.Conn)
+	// Establish a muxed connection with the edge
+	// Client mux handshake with agent server
+	muxer, err := h2mux.Handshake(edgeConn, edgeConn, config)
+	if err != nil {
+		return nil, "", err
+	}
+	return muxer, edgeConn.LocalAddr().String(), nil
+}
+
+func (t *h2muxTransport) dialTLS(ctx context.Context, addr string) (io.Closer, error) {
+	// Inherit from parent context so we can cancel (Ctrl-C) while dialing
+	dialCtx, dialCancel := context.WithTimeout(ctx, dialTimeout)
+	// TUN-92: enforce a timeout on dial and handshake (as tls.Dial does not support one)
+	plaintextEdgeConn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	dialCancel()
+	if err != nil {
+		return nil, dialError{cause: errors.Wrap(err, "DialContext error")}
+	}
+	traceHandshaking(ctx)
+	edgeConn := tls.Client(plaintextEdgeConn, t.tlsConfig)
+	edgeConn.SetDeadline(time.Now().Add(dialTimeout))
+	err = edgeConn.HandshakeContext(ctx)
+	if err != nil {
+		plaintextEdgeConn.Close()
+		return nil, dialError{cause: errors.Wrap(err, "Handshake with edge error")}
+	}
+	// clear the deadline on the conn; h2mux has its own timeouts
+	edgeConn.SetDeadline(time.Time{})
+	return edgeConn, nil
+}
+
+// quicTransport runs the muxer over QUIC, on the UDP port with the same number as the edge's TCP port.
+type quicTransport struct {
+	tlsConfig *tls.Config
+}
+
+func (t *quicTransport) Dial(ctx context.Context, addr string, config h2mux.MuxerConfig) (h2mux.MuxedConnection, string, error) {
+	tlsConfig := t.tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{h2mux.QUICProtocol}
+	conn, err := dialEdge(ctx, addr, func(ctx context.Context, addr string) (io.Closer, error) {
+		// Inherit from parent context so we can cancel (Ctrl-C) while dialing
+		dialCtx, dialCancel := context.WithTimeout(ctx, dialTimeout)
+		defer dialCancel()
+		conn, err := quic.DialAddr(dialCtx, addr, tlsConfig, h2mux.NewQUICConfig(config))
+		if err != nil {
+			return nil, dialError{cause: errors.Wrap(err, "QUIC dial error")}
+		}
+		return quicConn{conn}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	quicConn := conn.(quicConn)
+	return h2mux.NewQUICMuxer(quicConn.Conn, config), quicConn.LocalAddr().String(), nil
+}
+
+// quicConn closes a QUIC connection without an error code, when it lost a dial race.
+type quicConn struct {
+	*quic.Conn
+}
+
+func (c quicConn) Close() error {
+	return c.CloseWithError(0, "")
+}
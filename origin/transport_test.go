@@ -0,0 +1,119 @@
+package origin
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConn struct {
+	addr   string
+	lock   sync.Mutex
+	closed bool
+}
+
+func (c *testConn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *testConn) isClosed() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.closed
+}
+
+// testDial connects to each addr after its delay, or fails if it has none.
+func testDial(delays map[string]time.Duration, conns chan *testConn) func(context.Context, string) (io.Closer, error) {
+	return func(ctx context.Context, addr string) (io.Closer, error) {
+		delay, ok := delays[addr]
+		if !ok {
+			return nil, dialError{cause: errors.New("unreachable " + addr)}
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, dialError{cause: ctx.Err()}
+		}
+		conn := &testConn{addr: addr}
+		if conns != nil {
+			conns <- conn
+		}
+		return conn, nil
+	}
+}
+
+func TestDialRace(t *testing.T) {
+	// the addr connects before the fallback is dialed
+	start := time.Now()
+	conn, addr, err := dialRace(context.Background(), "v6", "v4", testDial(map[string]time.Duration{"v6": 10 * time.Millisecond, "v4": 0}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v6", addr)
+	assert.Equal(t, "v6", conn.(*testConn).addr)
+	assert.True(t, time.Since(start) < fallbackDelay)
+
+	// the addr hangs, so the fallback is dialed after fallbackDelay and wins
+	start = time.Now()
+	conn, addr, err = dialRace(context.Background(), "v6", "v4", testDial(map[string]time.Duration{"v6": time.Hour, "v4": 0}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v4", addr)
+	assert.True(t, time.Since(start) >= fallbackDelay)
+
+	// the addr fails, so the fallback is dialed right away
+	start = time.Now()
+	_, addr, err = dialRace(context.Background(), "v6", "v4", testDial(map[string]time.Duration{"v4": 0}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "v4", addr)
+	assert.True(t, time.Since(start) < fallbackDelay)
+
+	// both fail with the error of the addr
+	_, _, err = dialRace(context.Background(), "v6", "v4", testDial(nil, nil))
+	assert.EqualError(t, err, "unreachable v6")
+}
+
+func TestDialRaceClosesLoser(t *testing.T) {
+	conns := make(chan *testConn, 2)
+	// both connect at about the same time once the fallback is dialed
+	dial := testDial(map[string]time.Duration{"v6": fallbackDelay + 20*time.Millisecond, "v4": 20 * time.Millisecond}, conns)
+	dial = func(dial func(context.Context, string) (io.Closer, error)) func(context.Context, string) (io.Closer, error) {
+		return func(_ context.Context, addr string) (io.Closer, error) {
+			// ignore cancellation, like a dial that completed as the race was decided
+			return dial(context.Background(), addr)
+		}
+	}(dial)
+	conn, _, err := dialRace(context.Background(), "v6", "v4", dial)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		c := <-conns
+		if c == conn {
+			continue
+		}
+		for start := time.Now(); !c.isClosed() && time.Since(start) < time.Second; {
+			time.Sleep(time.Millisecond)
+		}
+		assert.True(t, c.isClosed())
+	}
+	assert.False(t, conn.(*testConn).isClosed())
+}
+
+func TestDialEdgeFallback(t *testing.T) {
+	dial := testDial(map[string]time.Duration{"127.0.0.1:7844": 0}, nil)
+	// without a fallback, only the addr is dialed
+	_, err := dialEdge(context.Background(), "[::1]:7844", dial)
+	assert.Error(t, err)
+
+	fallback := &edgeFallback{addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 7844}}
+	ctx := withEdgeFallback(context.Background(), fallback)
+	_, err = dialEdge(ctx, "[::1]:7844", dial)
+	assert.NoError(t, err)
+	assert.True(t, fallback.Won())
+}
@@ -4,9 +4,12 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -14,6 +17,7 @@ import (
 	"time"
 
 	"golang.org/x/net/context"
+	"golang.org/x/net/http2"
 
 	"github.com/cloudflare/cloudflare-warp/h2mux"
 	"github.com/cloudflare/cloudflare-warp/tunnelrpc"
@@ -32,28 +36,71 @@ var Log *logrus.Logger
 
 const (
 	dialTimeout = 15 * time.Second
+	// unregisterTimeout bounds how long shutdown waits for the edge to acknowledge unregistration.
+	unregisterTimeout = 5 * time.Second
+	// rateLimitRetryAfter is the shortest wait after the edge rate limits a registration.
+	rateLimitRetryAfter = time.Minute
 
 	TagHeaderNamePrefix      = "Cf-Warp-Tag-"
 	DuplicateConnectionError = "EDUPCONN"
 )
 
 type TunnelConfig struct {
-	EdgeAddrs         []string
-	OriginUrl         string
-	Hostname          string
-	OriginCert        []byte
-	TlsConfig         *tls.Config
-	ClientTlsConfig   *tls.Config
-	Retries           uint
+	EdgeAddrs       []string
+	OriginUrl       string
+	Hostname        string
+	Hostnames       []string
+	OriginCert      []byte
+	Credentials     *tunnelpogs.TunnelCredentials
+	TlsConfig       *tls.Config
+	ClientTlsConfig *tls.Config
+	Retries         uint
+	// BackoffPolicy and MaxBackoff shape the backoff between retries
+	BackoffPolicy     BackoffPolicy
+	MaxBackoff        time.Duration
 	HeartbeatInterval time.Duration
 	MaxHeartbeats     uint64
-	ClientID          string
-	ReportedVersion   string
-	LBPool            string
-	Tags              []tunnelpogs.Tag
-	HAConnections     int
-	HTTPTransport     http.RoundTripper
-	Metrics           *TunnelMetrics
+	DeadPeerDetection bool
+	// Protocol selects the EdgeTransport used to connect to the edge
+	Protocol string
+	// CaptureDir is the directory to write a frame capture of each connection to, if set
+	CaptureDir    string
+	RedactCapture bool
+	// Stream timeouts passed on to h2mux.MuxerConfig
+	StreamIdleTimeout    time.Duration
+	StreamMaxLifetime    time.Duration
+	WebSocketIdleTimeout time.Duration
+	WebSocketMaxLifetime time.Duration
+	ClientID             string
+	ReportedVersion      string
+	LBPool               string
+	Tags                 []tunnelpogs.Tag
+	HAConnections        int
+	// HAMinLocations is the number of distinct edge locations the supervisor spreads the HA
+	// connections across, if enough SRV targets are available. Off unless above 1.
+	HAMinLocations int
+	// HAMinConnections and HAMaxConnections bound the number of HA connections when it scales
+	// with the load. A connection is added while the connections proxy more than
+	// HAScaleUpRequests concurrent requests each on average, and removed while they are idle.
+	HAMinConnections  int
+	HAMaxConnections  int
+	HAScaleUpRequests int
+	// HAScaleUpRequestRate adds a connection while the connections start more requests per second
+	// each than this on average, unless 0
+	HAScaleUpRequestRate int
+	// EdgeDiscovery finds the edge addresses when EdgeAddrs is empty, trying each in order
+	EdgeDiscovery []EdgeDiscovery
+	// EdgeIPVersion restricts the edge addresses to IPv4 or IPv6
+	EdgeIPVersion IPVersion
+	HTTPTransport http.RoundTripper
+	Metrics       *TunnelMetrics
+	// ConnectionTracker records the state of each connection, if set
+	ConnectionTracker *ConnectionTracker
+	// StateFile remembers the edges, registrations and reconnects across restarts, if set
+	StateFile *StateFile
+	// DrainOverlap bounds how long a connection the edge sent GOAWAY on keeps serving its requests
+	// in flight, alongside the connection replacing it. Zero resets them right away.
+	DrainOverlap      time.Duration
 	MetricsUpdateFreq time.Duration
 	ProtocolLogger    *logrus.Logger
 	Logger            *logrus.Logger
@@ -74,15 +121,80 @@ func (e dupConnRegisterTunnelError) Error() string {
 	return "already connected to this server"
 }
 
+// goAwayError is returned by ServeTunnel when the edge sent GOAWAY after the tunnel registered.
+// The connection keeps serving its requests in flight until drain returns, which the caller runs
+// in the background with drainingTunnels while a replacement connects.
+type goAwayError struct {
+	connectionID uint8
+	drain        func(ctx context.Context)
+}
+
+func (e goAwayError) Error() string {
+	return "edge server is going away"
+}
+
+// earlyGoAwayError is returned by ServeTunnel when the edge sent GOAWAY before the tunnel
+// registered. There is nothing to drain, so the connection backs off as after other failures.
+type earlyGoAwayError struct{}
+
+func (e earlyGoAwayError) Error() string {
+	return "edge server went away before the tunnel registered"
+}
+
 type printableRegisterTunnelError struct {
 	cause     error
 	permanent bool
+	// code classifies the error, if the edge did
+	code tunnelrpc.RegistrationErrorCode
+	// retryAfter is how long the edge asked the client to wait before retrying, or zero
+	retryAfter time.Duration
+	details    map[string]string
 }
 
 func (e printableRegisterTunnelError) Error() string {
 	return e.cause.Error()
 }
 
+// retryPolicy returns whether to retry the registration on the same edge server, and how long to
+// wait at least before retrying.
+func (e printableRegisterTunnelError) retryPolicy() (bool, time.Duration) {
+	switch e.code {
+	case tunnelrpc.RegistrationErrorCode_unauthorized, tunnelrpc.RegistrationErrorCode_versionTooOld:
+		// retrying won't help
+		return false, 0
+	case tunnelrpc.RegistrationErrorCode_overloaded:
+		// the supervisor connects to another server instead
+		return false, e.retryAfter
+	case tunnelrpc.RegistrationErrorCode_rateLimited:
+		if e.retryAfter < rateLimitRetryAfter {
+			return true, rateLimitRetryAfter
+		}
+		return true, e.retryAfter
+	}
+	return !e.permanent, e.retryAfter
+}
+
+// isFatalRegistrationError returns true for registration errors that no connection to any edge
+// server can recover from.
+func isFatalRegistrationError(err error) bool {
+	if e, ok := err.(printableRegisterTunnelError); ok {
+		return e.code == tunnelrpc.RegistrationErrorCode_unauthorized || e.code == tunnelrpc.RegistrationErrorCode_versionTooOld
+	}
+	return false
+}
+
+// shouldSwitchEdge returns true for errors after which the client should connect to a different edge
+// server.
+func shouldSwitchEdge(err error) bool {
+	switch e := err.(type) {
+	case reconnectError, goAwayError, earlyGoAwayError:
+		return true
+	case printableRegisterTunnelError:
+		return e.code == tunnelrpc.RegistrationErrorCode_overloaded
+	}
+	return false
+}
+
 func (c *TunnelConfig) RegistrationOptions(connectionID uint8, OriginLocalIP string) *tunnelpogs.RegistrationOptions {
 	policy := tunnelrpc.ExistingTunnelPolicy_balance
 	if c.HAConnections <= 1 && c.LBPool == "" {
@@ -98,6 +210,7 @@ func (c *TunnelConfig) RegistrationOptions(connectionID uint8, OriginLocalIP str
 		ConnectionID:         connectionID,
 		OriginLocalIP:        OriginLocalIP,
 		IsAutoupdated:        c.IsAutoupdated,
+		Features:             clientFeatures,
 	}
 }
 
@@ -108,22 +221,70 @@ func StartTunnelDaemon(config *TunnelConfig, shutdownC <-chan struct{}, connecte
 		<-shutdownC
 		cancel()
 	}()
+	if config.ConnectionTracker == nil {
+		config.ConnectionTracker = NewConnectionTracker()
+	}
+	defer config.ConnectionTracker.Subscribe(config.Metrics.setConnectionState)()
+	config.StateFile.setClientID(config.ClientID)
 	// If a user specified negative HAConnections, we will treat it as requesting 1 connection
-	if config.HAConnections > 1 {
+	if config.HAConnections > 1 || config.HAMaxConnections > 1 {
 		return NewSupervisor(config).Run(ctx, connectedSignal)
 	} else {
-		addrs, err := ResolveEdgeIPs(config.EdgeAddrs)
+		config.ConnectionTracker.setState(0, StateResolving, nil)
+		addrsByTarget, err := resolveEdgeIPs(config)
 		if err != nil {
 			return err
 		}
-		return ServeTunnelLoop(ctx, config, addrs[0], 0, connectedSignal)
+		// Try the addrs that connect fastest first
+		selector := newEdgeSelector(config.TlsConfig)
+		selector.update(addrsByTarget)
+		selector.seed(config.StateFile.State().Edges)
+		selector.probeAll(ctx)
+		addrs := selector.ranked()
+		go persistState(ctx, config, selector)
+		var drains drainingTunnels
+		defer func() {
+			cancel()
+			drains.wait()
+		}()
+		// Move to the next address when the edge asks the client to connect elsewhere
+		connectionID := uint8(0)
+		backoff := BackoffHandler{MaxRetries: config.Retries, RetryForever: true, Policy: config.BackoffPolicy, MaxBackoff: config.MaxBackoff}
+		for i := 0; ; i++ {
+			addr := addrs[i%len(addrs)]
+			fallback := &edgeFallback{addr: selector.fallback(addr, nil)}
+			err = ServeTunnelLoop(withEdgeFallback(ctx, fallback), config, addr, connectionID, connectedSignal)
+			if drains.start(ctx, err) {
+				// the replacement registers with another ID while this connection drains
+				connectionID, _ = drains.freeConnectionID(func(uint8) bool { return false })
+			}
+			if !shouldSwitchEdge(err) {
+				return err
+			}
+			if e, ok := err.(printableRegisterTunnelError); ok {
+				// Overloaded, the next address may be too. Wait as long as the edge asked at least.
+				_, retryAfter := e.retryPolicy()
+				backoff.SetRetryAfter(retryAfter)
+				if !backoff.Backoff(ctx) {
+					return err
+				}
+			}
+			select {
+			case <-connectedSignal:
+				// closed when the previous connection registered
+				connectedSignal = make(chan struct{})
+				backoff.SetGracePeriod()
+			default:
+			}
+		}
 	}
 }
 
 func ServeTunnelLoop(ctx context.Context, config *TunnelConfig, addr *net.TCPAddr, connectionID uint8, connectedSignal chan struct{}) error {
 	config.Metrics.incrementHaConnections()
 	defer config.Metrics.decrementHaConnections()
-	backoff := BackoffHandler{MaxRetries: config.Retries}
+	defer config.Metrics.unregisterServerLocation(uint8ToString(connectionID))
+	backoff := BackoffHandler{MaxRetries: config.Retries, Policy: config.BackoffPolicy, MaxBackoff: config.MaxBackoff}
 	// Used to close connectedSignal no more than once
 	connectedFuse := h2mux.NewBooleanFuse()
 	go func() {
@@ -137,11 +298,20 @@ func ServeTunnelLoop(ctx context.Context, config *TunnelConfig, addr *net.TCPAdd
 		err, recoverable := ServeTunnel(ctx, config, addr, connectionID, connectedFuse, &backoff)
 		if recoverable {
 			if duration, ok := backoff.GetBackoffDuration(ctx); ok {
-				Log.Infof("Retrying in %s seconds", duration)
+				if config.BackoffPolicy == BackoffExponential {
+					Log.Infof("Retrying in %s seconds", duration)
+				} else {
+					Log.Infof("Retrying in up to %s", duration)
+				}
+				config.ConnectionTracker.setState(int(connectionID), StateBackingOff, err)
 				backoff.Backoff(ctx)
 				continue
 			}
 		}
+		if _, ok := err.(goAwayError); !ok {
+			// a connection the edge went away from is draining until it is replaced
+			config.ConnectionTracker.setState(int(connectionID), StateDisconnected, err)
+		}
 		return err
 	}
 }
@@ -174,6 +344,7 @@ func ServeTunnel(
 		switch err.(type) {
 		case dialError:
 			errLog.Error("Unable to dial edge")
+			config.Metrics.incrementDialFailures(ipVersionOf(addr).String())
 		case h2mux.MuxerHandshakeError:
 			errLog.Error("Handshake failed with edge server")
 		default:
@@ -183,17 +354,43 @@ func ServeTunnel(
 		return err, true
 	}
 	serveCtx, serveCancel := context.WithCancel(ctx)
+	client := newTunnelClient(config, handler, connectionID, serveCancel)
 	registerErrC := make(chan error, 1)
+	// registeredC is closed once the tunnel is registered
+	registeredC := make(chan struct{})
+	// drainC is closed when the muxer can start draining, after unregistering if needed
+	drainC := make(chan struct{})
 	go func() {
-		err := RegisterTunnel(serveCtx, handler.muxer, config, connectionID, originLocalIP)
+		defer wg.Done()
+		defer close(drainC)
+		var serverInfo *tunnelpogs.ServerInfo
+		config.ConnectionTracker.setState(int(connectionID), StateRegistering, nil)
+		rpcConn, err := openRPCConn(handler.muxer, Log.WithField("subsystem", "rpc"), client)
 		if err == nil {
-			connectedFuse.Fuse(true)
-			backoff.SetGracePeriod()
-		} else {
+			// The RPC connection stays open for commands from the edge until the tunnel closes.
+			defer rpcConn.Close()
+			serverInfo, err = RegisterTunnel(serveCtx, rpcConn, config, connectionID, originLocalIP)
+		}
+		if err != nil {
 			serveCancel()
+			registerErrC <- err
+			return
+		}
+		features := negotiateFeatures(clientFeatures, serverInfo)
+		if features.has(tunnelpogs.FeatureServerHeartbeat) {
+			applyServerHeartbeat(handler.muxer, serverInfo)
+		}
+		config.ConnectionTracker.setState(int(connectionID), StateConnected, nil)
+		connectedFuse.Fuse(true)
+		close(registeredC)
+		backoff.SetGracePeriod()
+		registerErrC <- nil
+		<-serveCtx.Done()
+		// Unregister before draining if the tunnel is being shut down or moved, rather than failing.
+		// Servers that don't support it would fail the call.
+		if features.has(tunnelpogs.FeatureUnregisterTunnel) && (ctx.Err() != nil || client.reconnectRequested() != nil) {
+			UnregisterTunnel(rpcConn, connectionID)
 		}
-		registerErrC <- err
-		wg.Done()
 	}()
 	updateMetricsTickC := time.Tick(config.MetricsUpdateFreq)
 	go func() {
@@ -201,6 +398,8 @@ func ServeTunnel(
 		for {
 			select {
 			case <-serveCtx.Done():
+				<-drainC
+				config.ConnectionTracker.setState(int(connectionID), StateDraining, nil)
 				handler.muxer.Shutdown()
 				return
 			case <-updateMetricsTickC:
@@ -209,19 +408,59 @@ func ServeTunnel(
 		}
 	}()
 
-	err = handler.muxer.Serve()
+	serveErrC := make(chan error, 1)
+	go func() {
+		serveErrC <- handler.muxer.Serve()
+	}()
+	goingAway, wentAwayEarly := false, false
+	select {
+	case err = <-serveErrC:
+		handler.closeCapture()
+	case <-handler.goingAway:
+		select {
+		case <-registeredC:
+			// Make before break: return so that the replacement connects while the requests in
+			// flight finish, as the edge sends no new requests on this connection.
+			goingAway = true
+		default:
+			// No requests were sent before the tunnel registered, so there is nothing to drain
+			wentAwayEarly = true
+			handler.muxer.Abort()
+			err = <-serveErrC
+			handler.closeCapture()
+		}
+	}
 	serveCancel()
 	registerErr := <-registerErrC
 	wg.Wait()
+	if goingAway {
+		return goAwayError{
+			connectionID: connectionID,
+			drain: func(ctx context.Context) {
+				drainTunnel(ctx, config, handler, connectionID, serveErrC)
+			},
+		}, false
+	}
+	if wentAwayEarly {
+		Log.Warn("Edge server went away before the tunnel registered")
+		return earlyGoAwayError{}, true
+	}
 	if err != nil {
 		Log.WithError(err).Error("Tunnel error")
 		return err, true
 	}
+	if err := client.reconnectRequested(); err != nil {
+		return err, false
+	}
 	if registerErr != nil {
 		// Don't retry on errors like entitlement failure or version too old
 		if e, ok := registerErr.(printableRegisterTunnelError); ok {
-			Log.Error(e)
-			return e.cause, !e.permanent
+			Log.WithField("code", e.code).WithFields(errorDetailFields(e.details)).Error(e)
+			recoverable, retryAfter := e.retryPolicy()
+			if retryAfter > 0 {
+				backoff.SetRetryAfter(retryAfter)
+			}
+			return e, recoverable
 		} else if e, ok := registerErr.(dupConnRegisterTunnelError); ok {
 			Log.Info("Already connected to this server, selecting a different one")
 			return e, true
@@ -234,6 +473,85 @@ func ServeTunnel(
 	return nil, false
 }
 
+// drainTunnel waits for a connection the edge sent GOAWAY on to finish its requests in flight.
+// Those still in flight after config.DrainOverlap, or once ctx is done, are reset.
+func drainTunnel(ctx context.Context, config *TunnelConfig, handler *TunnelHandler, connectionID uint8, serveErrC <-chan error) {
+	start := time.Now()
+	config.Metrics.incrementDrainingConnections()
+	timer := time.NewTimer(config.DrainOverlap)
+	defer timer.Stop()
+	timedOut := false
+	select {
+	case err := <-serveErrC:
+		if err != nil {
+			Log.WithError(err).Errorf("Tunnel error while draining connection %d", connectionID)
+		}
+	case <-timer.C:
+		Log.Warnf("Connection %d still has requests in flight %s after its edge server went away, closing it", connectionID, config.DrainOverlap)
+		timedOut = true
+		handler.muxer.Abort()
+		<-serveErrC
+	case <-ctx.Done():
+		handler.muxer.Abort()
+		<-serveErrC
+	}
+	handler.closeCapture()
+	config.ConnectionTracker.setState(int(connectionID), StateDisconnected, nil)
+	// its replacement registered with another connection ID
+	config.ConnectionTracker.remove(int(connectionID))
+	config.Metrics.decrementDrainingConnections(time.Since(start), timedOut)
+}
+
+// drainingTunnels runs the connections the edge went away from until their requests in flight
+// finish, and keeps their connection IDs so that the connections replacing them register with
+// other IDs.
+type drainingTunnels struct {
+	lock sync.Mutex
+	ids  map[uint8]bool
+	wg   sync.WaitGroup
+}
+
+// start drains the connection that returned err in the background, until ctx is done, if the
+// edge went away from it. It returns false for other errors.
+func (d *drainingTunnels) start(ctx context.Context, err error) bool {
+	goAway, ok := err.(goAwayError)
+	if !ok {
+		return false
+	}
+	d.lock.Lock()
+	if d.ids == nil {
+		d.ids = map[uint8]bool{}
+	}
+	d.ids[goAway.connectionID] = true
+	d.lock.Unlock()
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		goAway.drain(ctx)
+		d.lock.Lock()
+		delete(d.ids, goAway.connectionID)
+		d.lock.Unlock()
+	}()
+	return true
+}
+
+// freeConnectionID returns the lowest connection ID that isn't draining or inUse, if there is one.
+func (d *drainingTunnels) freeConnectionID(inUse func(uint8) bool) (uint8, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for id := 0; id <= math.MaxUint8; id++ {
+		if !d.ids[uint8(id)] && !inUse(uint8(id)) {
+			return uint8(id), true
+		}
+	}
+	return 0, false
+}
+
+// wait returns once the draining connections are closed.
+func (d *drainingTunnels) wait() {
+	d.wg.Wait()
+}
+
 func IsRPCStreamResponse(headers []h2mux.Header) bool {
 	if len(headers) != 1 {
 		return false
@@ -244,8 +562,9 @@ func IsRPCStreamResponse(headers []h2mux.Header) bool {
 	return true
 }
 
-func RegisterTunnel(ctx context.Context, muxer *h2mux.Muxer, config *TunnelConfig, connectionID uint8, originLocalIP string) error {
-	logger := Log.WithField("subsystem", "rpc")
+// openRPCConn opens a stream to the edge for a capnp RPC connection, on which client is exported
+// for the edge to send commands, unless it is nil.
+func openRPCConn(muxer h2mux.MuxedConnection, logger *logrus.Entry, client tunnelpogs.TunnelClient) (*rpc.Conn, error) {
 	logger.Debug("initiating RPC stream")
 	stream, err := muxer.OpenStream([]h2mux.Header{
 		{Name: ":method", Value: "RPC"},
@@ -255,68 +574,194 @@ func RegisterTunnel(ctx context.Context, muxer *h2mux.Muxer, config *TunnelConfi
 	if err != nil {
 		// RPC stream open error
 		raven.CaptureError(err, nil)
-		return err
+		return nil, err
 	}
 	if !IsRPCStreamResponse(stream.Headers) {
 		// stream response error
+		err = fmt.Errorf("unexpected RPC stream response: %v", stream.Headers)
 		raven.CaptureError(err, nil)
-		return err
+		return nil, err
 	}
-	conn := rpc.NewConn(
-		tunnelrpc.NewTransportLogger(logger, rpc.StreamTransport(stream)),
-		tunnelrpc.ConnLog(logger.WithField("subsystem", "rpc-transport")),
-	)
-	defer conn.Close()
+	// The RPC connection lasts as long as the tunnel connection
+	stream.DisableTimeouts()
+	options := []rpc.ConnOption{tunnelrpc.ConnLog(logger.WithField("subsystem", "rpc-transport"))}
+	if client != nil {
+		options = append(options, rpc.MainInterface(tunnelpogs.TunnelClient_ServerToClient(client).Client))
+	}
+	return rpc.NewConn(tunnelrpc.NewTransportLogger(logger, rpc.StreamTransport(stream)), options...), nil
+}
+
+// RegisterTunnel registers a tunnel connection over its RPC connection. It returns the server info
+// if it was available, even if registration failed.
+func RegisterTunnel(ctx context.Context, conn *rpc.Conn, config *TunnelConfig, connectionID uint8, originLocalIP string) (*tunnelpogs.ServerInfo, error) {
+	logger := Log.WithField("subsystem", "rpc")
 	ts := tunnelpogs.TunnelServer_PogsClient{Client: conn.Bootstrap(ctx)}
 	// Request server info without blocking tunnel registration; must use capnp library directly.
 	tsClient := tunnelrpc.TunnelServer{Client: ts.Client}
 	serverInfoPromise := tsClient.GetServerInfo(ctx, func(tunnelrpc.TunnelServer_getServerInfo_Params) error {
 		return nil
 	})
-	registration, err := ts.RegisterTunnel(
-		ctx,
-		config.OriginCert,
-		config.Hostname,
-		config.RegistrationOptions(connectionID, originLocalIP),
-	)
-	LogServerInfo(logger, serverInfoPromise.Result(), connectionID, config.Metrics)
+	options := config.RegistrationOptions(connectionID, originLocalIP)
+	if len(config.Hostnames) > 0 {
+		registrations, err := ts.RegisterTunnels(ctx, config.OriginCert, config.hostnameRegistrations(options))
+		serverInfo := LogServerInfo(logger, serverInfoPromise.Result(), connectionID, config.Metrics)
+		if err != nil {
+			// RegisterTunnels RPC failure
+			return serverInfo, err
+		}
+		if err := processRegistrations(logger, registrations); err != nil {
+			return serverInfo, err
+		}
+		for _, registration := range registrations {
+			if registration.Registration.Err == "" {
+				config.StateFile.recordRegistration(registration.Hostname, registration.Registration.Url)
+			}
+		}
+		return serverInfo, nil
+	}
+	var registration *tunnelpogs.TunnelRegistration
+	var err error
+	if config.Credentials != nil {
+		registration, err = ts.RegisterNamedTunnel(ctx, config.Credentials, config.Hostname, options)
+	} else {
+		registration, err = ts.RegisterTunnel(
+			ctx,
+			config.OriginCert,
+			config.Hostname,
+			options,
+		)
+	}
+	serverInfo := LogServerInfo(logger, serverInfoPromise.Result(), connectionID, config.Metrics)
 	if err != nil {
 		// RegisterTunnel RPC failure
-		return err
+		return serverInfo, err
+	}
+	if err := processRegistration(logger, registration); err != nil {
+		return serverInfo, err
+	}
+
+	Log.Infof("Registered at %s", registration.Url)
+	config.StateFile.recordRegistration(config.Hostname, registration.Url)
+	return serverInfo, nil
+}
+
+// UnregisterTunnel tells the edge that the connection is about to close, so that it stops sending
+// new requests to it. Errors are logged, as the connection is closed anyway.
+func UnregisterTunnel(conn *rpc.Conn, connectionID uint8) {
+	logger := Log.WithField("subsystem", "rpc")
+	ctx, cancel := context.WithTimeout(context.Background(), unregisterTimeout)
+	defer cancel()
+	ts := tunnelpogs.TunnelServer_PogsClient{Client: conn.Bootstrap(ctx)}
+	if err := ts.UnregisterTunnel(ctx); err != nil {
+		logger.WithError(err).Warn("Cannot unregister")
+		return
 	}
+	logger.Infof("Unregistered connection %d", connectionID)
+}
+
+func (c *TunnelConfig) hostnameRegistrations(options *tunnelpogs.RegistrationOptions) []tunnelpogs.HostnameRegistration {
+	hostnames := []tunnelpogs.HostnameRegistration{{Hostname: c.Hostname, Options: *options}}
+	for _, hostname := range c.Hostnames {
+		hostnames = append(hostnames, tunnelpogs.HostnameRegistration{Hostname: hostname, Options: *options})
+	}
+	return hostnames
+}
+
+// processRegistration logs the messages in a registration, and returns its error.
+func processRegistration(logger *logrus.Entry, registration *tunnelpogs.TunnelRegistration) error {
 	for _, logLine := range registration.LogLines {
 		logger.Info(logLine)
 	}
-	if registration.Err == DuplicateConnectionError {
+	// Edges that don't send error codes flag duplicate connections with DuplicateConnectionError
+	if registration.ErrorCode == tunnelrpc.RegistrationErrorCode_duplicateConnection || registration.Err == DuplicateConnectionError {
 		return dupConnRegisterTunnelError{}
 	} else if registration.Err != "" {
+		var details map[string]string
+		if len(registration.ErrorDetails) > 0 {
+			details = make(map[string]string, len(registration.ErrorDetails))
+			for _, detail := range registration.ErrorDetails {
+				details[detail.Key] = detail.Value
+			}
+		}
 		return printableRegisterTunnelError{
-			cause:     fmt.Errorf("Server error: %s", registration.Err),
-			permanent: registration.PermanentFailure,
+			cause:      fmt.Errorf("Server error: %s", registration.Err),
+			permanent:  registration.PermanentFailure,
+			code:       registration.ErrorCode,
+			retryAfter: time.Duration(registration.RetryAfterSeconds) * time.Second,
+			details:    details,
 		}
 	}
-
-	Log.Infof("Registered at %s", registration.Url)
 	return nil
 }
 
+// errorDetailFields returns the details of a registration error as log fields.
+func errorDetailFields(details map[string]string) logrus.Fields {
+	fields := logrus.Fields{}
+	for key, value := range details {
+		fields["detail."+key] = value
+	}
+	return fields
+}
+
+// processRegistrations handles the result of registering several hostnames. The connection is kept
+// as long as one hostname is registered. If none are, the failure is only permanent if it is
+// permanent for every hostname, and only keeps its code if every hostname failed the same way.
+func processRegistrations(logger *logrus.Entry, registrations []tunnelpogs.HostnameTunnelRegistration) error {
+	var failure *printableRegisterTunnelError
+	registered := 0
+	for i := range registrations {
+		hostname, registration := registrations[i].Hostname, &registrations[i].Registration
+		switch err := processRegistration(logger.WithField("hostname", hostname), registration).(type) {
+		case nil:
+			Log.Infof("Registered %s at %s", hostname, registration.Url)
+			registered++
+		case dupConnRegisterTunnelError:
+			return err
+		case printableRegisterTunnelError:
+			Log.WithError(err).Errorf("Cannot register %s", hostname)
+			if failure == nil {
+				failure = &err
+			} else {
+				failure.permanent = failure.permanent && err.permanent
+				if failure.code != err.code {
+					failure.code = tunnelrpc.RegistrationErrorCode_unknown
+				}
+				if failure.retryAfter < err.retryAfter {
+					failure.retryAfter = err.retryAfter
+				}
+			}
+		}
+	}
+	if registered > 0 {
+		return nil
+	}
+	if failure == nil {
+		return fmt.Errorf("no hostnames were registered")
+	}
+	return *failure
+}
+
+// LogServerInfo logs the server info and records its location, and returns it. It returns nil if the
+// server info is unavailable.
 func LogServerInfo(logger *logrus.Entry,
 	promise tunnelrpc.ServerInfo_Promise,
 	connectionID uint8,
 	metrics *TunnelMetrics,
-) {
+) *tunnelpogs.ServerInfo {
 	serverInfoMessage, err := promise.Struct()
 	if err != nil {
 		logger.WithError(err).Warn("Failed to retrieve server information")
-		return
+		return nil
 	}
 	serverInfo, err := tunnelpogs.UnmarshalServerInfo(serverInfoMessage)
 	if err != nil {
 		logger.WithError(err).Warn("Failed to retrieve server information")
-		return
+		return nil
 	}
 	Log.Infof("Connected to %s", serverInfo.LocationName)
+	logger.WithField("version", serverInfo.Version).WithField("features", serverInfo.SupportedFeatures).Debug("Server info")
 	metrics.registerServerLocation(uint8ToString(connectionID), serverInfo.LocationName)
+	return serverInfo
 }
 
 func H2RequestHeadersToH1Request(h2 []h2mux.Header, h1 *http.Request) error {
@@ -358,23 +803,35 @@ func H1ResponseToH2Response(h1 *http.Response) (h2 []h2mux.Header) {
 
 type TunnelHandler struct {
 	originUrl  string
-	muxer      *h2mux.Muxer
+	muxer      h2mux.MuxedConnection
 	httpClient http.RoundTripper
 	tlsConfig  *tls.Config
 	tags       []tunnelpogs.Tag
 	metrics    *TunnelMetrics
 	// connectionID is only used by metrics, and prometheus requires labels to be string
 	connectionID string
+	// rtt is the last RTT measurement exported to metrics
+	rtt h2mux.RTTMeasurement
+	// streamResets is the last snapshot of the muxer's reset counters exported to metrics
+	streamResets *h2mux.StreamResetMetrics
+	// capture records the frames of the connection, if enabled
+	capture *h2mux.FrameCapture
+	// goingAway is closed when the edge sends GOAWAY
+	goingAway chan struct{}
+	// tagsLock protects tags, which the edge can replace
+	tagsLock sync.RWMutex
 }
 
-var dialer = net.Dialer{DualStack: true}
-
 // NewTunnelHandler returns a TunnelHandler, origin LAN IP and error
 func NewTunnelHandler(ctx context.Context, config *TunnelConfig, addr string, connectionID uint8) (*TunnelHandler, string, error) {
 	url, err := validation.ValidateUrl(config.OriginUrl)
 	if err != nil {
 		return nil, "", fmt.Errorf("Unable to parse origin url %#v", url)
 	}
+	transport, err := NewEdgeTransport(config.Protocol, config.TlsConfig)
+	if err != nil {
+		return nil, "", err
+	}
 	h := &TunnelHandler{
 		originUrl:    url,
 		httpClient:   config.HTTPTransport,
@@ -382,54 +839,97 @@ func NewTunnelHandler(ctx context.Context, config *TunnelConfig, addr string, co
 		tags:         config.Tags,
 		metrics:      config.Metrics,
 		connectionID: uint8ToString(connectionID),
+		streamResets: &h2mux.StreamResetMetrics{},
+		goingAway:    make(chan struct{}),
 	}
 	if h.httpClient == nil {
 		h.httpClient = http.DefaultTransport
 	}
-	// Inherit from parent context so we can cancel (Ctrl-C) while dialing
-	dialCtx, dialCancel := context.WithTimeout(ctx, dialTimeout)
-	// TUN-92: enforce a timeout on dial and handshake (as tls.Dial does not support one)
-	plaintextEdgeConn, err := dialer.DialContext(dialCtx, "tcp", addr)
-	dialCancel()
-	if err != nil {
-		return nil, "", dialError{cause: errors.Wrap(err, "DialContext error")}
+	muxerConfig := h2mux.MuxerConfig{
+		Timeout:              5 * time.Second,
+		Handler:              h,
+		IsClient:             true,
+		HeartbeatInterval:    config.HeartbeatInterval,
+		MaxHeartbeats:        config.MaxHeartbeats,
+		DeadPeerDetection:    config.DeadPeerDetection,
+		StreamIdleTimeout:    config.StreamIdleTimeout,
+		StreamMaxLifetime:    config.StreamMaxLifetime,
+		WebSocketIdleTimeout: config.WebSocketIdleTimeout,
+		WebSocketMaxLifetime: config.WebSocketMaxLifetime,
+		Logger:               config.ProtocolLogger,
+		GoingAway: func() {
+			Log.Infof("Edge server of connection %d is going away, replacing the connection", connectionID)
+			close(h.goingAway)
+		},
 	}
-	edgeConn := tls.Client(plaintextEdgeConn, config.TlsConfig)
-	edgeConn.SetDeadline(time.Now().Add(dialTimeout))
-	err = edgeConn.Handshake()
-	if err != nil {
-		return nil, "", dialError{cause: errors.Wrap(err, "Handshake with edge error")}
-	}
-	// clear the deadline on the conn; h2mux has its own timeouts
-	edgeConn.SetDeadline(time.Time{})
-	// Establish a muxed connection with the edge
-	// Client mux handshake with agent server
-	h.muxer, err = h2mux.Handshake(edgeConn, edgeConn, h2mux.MuxerConfig{
-		Timeout:           5 * time.Second,
-		Handler:           h,
-		IsClient:          true,
-		HeartbeatInterval: config.HeartbeatInterval,
-		MaxHeartbeats:     config.MaxHeartbeats,
-		Logger:            config.ProtocolLogger,
-	})
+	if config.CaptureDir != "" {
+		h.capture, err = openFrameCapture(config.CaptureDir, connectionID, config.RedactCapture)
+		if err != nil {
+			return nil, "", err
+		}
+		muxerConfig.Capture = h.capture
+	}
+	config.ConnectionTracker.setDialing(int(connectionID), addr)
+	dialCtx := withDialTrace(ctx, &dialTrace{Handshaking: func() {
+		config.ConnectionTracker.setState(int(connectionID), StateHandshaking, nil)
+	}})
+	muxer, localAddr, err := transport.Dial(dialCtx, addr, muxerConfig)
 	if err != nil {
+		h.closeCapture()
+	}
+	if _, ok := err.(dialError); ok {
+		return nil, "", err
+	} else if err != nil {
 		return h, "", errors.New("TLS handshake error")
 	}
-	return h, edgeConn.LocalAddr().String(), err
+	h.muxer = muxer
+	return h, localAddr, nil
+}
+
+// openFrameCapture creates a file in dir to capture the frames of a connection.
+func openFrameCapture(dir string, connectionID uint8, redact bool) (*h2mux.FrameCapture, error) {
+	name := fmt.Sprintf("h2mux-%d-%s.capture", connectionID, time.Now().UTC().Format("20060102T150405.000"))
+	file, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "Cannot create frame capture")
+	}
+	return h2mux.NewFrameCapture(file, redact), nil
+}
+
+func (h *TunnelHandler) closeCapture() {
+	if h.capture == nil {
+		return
+	}
+	if err := h.capture.Close(); err != nil {
+		Log.WithError(err).Error("Error writing frame capture")
+	}
+	if dropped := h.capture.Dropped(); dropped > 0 {
+		Log.Warnf("%d frames were left out of the frame capture because it couldn't keep up", dropped)
+	}
 }
 
 func (h *TunnelHandler) AppendTagHeaders(r *http.Request) {
+	h.tagsLock.RLock()
+	defer h.tagsLock.RUnlock()
 	for _, tag := range h.tags {
 		r.Header.Add(TagHeaderNamePrefix+tag.Name, tag.Value)
 	}
 }
 
+func (h *TunnelHandler) setTags(tags []tunnelpogs.Tag) {
+	h.tagsLock.Lock()
+	defer h.tagsLock.Unlock()
+	h.tags = tags
+}
+
 func (h *TunnelHandler) ServeStream(stream *h2mux.MuxedStream) error {
 	h.metrics.incrementRequests(h.connectionID)
 	req, err := http.NewRequest("GET", h.originUrl, h2mux.MuxedStreamReader{MuxedStream: stream})
 	if err != nil {
 		Log.WithError(err).Panic("Unexpected error from http.NewRequest")
 	}
+	// Abandon the origin request if the edge resets the stream or the connection goes away.
+	req = req.WithContext(stream.Context())
 	err = H2RequestHeadersToH1Request(stream.Headers, req)
 	if err != nil {
 		Log.WithError(err).Error("invalid request received")
@@ -452,8 +952,18 @@ func (h *TunnelHandler) ServeStream(stream *h2mux.MuxedStream) error {
 		} else {
 			defer response.Body.Close()
 			stream.WriteHeaders(H1ResponseToH2Response(response))
-			io.Copy(stream, response.Body)
-			h.metrics.incrementResponses(h.connectionID, "200")
+			code := "200"
+			if _, err = io.Copy(stream, response.Body); err != nil {
+				// The body was truncated
+				code = "reset"
+				_, isReset := err.(h2mux.StreamResetError)
+				if !isReset && stream.Context().Err() == nil {
+					// The origin failed mid-response; tell the edge instead of leaving a truncated body.
+					Log.WithError(err).Error("error proxying response body")
+					stream.Reset(http2.ErrCodeInternal)
+				}
+			}
+			h.metrics.incrementResponses(h.connectionID, code)
 		}
 	}
 	h.metrics.decrementConcurrentRequests(h.connectionID)
@@ -470,6 +980,14 @@ func (h *TunnelHandler) logError(stream *h2mux.MuxedStream, err error) {
 func (h *TunnelHandler) UpdateMetrics() {
 	flowCtlMetrics := h.muxer.FlowControlMetrics()
 	h.metrics.updateTunnelFlowControlMetrics(flowCtlMetrics)
+	// Only record new measurements in the RTT histogram
+	if rtt := h.muxer.RTT(); rtt != h.rtt {
+		h.metrics.observeRTT(h.connectionID, rtt.Current)
+		h.rtt = rtt
+	}
+	streamResets := h.muxer.StreamResetMetrics()
+	h.metrics.updateStreamResetMetrics(h.connectionID, h.streamResets, streamResets)
+	h.streamResets = streamResets
 }
 
 func uint8ToString(input uint8) string {
@@ -0,0 +1,93 @@
+package origin
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// drainingMuxer is a muxer whose Serve returns once its streams finish, or when it is aborted.
+type drainingMuxer struct {
+	h2mux.MuxedConnection
+	aborted chan struct{}
+}
+
+func (m *drainingMuxer) Abort() {
+	close(m.aborted)
+}
+
+func TestDrainTunnel(t *testing.T) {
+	Log = logrus.New()
+	drainTimeouts := func() float64 {
+		var metric dto.Metric
+		m.drainTimeouts.Write(&metric)
+		return metric.GetCounter().GetValue()
+	}
+	config := &TunnelConfig{Metrics: m, DrainOverlap: time.Hour}
+
+	// the requests in flight finish
+	muxer := &drainingMuxer{aborted: make(chan struct{})}
+	serveErrC := make(chan error, 1)
+	serveErrC <- nil
+	drainTunnel(context.Background(), config, &TunnelHandler{muxer: muxer}, 0, serveErrC)
+	assert.Equal(t, float64(0), drainTimeouts())
+
+	// the requests in flight are reset once the overlap is over
+	config.DrainOverlap = time.Millisecond
+	muxer = &drainingMuxer{aborted: make(chan struct{})}
+	serveErrC = make(chan error, 1)
+	go func() {
+		<-muxer.aborted
+		serveErrC <- nil
+	}()
+	drainTunnel(context.Background(), config, &TunnelHandler{muxer: muxer}, 0, serveErrC)
+	assert.Equal(t, float64(1), drainTimeouts())
+
+	// the requests in flight are reset on shutdown, which isn't a timeout
+	config.DrainOverlap = time.Hour
+	muxer = &drainingMuxer{aborted: make(chan struct{})}
+	serveErrC = make(chan error, 1)
+	go func() {
+		<-muxer.aborted
+		serveErrC <- nil
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	drainTunnel(ctx, config, &TunnelHandler{muxer: muxer}, 0, serveErrC)
+	assert.Equal(t, float64(1), drainTimeouts())
+
+	var metric dto.Metric
+	m.drainingConnections.Write(&metric)
+	assert.Equal(t, float64(0), metric.GetGauge().GetValue())
+}
+
+func TestDrainingTunnels(t *testing.T) {
+	Log = logrus.New()
+	var drains drainingTunnels
+	assert.False(t, drains.start(context.Background(), fmt.Errorf("not going away")))
+
+	drained := make(chan struct{})
+	assert.True(t, drains.start(context.Background(), goAwayError{
+		connectionID: 0,
+		drain:        func(context.Context) { <-drained },
+	}))
+	// the draining connection keeps its ID
+	id, ok := drains.freeConnectionID(func(id uint8) bool { return id == 1 })
+	assert.True(t, ok)
+	assert.Equal(t, uint8(2), id)
+	_, ok = drains.freeConnectionID(func(id uint8) bool { return true })
+	assert.False(t, ok)
+
+	close(drained)
+	drains.wait()
+	id, ok = drains.freeConnectionID(func(id uint8) bool { return false })
+	assert.True(t, ok)
+	assert.Equal(t, uint8(0), id)
+}
@@ -0,0 +1,99 @@
+package origin
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"github.com/cloudflare/cloudflare-warp/h2mux"
+	tunnelpogs "github.com/cloudflare/cloudflare-warp/tunnelrpc/pogs"
+
+	"github.com/sirupsen/logrus"
+)
+
+// reconnectError is returned by ServeTunnel when the edge asked for the connection to be moved to
+// another server.
+type reconnectError struct {
+	reason string
+}
+
+func (e reconnectError) Error() string {
+	return fmt.Sprintf("edge requested a reconnect: %s", e.reason)
+}
+
+// tunnelClient carries out the commands the edge sends over the RPC connection of a tunnel
+// connection. It implements tunnelpogs.TunnelClient.
+type tunnelClient struct {
+	config       *TunnelConfig
+	handler      *TunnelHandler
+	connectionID uint8
+	// cancel ends the tunnel connection.
+	cancel context.CancelFunc
+
+	reconnectOnce   sync.Once
+	reconnectFuse   *h2mux.BooleanFuse
+	reconnectReason string
+}
+
+func newTunnelClient(config *TunnelConfig, handler *TunnelHandler, connectionID uint8, cancel context.CancelFunc) *tunnelClient {
+	return &tunnelClient{
+		config:        config,
+		handler:       handler,
+		connectionID:  connectionID,
+		cancel:        cancel,
+		reconnectFuse: h2mux.NewBooleanFuse(),
+	}
+}
+
+// reconnectRequested returns the error for ServeTunnel to return if the edge asked for a reconnect,
+// or nil.
+func (c *tunnelClient) reconnectRequested() error {
+	if !c.reconnectFuse.Value() {
+		return nil
+	}
+	return reconnectError{reason: c.reconnectReason}
+}
+
+func (c *tunnelClient) Reconnect(ctx context.Context, reason string) error {
+	c.reconnectOnce.Do(func() {
+		Log.Infof("Edge requested connection %d to reconnect: %s", c.connectionID, reason)
+		c.reconnectReason = reason
+		c.reconnectFuse.Fuse(true)
+		c.cancel()
+	})
+	return nil
+}
+
+func (c *tunnelClient) SetLogLevel(ctx context.Context, level string) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	Log.Infof("Edge changed the log level to %s", logLevel)
+	Log.SetLevel(logLevel)
+	return nil
+}
+
+func (c *tunnelClient) SetTags(ctx context.Context, tags []tunnelpogs.Tag) error {
+	Log.Infof("Edge changed the tags of connection %d", c.connectionID)
+	c.handler.setTags(tags)
+	return nil
+}
+
+func (c *tunnelClient) GetDiagnostics(ctx context.Context) (*tunnelpogs.Diagnostics, error) {
+	var goroutines bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return nil, err
+	}
+	return &tunnelpogs.Diagnostics{
+		Version:      c.config.ReportedVersion,
+		OS:           fmt.Sprintf("%s_%s", runtime.GOOS, runtime.GOARCH),
+		GoVersion:    runtime.Version(),
+		ConnectionID: c.connectionID,
+		Goroutines:   goroutines.String(),
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"time"
@@ -35,12 +36,42 @@ func NewUpstreamHTTPS(endpoint string, bootstrap []string) (Upstream, error) {
 
 	// Update TLS and HTTP client configuration
 	tls := &tls.Config{ServerName: u.Hostname()}
-	client := &http.Client{
-		Timeout:   time.Second * defaultTimeout,
-		Transport: &http.Transport{TLSClientConfig: tls},
+	upstream := &UpstreamHTTPS{endpoint: u, addresses: bootstrap}
+	upstream.client = &http.Client{
+		Timeout:   defaultTimeout,
+		Transport: &http.Transport{TLSClientConfig: tls, DialContext: upstream.dial},
 	}
 
-	return &UpstreamHTTPS{client: client, endpoint: u, addresses: bootstrap}, nil
+	return upstream, nil
+}
+
+// dial connects to the endpoint, or to its bootstrap addresses if its hostname can't be resolved
+func (u *UpstreamHTTPS) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if !isDNSError(err) {
+		return conn, err
+	}
+	_, port, splitErr := net.SplitHostPort(address)
+	if splitErr != nil {
+		return nil, err
+	}
+	for _, bootstrap := range u.addresses {
+		conn, bootstrapErr := dialer.DialContext(ctx, network, net.JoinHostPort(bootstrap, port))
+		if bootstrapErr == nil {
+			return conn, nil
+		}
+		log.WithError(bootstrapErr).Debugf("Failed to connect to bootstrap address %s", bootstrap)
+	}
+	return nil, err
+}
+
+func isDNSError(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	_, ok := err.(*net.DNSError)
+	return ok
 }
 
 // Exchange provides an implementation for the Upstream interface
@@ -51,7 +82,7 @@ func (u *UpstreamHTTPS) Exchange(ctx context.Context, state request.Request) (*d
 	}
 
 	// No content negotiation for now, use DNS wire format
-	buf, backendErr := u.exchangeWireformat(queryBuf)
+	buf, backendErr := u.exchangeWireformat(ctx, queryBuf)
 	if backendErr == nil {
 		m := &dns.Msg{}
 		if err := m.Unpack(buf); err != nil {
@@ -68,8 +99,8 @@ func (u *UpstreamHTTPS) Exchange(ctx context.Context, state request.Request) (*d
 
 // Perform message exchange with the default UDP wireformat defined in current draft
 // https://datatracker.ietf.org/doc/draft-ietf-doh-dns-over-https
-func (u *UpstreamHTTPS) exchangeWireformat(msg []byte) ([]byte, error) {
-	req, err := http.NewRequest("POST", u.endpoint.String(), bytes.NewBuffer(msg))
+func (u *UpstreamHTTPS) exchangeWireformat(ctx context.Context, msg []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", u.endpoint.String(), bytes.NewBuffer(msg))
 	if err != nil {
 		return nil, err
 	}
@@ -21,6 +21,8 @@ import (
 var (
 	// DefaultHost is the default endpoint for this service.
 	DefaultHost = "dns.cloudflare.com."
+	// DefaultUpstream is the default DNS over HTTPS upstream.
+	DefaultUpstream = "https://dns.cloudflare.com/.well-known/dns-query"
 	// DefaultBootstrapAddress is the fallback list of service addresses
 	DefaultBootstrapAddress = []string{
 		"2400:cb00:2048:1::6813:c066",
@@ -26,10 +26,13 @@ func UnmarshalAuthentication(s tunnelrpc.Authentication) (*Authentication, error
 }
 
 type TunnelRegistration struct {
-	Err              string
-	Url              string
-	LogLines         []string
-	PermanentFailure bool
+	Err               string
+	Url               string
+	LogLines          []string
+	PermanentFailure  bool
+	ErrorCode         tunnelrpc.RegistrationErrorCode
+	RetryAfterSeconds uint32
+	ErrorDetails      []ErrorDetail
 }
 
 func MarshalTunnelRegistration(s tunnelrpc.TunnelRegistration, p *TunnelRegistration) error {
@@ -42,6 +45,11 @@ func UnmarshalTunnelRegistration(s tunnelrpc.TunnelRegistration) (*TunnelRegistr
 	return p, err
 }
 
+type ErrorDetail struct {
+	Key   string
+	Value string
+}
+
 type RegistrationOptions struct {
 	ClientID             string `capnp:"clientId"`
 	Version              string
@@ -52,8 +60,25 @@ type RegistrationOptions struct {
 	ConnectionID         uint8  `capnp:"connectionId"`
 	OriginLocalIP        string `capnp:"originLocalIp"`
 	IsAutoupdated        bool   `capnp:"isAutoupdated"`
+	Features             []string
 }
 
+// Optional features that clients list in RegistrationOptions and servers in ServerInfo. A feature
+// is only used when both ends list it.
+const (
+	// FeatureUnregisterTunnel is the unregisterTunnel method of TunnelServer.
+	FeatureUnregisterTunnel = "unregisterTunnel"
+	// FeatureTunnelClient is the TunnelClient interface exported by the client.
+	FeatureTunnelClient = "tunnelClient"
+	// FeatureRegistrationErrorCodes is the errorCode, retryAfterSeconds and errorDetails fields of
+	// TunnelRegistration.
+	FeatureRegistrationErrorCodes = "registrationErrorCodes"
+	// FeatureServerHeartbeat is the heartbeat settings in ServerInfo.
+	FeatureServerHeartbeat = "serverHeartbeat"
+	// FeatureNamedTunnels is the methods of TunnelServer for named tunnels.
+	FeatureNamedTunnels = "namedTunnels"
+)
+
 func MarshalRegistrationOptions(s tunnelrpc.RegistrationOptions, p *RegistrationOptions) error {
 	return pogs.Insert(tunnelrpc.RegistrationOptions_TypeID, s.Struct, p)
 }
@@ -69,8 +94,84 @@ type Tag struct {
 	Value string
 }
 
+func MarshalTag(s tunnelrpc.Tag, p *Tag) error {
+	return pogs.Insert(tunnelrpc.Tag_TypeID, s.Struct, p)
+}
+
+func UnmarshalTag(s tunnelrpc.Tag) (*Tag, error) {
+	p := new(Tag)
+	err := pogs.Extract(p, tunnelrpc.Tag_TypeID, s.Struct)
+	return p, err
+}
+
+type HostnameRegistration struct {
+	Hostname string
+	Options  RegistrationOptions
+}
+
+func MarshalHostnameRegistration(s tunnelrpc.HostnameRegistration, p *HostnameRegistration) error {
+	return pogs.Insert(tunnelrpc.HostnameRegistration_TypeID, s.Struct, p)
+}
+
+func UnmarshalHostnameRegistration(s tunnelrpc.HostnameRegistration) (*HostnameRegistration, error) {
+	p := new(HostnameRegistration)
+	err := pogs.Extract(p, tunnelrpc.HostnameRegistration_TypeID, s.Struct)
+	return p, err
+}
+
+type HostnameTunnelRegistration struct {
+	Hostname     string
+	Registration TunnelRegistration
+}
+
+func MarshalHostnameTunnelRegistration(s tunnelrpc.HostnameTunnelRegistration, p *HostnameTunnelRegistration) error {
+	return pogs.Insert(tunnelrpc.HostnameTunnelRegistration_TypeID, s.Struct, p)
+}
+
+func UnmarshalHostnameTunnelRegistration(s tunnelrpc.HostnameTunnelRegistration) (*HostnameTunnelRegistration, error) {
+	p := new(HostnameTunnelRegistration)
+	err := pogs.Extract(p, tunnelrpc.HostnameTunnelRegistration_TypeID, s.Struct)
+	return p, err
+}
+
+type TunnelCredentials struct {
+	TunnelID string `capnp:"tunnelId"`
+	Secret   []byte
+}
+
+func MarshalTunnelCredentials(s tunnelrpc.TunnelCredentials, p *TunnelCredentials) error {
+	return pogs.Insert(tunnelrpc.TunnelCredentials_TypeID, s.Struct, p)
+}
+
+func UnmarshalTunnelCredentials(s tunnelrpc.TunnelCredentials) (*TunnelCredentials, error) {
+	p := new(TunnelCredentials)
+	err := pogs.Extract(p, tunnelrpc.TunnelCredentials_TypeID, s.Struct)
+	return p, err
+}
+
+type NamedTunnel struct {
+	TunnelID  string `capnp:"tunnelId"`
+	Name      string
+	Hostnames []string
+	CreatedAt int64
+}
+
+func MarshalNamedTunnel(s tunnelrpc.NamedTunnel, p *NamedTunnel) error {
+	return pogs.Insert(tunnelrpc.NamedTunnel_TypeID, s.Struct, p)
+}
+
+func UnmarshalNamedTunnel(s tunnelrpc.NamedTunnel) (*NamedTunnel, error) {
+	p := new(NamedTunnel)
+	err := pogs.Extract(p, tunnelrpc.NamedTunnel_TypeID, s.Struct)
+	return p, err
+}
+
 type ServerInfo struct {
-	LocationName string
+	LocationName        string
+	SupportedFeatures   []string
+	Version             string
+	HeartbeatIntervalMs uint32
+	MaxHeartbeats       uint32
 }
 
 func MarshalServerInfo(s tunnelrpc.ServerInfo, p *ServerInfo) error {
@@ -86,6 +187,12 @@ func UnmarshalServerInfo(s tunnelrpc.ServerInfo) (*ServerInfo, error) {
 type TunnelServer interface {
 	RegisterTunnel(ctx context.Context, originCert []byte, hostname string, options *RegistrationOptions) (*TunnelRegistration, error)
 	GetServerInfo(ctx context.Context) (*ServerInfo, error)
+	RegisterTunnels(ctx context.Context, originCert []byte, hostnames []HostnameRegistration) ([]HostnameTunnelRegistration, error)
+	UnregisterTunnel(ctx context.Context) error
+	RegisterNamedTunnel(ctx context.Context, credentials *TunnelCredentials, hostname string, options *RegistrationOptions) (*TunnelRegistration, error)
+	CreateTunnel(ctx context.Context, originCert []byte, name string, hostnames []string) (*TunnelCredentials, error)
+	ListTunnels(ctx context.Context, originCert []byte) ([]NamedTunnel, error)
+	DeleteTunnel(ctx context.Context, originCert []byte, tunnelID string) error
 }
 
 func TunnelServer_ServerToClient(s TunnelServer) tunnelrpc.TunnelServer {
@@ -138,6 +245,147 @@ func (i TunnelServer_PogsImpl) GetServerInfo(p tunnelrpc.TunnelServer_getServerI
 	return MarshalServerInfo(result, serverInfo)
 }
 
+func (i TunnelServer_PogsImpl) RegisterTunnels(p tunnelrpc.TunnelServer_registerTunnels) error {
+	originCert, err := p.Params.OriginCert()
+	if err != nil {
+		return err
+	}
+	hostnameList, err := p.Params.Hostnames()
+	if err != nil {
+		return err
+	}
+	hostnames := make([]HostnameRegistration, hostnameList.Len())
+	for j := range hostnames {
+		hostname, err := UnmarshalHostnameRegistration(hostnameList.At(j))
+		if err != nil {
+			return err
+		}
+		hostnames[j] = *hostname
+	}
+	server.Ack(p.Options)
+	registrations, err := i.impl.RegisterTunnels(p.Ctx, originCert, hostnames)
+	if err != nil {
+		return err
+	}
+	result, err := p.Results.NewResult(int32(len(registrations)))
+	if err != nil {
+		return err
+	}
+	for j := range registrations {
+		err = MarshalHostnameTunnelRegistration(result.At(j), &registrations[j])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i TunnelServer_PogsImpl) UnregisterTunnel(p tunnelrpc.TunnelServer_unregisterTunnel) error {
+	server.Ack(p.Options)
+	return i.impl.UnregisterTunnel(p.Ctx)
+}
+
+func (i TunnelServer_PogsImpl) RegisterNamedTunnel(p tunnelrpc.TunnelServer_registerNamedTunnel) error {
+	credentials, err := p.Params.Credentials()
+	if err != nil {
+		return err
+	}
+	pogsCredentials, err := UnmarshalTunnelCredentials(credentials)
+	if err != nil {
+		return err
+	}
+	hostname, err := p.Params.Hostname()
+	if err != nil {
+		return err
+	}
+	options, err := p.Params.Options()
+	if err != nil {
+		return err
+	}
+	pogsOptions, err := UnmarshalRegistrationOptions(options)
+	if err != nil {
+		return err
+	}
+	server.Ack(p.Options)
+	registration, err := i.impl.RegisterNamedTunnel(p.Ctx, pogsCredentials, hostname, pogsOptions)
+	if err != nil {
+		return err
+	}
+	result, err := p.Results.NewResult()
+	if err != nil {
+		return err
+	}
+	return MarshalTunnelRegistration(result, registration)
+}
+
+func (i TunnelServer_PogsImpl) CreateTunnel(p tunnelrpc.TunnelServer_createTunnel) error {
+	originCert, err := p.Params.OriginCert()
+	if err != nil {
+		return err
+	}
+	name, err := p.Params.Name()
+	if err != nil {
+		return err
+	}
+	hostnameList, err := p.Params.Hostnames()
+	if err != nil {
+		return err
+	}
+	hostnames := make([]string, hostnameList.Len())
+	for j := range hostnames {
+		hostnames[j], err = hostnameList.At(j)
+		if err != nil {
+			return err
+		}
+	}
+	server.Ack(p.Options)
+	credentials, err := i.impl.CreateTunnel(p.Ctx, originCert, name, hostnames)
+	if err != nil {
+		return err
+	}
+	result, err := p.Results.NewResult()
+	if err != nil {
+		return err
+	}
+	return MarshalTunnelCredentials(result, credentials)
+}
+
+func (i TunnelServer_PogsImpl) ListTunnels(p tunnelrpc.TunnelServer_listTunnels) error {
+	originCert, err := p.Params.OriginCert()
+	if err != nil {
+		return err
+	}
+	server.Ack(p.Options)
+	tunnels, err := i.impl.ListTunnels(p.Ctx, originCert)
+	if err != nil {
+		return err
+	}
+	result, err := p.Results.NewResult(int32(len(tunnels)))
+	if err != nil {
+		return err
+	}
+	for j := range tunnels {
+		err = MarshalNamedTunnel(result.At(j), &tunnels[j])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i TunnelServer_PogsImpl) DeleteTunnel(p tunnelrpc.TunnelServer_deleteTunnel) error {
+	originCert, err := p.Params.OriginCert()
+	if err != nil {
+		return err
+	}
+	tunnelID, err := p.Params.TunnelId()
+	if err != nil {
+		return err
+	}
+	server.Ack(p.Options)
+	return i.impl.DeleteTunnel(p.Ctx, originCert, tunnelID)
+}
+
 type TunnelServer_PogsClient struct {
 	Client capnp.Client
 	Conn   *rpc.Conn
@@ -186,3 +434,284 @@ func (c TunnelServer_PogsClient) GetServerInfo(ctx context.Context) (*ServerInfo
 	}
 	return UnmarshalServerInfo(retval)
 }
+
+func (c TunnelServer_PogsClient) RegisterTunnels(ctx context.Context, originCert []byte, hostnames []HostnameRegistration) ([]HostnameTunnelRegistration, error) {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.RegisterTunnels(ctx, func(p tunnelrpc.TunnelServer_registerTunnels_Params) error {
+		err := p.SetOriginCert(originCert)
+		if err != nil {
+			return err
+		}
+		hostnameList, err := p.NewHostnames(int32(len(hostnames)))
+		if err != nil {
+			return err
+		}
+		for i := range hostnames {
+			err = MarshalHostnameRegistration(hostnameList.At(i), &hostnames[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	retval, err := promise.Struct()
+	if err != nil {
+		return nil, err
+	}
+	result, err := retval.Result()
+	if err != nil {
+		return nil, err
+	}
+	registrations := make([]HostnameTunnelRegistration, result.Len())
+	for i := range registrations {
+		registration, err := UnmarshalHostnameTunnelRegistration(result.At(i))
+		if err != nil {
+			return nil, err
+		}
+		registrations[i] = *registration
+	}
+	return registrations, nil
+}
+
+func (c TunnelServer_PogsClient) UnregisterTunnel(ctx context.Context) error {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.UnregisterTunnel(ctx, func(p tunnelrpc.TunnelServer_unregisterTunnel_Params) error {
+		return nil
+	})
+	_, err := promise.Struct()
+	return err
+}
+
+func (c TunnelServer_PogsClient) RegisterNamedTunnel(ctx context.Context, credentials *TunnelCredentials, hostname string, options *RegistrationOptions) (*TunnelRegistration, error) {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.RegisterNamedTunnel(ctx, func(p tunnelrpc.TunnelServer_registerNamedTunnel_Params) error {
+		tunnelCredentials, err := p.NewCredentials()
+		if err != nil {
+			return err
+		}
+		err = MarshalTunnelCredentials(tunnelCredentials, credentials)
+		if err != nil {
+			return err
+		}
+		err = p.SetHostname(hostname)
+		if err != nil {
+			return err
+		}
+		registrationOptions, err := p.NewOptions()
+		if err != nil {
+			return err
+		}
+		return MarshalRegistrationOptions(registrationOptions, options)
+	})
+	retval, err := promise.Result().Struct()
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalTunnelRegistration(retval)
+}
+
+func (c TunnelServer_PogsClient) CreateTunnel(ctx context.Context, originCert []byte, name string, hostnames []string) (*TunnelCredentials, error) {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.CreateTunnel(ctx, func(p tunnelrpc.TunnelServer_createTunnel_Params) error {
+		err := p.SetOriginCert(originCert)
+		if err != nil {
+			return err
+		}
+		err = p.SetName(name)
+		if err != nil {
+			return err
+		}
+		hostnameList, err := p.NewHostnames(int32(len(hostnames)))
+		if err != nil {
+			return err
+		}
+		for i, hostname := range hostnames {
+			err = hostnameList.Set(i, hostname)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	retval, err := promise.Result().Struct()
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalTunnelCredentials(retval)
+}
+
+func (c TunnelServer_PogsClient) ListTunnels(ctx context.Context, originCert []byte) ([]NamedTunnel, error) {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.ListTunnels(ctx, func(p tunnelrpc.TunnelServer_listTunnels_Params) error {
+		return p.SetOriginCert(originCert)
+	})
+	retval, err := promise.Struct()
+	if err != nil {
+		return nil, err
+	}
+	result, err := retval.Result()
+	if err != nil {
+		return nil, err
+	}
+	tunnels := make([]NamedTunnel, result.Len())
+	for i := range tunnels {
+		tunnel, err := UnmarshalNamedTunnel(result.At(i))
+		if err != nil {
+			return nil, err
+		}
+		tunnels[i] = *tunnel
+	}
+	return tunnels, nil
+}
+
+func (c TunnelServer_PogsClient) DeleteTunnel(ctx context.Context, originCert []byte, tunnelID string) error {
+	client := tunnelrpc.TunnelServer{Client: c.Client}
+	promise := client.DeleteTunnel(ctx, func(p tunnelrpc.TunnelServer_deleteTunnel_Params) error {
+		err := p.SetOriginCert(originCert)
+		if err != nil {
+			return err
+		}
+		return p.SetTunnelId(tunnelID)
+	})
+	_, err := promise.Struct()
+	return err
+}
+
+type Diagnostics struct {
+	Version      string
+	OS           string `capnp:"os"`
+	GoVersion    string
+	ConnectionID uint8 `capnp:"connectionId"`
+	Goroutines   string
+}
+
+func MarshalDiagnostics(s tunnelrpc.Diagnostics, p *Diagnostics) error {
+	return pogs.Insert(tunnelrpc.Diagnostics_TypeID, s.Struct, p)
+}
+
+func UnmarshalDiagnostics(s tunnelrpc.Diagnostics) (*Diagnostics, error) {
+	p := new(Diagnostics)
+	err := pogs.Extract(p, tunnelrpc.Diagnostics_TypeID, s.Struct)
+	return p, err
+}
+
+type TunnelClient interface {
+	Reconnect(ctx context.Context, reason string) error
+	SetLogLevel(ctx context.Context, level string) error
+	SetTags(ctx context.Context, tags []Tag) error
+	GetDiagnostics(ctx context.Context) (*Diagnostics, error)
+}
+
+func TunnelClient_ServerToClient(s TunnelClient) tunnelrpc.TunnelClient {
+	return tunnelrpc.TunnelClient_ServerToClient(TunnelClient_PogsImpl{s})
+}
+
+type TunnelClient_PogsImpl struct {
+	impl TunnelClient
+}
+
+func (i TunnelClient_PogsImpl) Reconnect(p tunnelrpc.TunnelClient_reconnect) error {
+	reason, err := p.Params.Reason()
+	if err != nil {
+		return err
+	}
+	server.Ack(p.Options)
+	return i.impl.Reconnect(p.Ctx, reason)
+}
+
+func (i TunnelClient_PogsImpl) SetLogLevel(p tunnelrpc.TunnelClient_setLogLevel) error {
+	level, err := p.Params.Level()
+	if err != nil {
+		return err
+	}
+	server.Ack(p.Options)
+	return i.impl.SetLogLevel(p.Ctx, level)
+}
+
+func (i TunnelClient_PogsImpl) SetTags(p tunnelrpc.TunnelClient_setTags) error {
+	tagList, err := p.Params.Tags()
+	if err != nil {
+		return err
+	}
+	tags := make([]Tag, tagList.Len())
+	for j := range tags {
+		tag, err := UnmarshalTag(tagList.At(j))
+		if err != nil {
+			return err
+		}
+		tags[j] = *tag
+	}
+	server.Ack(p.Options)
+	return i.impl.SetTags(p.Ctx, tags)
+}
+
+func (i TunnelClient_PogsImpl) GetDiagnostics(p tunnelrpc.TunnelClient_getDiagnostics) error {
+	server.Ack(p.Options)
+	diagnostics, err := i.impl.GetDiagnostics(p.Ctx)
+	if err != nil {
+		return err
+	}
+	result, err := p.Results.NewResult()
+	if err != nil {
+		return err
+	}
+	return MarshalDiagnostics(result, diagnostics)
+}
+
+type TunnelClient_PogsClient struct {
+	Client capnp.Client
+	Conn   *rpc.Conn
+}
+
+func (c TunnelClient_PogsClient) Close() error {
+	return c.Conn.Close()
+}
+
+func (c TunnelClient_PogsClient) Reconnect(ctx context.Context, reason string) error {
+	client := tunnelrpc.TunnelClient{Client: c.Client}
+	promise := client.Reconnect(ctx, func(p tunnelrpc.TunnelClient_reconnect_Params) error {
+		return p.SetReason(reason)
+	})
+	_, err := promise.Struct()
+	return err
+}
+
+func (c TunnelClient_PogsClient) SetLogLevel(ctx context.Context, level string) error {
+	client := tunnelrpc.TunnelClient{Client: c.Client}
+	promise := client.SetLogLevel(ctx, func(p tunnelrpc.TunnelClient_setLogLevel_Params) error {
+		return p.SetLevel(level)
+	})
+	_, err := promise.Struct()
+	return err
+}
+
+func (c TunnelClient_PogsClient) SetTags(ctx context.Context, tags []Tag) error {
+	client := tunnelrpc.TunnelClient{Client: c.Client}
+	promise := client.SetTags(ctx, func(p tunnelrpc.TunnelClient_setTags_Params) error {
+		tagList, err := p.NewTags(int32(len(tags)))
+		if err != nil {
+			return err
+		}
+		for i := range tags {
+			err = MarshalTag(tagList.At(i), &tags[i])
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	_, err := promise.Struct()
+	return err
+}
+
+func (c TunnelClient_PogsClient) GetDiagnostics(ctx context.Context) (*Diagnostics, error) {
+	client := tunnelrpc.TunnelClient{Client: c.Client}
+	promise := client.GetDiagnostics(ctx, func(p tunnelrpc.TunnelClient_getDiagnostics_Params) error {
+		return nil
+	})
+	retval, err := promise.Result().Struct()
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalDiagnostics(retval)
+}
@@ -119,12 +119,12 @@ type TunnelRegistration struct{ capnp.Struct }
 const TunnelRegistration_TypeID = 0xf41a0f001ad49e46
 
 func NewTunnelRegistration(s *capnp.Segment) (TunnelRegistration, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4})
 	return TunnelRegistration{st}, err
 }
 
 func NewRootTunnelRegistration(s *capnp.Segment) (TunnelRegistration, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4})
 	return TunnelRegistration{st}, err
 }
 
@@ -209,12 +209,53 @@ func (s TunnelRegistration) SetPermanentFailure(v bool) {
 	s.Struct.SetBit(0, v)
 }
 
+func (s TunnelRegistration) ErrorCode() RegistrationErrorCode {
+	return RegistrationErrorCode(s.Struct.Uint16(2))
+}
+
+func (s TunnelRegistration) SetErrorCode(v RegistrationErrorCode) {
+	s.Struct.SetUint16(2, uint16(v))
+}
+
+func (s TunnelRegistration) RetryAfterSeconds() uint32 {
+	return s.Struct.Uint32(4)
+}
+
+func (s TunnelRegistration) SetRetryAfterSeconds(v uint32) {
+	s.Struct.SetUint32(4, v)
+}
+
+func (s TunnelRegistration) ErrorDetails() (ErrorDetail_List, error) {
+	p, err := s.Struct.Ptr(3)
+	return ErrorDetail_List{List: p.List()}, err
+}
+
+func (s TunnelRegistration) HasErrorDetails() bool {
+	p, err := s.Struct.Ptr(3)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelRegistration) SetErrorDetails(v ErrorDetail_List) error {
+	return s.Struct.SetPtr(3, v.List.ToPtr())
+}
+
+// NewErrorDetails sets the errorDetails field to a newly
+// allocated ErrorDetail_List, preferring placement in s's segment.
+func (s TunnelRegistration) NewErrorDetails(n int32) (ErrorDetail_List, error) {
+	l, err := NewErrorDetail_List(s.Struct.Segment(), n)
+	if err != nil {
+		return ErrorDetail_List{}, err
+	}
+	err = s.Struct.SetPtr(3, l.List.ToPtr())
+	return l, err
+}
+
 // TunnelRegistration_List is a list of TunnelRegistration.
 type TunnelRegistration_List struct{ capnp.List }
 
 // NewTunnelRegistration creates a new list of TunnelRegistration.
 func NewTunnelRegistration_List(s *capnp.Segment, sz int32) (TunnelRegistration_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4}, sz)
 	return TunnelRegistration_List{l}, err
 }
 
@@ -234,18 +275,177 @@ func (p TunnelRegistration_Promise) Struct() (TunnelRegistration, error) {
 	return TunnelRegistration{s}, err
 }
 
+type RegistrationErrorCode uint16
+
+// RegistrationErrorCode_TypeID is the unique identifier for the type RegistrationErrorCode.
+const RegistrationErrorCode_TypeID = 0x80b8e91487ba47b2
+
+// Values of RegistrationErrorCode.
+const (
+	RegistrationErrorCode_unknown             RegistrationErrorCode = 0
+	RegistrationErrorCode_duplicateConnection RegistrationErrorCode = 1
+	RegistrationErrorCode_rateLimited         RegistrationErrorCode = 2
+	RegistrationErrorCode_unauthorized        RegistrationErrorCode = 3
+	RegistrationErrorCode_overloaded          RegistrationErrorCode = 4
+	RegistrationErrorCode_versionTooOld       RegistrationErrorCode = 5
+)
+
+// String returns the enum's constant name.
+func (c RegistrationErrorCode) String() string {
+	switch c {
+	case RegistrationErrorCode_unknown:
+		return "unknown"
+	case RegistrationErrorCode_duplicateConnection:
+		return "duplicateConnection"
+	case RegistrationErrorCode_rateLimited:
+		return "rateLimited"
+	case RegistrationErrorCode_unauthorized:
+		return "unauthorized"
+	case RegistrationErrorCode_overloaded:
+		return "overloaded"
+	case RegistrationErrorCode_versionTooOld:
+		return "versionTooOld"
+
+	default:
+		return ""
+	}
+}
+
+// RegistrationErrorCodeFromString returns the enum value with a name,
+// or the zero value if there's no such value.
+func RegistrationErrorCodeFromString(c string) RegistrationErrorCode {
+	switch c {
+	case "unknown":
+		return RegistrationErrorCode_unknown
+	case "duplicateConnection":
+		return RegistrationErrorCode_duplicateConnection
+	case "rateLimited":
+		return RegistrationErrorCode_rateLimited
+	case "unauthorized":
+		return RegistrationErrorCode_unauthorized
+	case "overloaded":
+		return RegistrationErrorCode_overloaded
+	case "versionTooOld":
+		return RegistrationErrorCode_versionTooOld
+
+	default:
+		return 0
+	}
+}
+
+type RegistrationErrorCode_List struct{ capnp.List }
+
+func NewRegistrationErrorCode_List(s *capnp.Segment, sz int32) (RegistrationErrorCode_List, error) {
+	l, err := capnp.NewUInt16List(s, sz)
+	return RegistrationErrorCode_List{l.List}, err
+}
+
+func (l RegistrationErrorCode_List) At(i int) RegistrationErrorCode {
+	ul := capnp.UInt16List{List: l.List}
+	return RegistrationErrorCode(ul.At(i))
+}
+
+func (l RegistrationErrorCode_List) Set(i int, v RegistrationErrorCode) {
+	ul := capnp.UInt16List{List: l.List}
+	ul.Set(i, uint16(v))
+}
+
+type ErrorDetail struct{ capnp.Struct }
+
+// ErrorDetail_TypeID is the unique identifier for the type ErrorDetail.
+const ErrorDetail_TypeID = 0xeaf8d3d28c9deaa4
+
+func NewErrorDetail(s *capnp.Segment) (ErrorDetail, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return ErrorDetail{st}, err
+}
+
+func NewRootErrorDetail(s *capnp.Segment) (ErrorDetail, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return ErrorDetail{st}, err
+}
+
+func ReadRootErrorDetail(msg *capnp.Message) (ErrorDetail, error) {
+	root, err := msg.RootPtr()
+	return ErrorDetail{root.Struct()}, err
+}
+
+func (s ErrorDetail) String() string {
+	str, _ := text.Marshal(0xeaf8d3d28c9deaa4, s.Struct)
+	return str
+}
+
+func (s ErrorDetail) Key() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s ErrorDetail) HasKey() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s ErrorDetail) KeyBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s ErrorDetail) SetKey(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s ErrorDetail) Value() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s ErrorDetail) HasValue() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s ErrorDetail) ValueBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s ErrorDetail) SetValue(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+// ErrorDetail_List is a list of ErrorDetail.
+type ErrorDetail_List struct{ capnp.List }
+
+// NewErrorDetail creates a new list of ErrorDetail.
+func NewErrorDetail_List(s *capnp.Segment, sz int32) (ErrorDetail_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return ErrorDetail_List{l}, err
+}
+
+func (s ErrorDetail_List) At(i int) ErrorDetail { return ErrorDetail{s.List.Struct(i)} }
+
+func (s ErrorDetail_List) Set(i int, v ErrorDetail) error { return s.List.SetStruct(i, v.Struct) }
+
+// ErrorDetail_Promise is a wrapper for a ErrorDetail promised by a client call.
+type ErrorDetail_Promise struct{ *capnp.Pipeline }
+
+func (p ErrorDetail_Promise) Struct() (ErrorDetail, error) {
+	s, err := p.Pipeline.Struct()
+	return ErrorDetail{s}, err
+}
+
 type RegistrationOptions struct{ capnp.Struct }
 
 // RegistrationOptions_TypeID is the unique identifier for the type RegistrationOptions.
 const RegistrationOptions_TypeID = 0xc793e50592935b4a
 
 func NewRegistrationOptions(s *capnp.Segment) (RegistrationOptions, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 6})
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 7})
 	return RegistrationOptions{st}, err
 }
 
 func NewRootRegistrationOptions(s *capnp.Segment) (RegistrationOptions, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 6})
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 7})
 	return RegistrationOptions{st}, err
 }
 
@@ -403,12 +603,37 @@ func (s RegistrationOptions) SetIsAutoupdated(v bool) {
 	s.Struct.SetBit(24, v)
 }
 
+func (s RegistrationOptions) Features() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(6)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s RegistrationOptions) HasFeatures() bool {
+	p, err := s.Struct.Ptr(6)
+	return p.IsValid() || err != nil
+}
+
+func (s RegistrationOptions) SetFeatures(v capnp.TextList) error {
+	return s.Struct.SetPtr(6, v.List.ToPtr())
+}
+
+// NewFeatures sets the features field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s RegistrationOptions) NewFeatures(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(6, l.List.ToPtr())
+	return l, err
+}
+
 // RegistrationOptions_List is a list of RegistrationOptions.
 type RegistrationOptions_List struct{ capnp.List }
 
 // NewRegistrationOptions creates a new list of RegistrationOptions.
 func NewRegistrationOptions_List(s *capnp.Segment, sz int32) (RegistrationOptions_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 6}, sz)
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 7}, sz)
 	return RegistrationOptions_List{l}, err
 }
 
@@ -572,585 +797,3189 @@ func (l ExistingTunnelPolicy_List) Set(i int, v ExistingTunnelPolicy) {
 	ul.Set(i, uint16(v))
 }
 
-type ServerInfo struct{ capnp.Struct }
+type HostnameRegistration struct{ capnp.Struct }
 
-// ServerInfo_TypeID is the unique identifier for the type ServerInfo.
-const ServerInfo_TypeID = 0xf2c68e2547ec3866
+// HostnameRegistration_TypeID is the unique identifier for the type HostnameRegistration.
+const HostnameRegistration_TypeID = 0xec8f88abedd8cc20
 
-func NewServerInfo(s *capnp.Segment) (ServerInfo, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return ServerInfo{st}, err
+func NewHostnameRegistration(s *capnp.Segment) (HostnameRegistration, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return HostnameRegistration{st}, err
 }
 
-func NewRootServerInfo(s *capnp.Segment) (ServerInfo, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return ServerInfo{st}, err
+func NewRootHostnameRegistration(s *capnp.Segment) (HostnameRegistration, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return HostnameRegistration{st}, err
 }
 
-func ReadRootServerInfo(msg *capnp.Message) (ServerInfo, error) {
+func ReadRootHostnameRegistration(msg *capnp.Message) (HostnameRegistration, error) {
 	root, err := msg.RootPtr()
-	return ServerInfo{root.Struct()}, err
+	return HostnameRegistration{root.Struct()}, err
 }
 
-func (s ServerInfo) String() string {
-	str, _ := text.Marshal(0xf2c68e2547ec3866, s.Struct)
+func (s HostnameRegistration) String() string {
+	str, _ := text.Marshal(0xec8f88abedd8cc20, s.Struct)
 	return str
 }
 
-func (s ServerInfo) LocationName() (string, error) {
+func (s HostnameRegistration) Hostname() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
-func (s ServerInfo) HasLocationName() bool {
+func (s HostnameRegistration) HasHostname() bool {
 	p, err := s.Struct.Ptr(0)
 	return p.IsValid() || err != nil
 }
 
-func (s ServerInfo) LocationNameBytes() ([]byte, error) {
+func (s HostnameRegistration) HostnameBytes() ([]byte, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.TextBytes(), err
 }
 
-func (s ServerInfo) SetLocationName(v string) error {
+func (s HostnameRegistration) SetHostname(v string) error {
 	return s.Struct.SetText(0, v)
 }
 
-// ServerInfo_List is a list of ServerInfo.
-type ServerInfo_List struct{ capnp.List }
+func (s HostnameRegistration) Options() (RegistrationOptions, error) {
+	p, err := s.Struct.Ptr(1)
+	return RegistrationOptions{Struct: p.Struct()}, err
+}
 
-// NewServerInfo creates a new list of ServerInfo.
-func NewServerInfo_List(s *capnp.Segment, sz int32) (ServerInfo_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return ServerInfo_List{l}, err
+func (s HostnameRegistration) HasOptions() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
 }
 
-func (s ServerInfo_List) At(i int) ServerInfo { return ServerInfo{s.List.Struct(i)} }
+func (s HostnameRegistration) SetOptions(v RegistrationOptions) error {
+	return s.Struct.SetPtr(1, v.Struct.ToPtr())
+}
 
-func (s ServerInfo_List) Set(i int, v ServerInfo) error { return s.List.SetStruct(i, v.Struct) }
+// NewOptions sets the options field to a newly
+// allocated RegistrationOptions struct, preferring placement in s's segment.
+func (s HostnameRegistration) NewOptions() (RegistrationOptions, error) {
+	ss, err := NewRegistrationOptions(s.Struct.Segment())
+	if err != nil {
+		return RegistrationOptions{}, err
+	}
+	err = s.Struct.SetPtr(1, ss.Struct.ToPtr())
+	return ss, err
+}
 
-// ServerInfo_Promise is a wrapper for a ServerInfo promised by a client call.
-type ServerInfo_Promise struct{ *capnp.Pipeline }
+// HostnameRegistration_List is a list of HostnameRegistration.
+type HostnameRegistration_List struct{ capnp.List }
 
-func (p ServerInfo_Promise) Struct() (ServerInfo, error) {
-	s, err := p.Pipeline.Struct()
-	return ServerInfo{s}, err
+// NewHostnameRegistration creates a new list of HostnameRegistration.
+func NewHostnameRegistration_List(s *capnp.Segment, sz int32) (HostnameRegistration_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return HostnameRegistration_List{l}, err
 }
 
-type TunnelServer struct{ Client capnp.Client }
+func (s HostnameRegistration_List) At(i int) HostnameRegistration {
+	return HostnameRegistration{s.List.Struct(i)}
+}
 
-// TunnelServer_TypeID is the unique identifier for the type TunnelServer.
-const TunnelServer_TypeID = 0xea58385c65416035
+func (s HostnameRegistration_List) Set(i int, v HostnameRegistration) error {
+	return s.List.SetStruct(i, v.Struct)
+}
 
-func (c TunnelServer) RegisterTunnel(ctx context.Context, params func(TunnelServer_registerTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_registerTunnel_Results_Promise {
-	if c.Client == nil {
-		return TunnelServer_registerTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
-	}
-	call := &capnp.Call{
-		Ctx: ctx,
-		Method: capnp.Method{
-			InterfaceID:   0xea58385c65416035,
-			MethodID:      0,
-			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
-			MethodName:    "registerTunnel",
-		},
-		Options: capnp.NewCallOptions(opts),
-	}
-	if params != nil {
-		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 3}
-		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_registerTunnel_Params{Struct: s}) }
-	}
-	return TunnelServer_registerTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+// HostnameRegistration_Promise is a wrapper for a HostnameRegistration promised by a client call.
+type HostnameRegistration_Promise struct{ *capnp.Pipeline }
+
+func (p HostnameRegistration_Promise) Struct() (HostnameRegistration, error) {
+	s, err := p.Pipeline.Struct()
+	return HostnameRegistration{s}, err
 }
-func (c TunnelServer) GetServerInfo(ctx context.Context, params func(TunnelServer_getServerInfo_Params) error, opts ...capnp.CallOption) TunnelServer_getServerInfo_Results_Promise {
-	if c.Client == nil {
-		return TunnelServer_getServerInfo_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
-	}
-	call := &capnp.Call{
-		Ctx: ctx,
-		Method: capnp.Method{
-			InterfaceID:   0xea58385c65416035,
-			MethodID:      1,
-			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
-			MethodName:    "getServerInfo",
-		},
-		Options: capnp.NewCallOptions(opts),
-	}
-	if params != nil {
-		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
-		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_getServerInfo_Params{Struct: s}) }
-	}
-	return TunnelServer_getServerInfo_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+
+func (p HostnameRegistration_Promise) Options() RegistrationOptions_Promise {
+	return RegistrationOptions_Promise{Pipeline: p.Pipeline.GetPipeline(1)}
 }
 
-type TunnelServer_Server interface {
-	RegisterTunnel(TunnelServer_registerTunnel) error
+type HostnameTunnelRegistration struct{ capnp.Struct }
 
-	GetServerInfo(TunnelServer_getServerInfo) error
+// HostnameTunnelRegistration_TypeID is the unique identifier for the type HostnameTunnelRegistration.
+const HostnameTunnelRegistration_TypeID = 0xd12757c1ad0677ab
+
+func NewHostnameTunnelRegistration(s *capnp.Segment) (HostnameTunnelRegistration, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return HostnameTunnelRegistration{st}, err
 }
 
-func TunnelServer_ServerToClient(s TunnelServer_Server) TunnelServer {
-	c, _ := s.(server.Closer)
-	return TunnelServer{Client: server.New(TunnelServer_Methods(nil, s), c)}
+func NewRootHostnameTunnelRegistration(s *capnp.Segment) (HostnameTunnelRegistration, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return HostnameTunnelRegistration{st}, err
 }
 
-func TunnelServer_Methods(methods []server.Method, s TunnelServer_Server) []server.Method {
-	if cap(methods) == 0 {
-		methods = make([]server.Method, 0, 2)
-	}
+func ReadRootHostnameTunnelRegistration(msg *capnp.Message) (HostnameTunnelRegistration, error) {
+	root, err := msg.RootPtr()
+	return HostnameTunnelRegistration{root.Struct()}, err
+}
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xea58385c65416035,
-			MethodID:      0,
-			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
-			MethodName:    "registerTunnel",
-		},
-		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
-			call := TunnelServer_registerTunnel{c, opts, TunnelServer_registerTunnel_Params{Struct: p}, TunnelServer_registerTunnel_Results{Struct: r}}
-			return s.RegisterTunnel(call)
-		},
-		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
-	})
+func (s HostnameTunnelRegistration) String() string {
+	str, _ := text.Marshal(0xd12757c1ad0677ab, s.Struct)
+	return str
+}
 
-	methods = append(methods, server.Method{
-		Method: capnp.Method{
-			InterfaceID:   0xea58385c65416035,
-			MethodID:      1,
-			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
-			MethodName:    "getServerInfo",
+func (s HostnameTunnelRegistration) Hostname() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s HostnameTunnelRegistration) HasHostname() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s HostnameTunnelRegistration) HostnameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s HostnameTunnelRegistration) SetHostname(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s HostnameTunnelRegistration) Registration() (TunnelRegistration, error) {
+	p, err := s.Struct.Ptr(1)
+	return TunnelRegistration{Struct: p.Struct()}, err
+}
+
+func (s HostnameTunnelRegistration) HasRegistration() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s HostnameTunnelRegistration) SetRegistration(v TunnelRegistration) error {
+	return s.Struct.SetPtr(1, v.Struct.ToPtr())
+}
+
+// NewRegistration sets the registration field to a newly
+// allocated TunnelRegistration struct, preferring placement in s's segment.
+func (s HostnameTunnelRegistration) NewRegistration() (TunnelRegistration, error) {
+	ss, err := NewTunnelRegistration(s.Struct.Segment())
+	if err != nil {
+		return TunnelRegistration{}, err
+	}
+	err = s.Struct.SetPtr(1, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// HostnameTunnelRegistration_List is a list of HostnameTunnelRegistration.
+type HostnameTunnelRegistration_List struct{ capnp.List }
+
+// NewHostnameTunnelRegistration creates a new list of HostnameTunnelRegistration.
+func NewHostnameTunnelRegistration_List(s *capnp.Segment, sz int32) (HostnameTunnelRegistration_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return HostnameTunnelRegistration_List{l}, err
+}
+
+func (s HostnameTunnelRegistration_List) At(i int) HostnameTunnelRegistration {
+	return HostnameTunnelRegistration{s.List.Struct(i)}
+}
+
+func (s HostnameTunnelRegistration_List) Set(i int, v HostnameTunnelRegistration) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// HostnameTunnelRegistration_Promise is a wrapper for a HostnameTunnelRegistration promised by a client call.
+type HostnameTunnelRegistration_Promise struct{ *capnp.Pipeline }
+
+func (p HostnameTunnelRegistration_Promise) Struct() (HostnameTunnelRegistration, error) {
+	s, err := p.Pipeline.Struct()
+	return HostnameTunnelRegistration{s}, err
+}
+
+func (p HostnameTunnelRegistration_Promise) Registration() TunnelRegistration_Promise {
+	return TunnelRegistration_Promise{Pipeline: p.Pipeline.GetPipeline(1)}
+}
+
+type TunnelCredentials struct{ capnp.Struct }
+
+// TunnelCredentials_TypeID is the unique identifier for the type TunnelCredentials.
+const TunnelCredentials_TypeID = 0x81b885be602d6b8b
+
+func NewTunnelCredentials(s *capnp.Segment) (TunnelCredentials, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelCredentials{st}, err
+}
+
+func NewRootTunnelCredentials(s *capnp.Segment) (TunnelCredentials, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelCredentials{st}, err
+}
+
+func ReadRootTunnelCredentials(msg *capnp.Message) (TunnelCredentials, error) {
+	root, err := msg.RootPtr()
+	return TunnelCredentials{root.Struct()}, err
+}
+
+func (s TunnelCredentials) String() string {
+	str, _ := text.Marshal(0x81b885be602d6b8b, s.Struct)
+	return str
+}
+
+func (s TunnelCredentials) TunnelId() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s TunnelCredentials) HasTunnelId() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelCredentials) TunnelIdBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s TunnelCredentials) SetTunnelId(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s TunnelCredentials) Secret() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelCredentials) HasSecret() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelCredentials) SetSecret(v []byte) error {
+	return s.Struct.SetData(1, v)
+}
+
+// TunnelCredentials_List is a list of TunnelCredentials.
+type TunnelCredentials_List struct{ capnp.List }
+
+// NewTunnelCredentials creates a new list of TunnelCredentials.
+func NewTunnelCredentials_List(s *capnp.Segment, sz int32) (TunnelCredentials_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return TunnelCredentials_List{l}, err
+}
+
+func (s TunnelCredentials_List) At(i int) TunnelCredentials {
+	return TunnelCredentials{s.List.Struct(i)}
+}
+
+func (s TunnelCredentials_List) Set(i int, v TunnelCredentials) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelCredentials_Promise is a wrapper for a TunnelCredentials promised by a client call.
+type TunnelCredentials_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelCredentials_Promise) Struct() (TunnelCredentials, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelCredentials{s}, err
+}
+
+type NamedTunnel struct{ capnp.Struct }
+
+// NamedTunnel_TypeID is the unique identifier for the type NamedTunnel.
+const NamedTunnel_TypeID = 0xb1898d62ab6dc5ec
+
+func NewNamedTunnel(s *capnp.Segment) (NamedTunnel, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	return NamedTunnel{st}, err
+}
+
+func NewRootNamedTunnel(s *capnp.Segment) (NamedTunnel, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	return NamedTunnel{st}, err
+}
+
+func ReadRootNamedTunnel(msg *capnp.Message) (NamedTunnel, error) {
+	root, err := msg.RootPtr()
+	return NamedTunnel{root.Struct()}, err
+}
+
+func (s NamedTunnel) String() string {
+	str, _ := text.Marshal(0xb1898d62ab6dc5ec, s.Struct)
+	return str
+}
+
+func (s NamedTunnel) TunnelId() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s NamedTunnel) HasTunnelId() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s NamedTunnel) TunnelIdBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s NamedTunnel) SetTunnelId(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s NamedTunnel) Name() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s NamedTunnel) HasName() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s NamedTunnel) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s NamedTunnel) SetName(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s NamedTunnel) Hostnames() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(2)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s NamedTunnel) HasHostnames() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s NamedTunnel) SetHostnames(v capnp.TextList) error {
+	return s.Struct.SetPtr(2, v.List.ToPtr())
+}
+
+// NewHostnames sets the hostnames field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s NamedTunnel) NewHostnames(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(2, l.List.ToPtr())
+	return l, err
+}
+
+func (s NamedTunnel) CreatedAt() int64 {
+	return int64(s.Struct.Uint64(0))
+}
+
+func (s NamedTunnel) SetCreatedAt(v int64) {
+	s.Struct.SetUint64(0, uint64(v))
+}
+
+// NamedTunnel_List is a list of NamedTunnel.
+type NamedTunnel_List struct{ capnp.List }
+
+// NewNamedTunnel creates a new list of NamedTunnel.
+func NewNamedTunnel_List(s *capnp.Segment, sz int32) (NamedTunnel_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3}, sz)
+	return NamedTunnel_List{l}, err
+}
+
+func (s NamedTunnel_List) At(i int) NamedTunnel { return NamedTunnel{s.List.Struct(i)} }
+
+func (s NamedTunnel_List) Set(i int, v NamedTunnel) error { return s.List.SetStruct(i, v.Struct) }
+
+// NamedTunnel_Promise is a wrapper for a NamedTunnel promised by a client call.
+type NamedTunnel_Promise struct{ *capnp.Pipeline }
+
+func (p NamedTunnel_Promise) Struct() (NamedTunnel, error) {
+	s, err := p.Pipeline.Struct()
+	return NamedTunnel{s}, err
+}
+
+type ServerInfo struct{ capnp.Struct }
+
+// ServerInfo_TypeID is the unique identifier for the type ServerInfo.
+const ServerInfo_TypeID = 0xf2c68e2547ec3866
+
+func NewServerInfo(s *capnp.Segment) (ServerInfo, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	return ServerInfo{st}, err
+}
+
+func NewRootServerInfo(s *capnp.Segment) (ServerInfo, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	return ServerInfo{st}, err
+}
+
+func ReadRootServerInfo(msg *capnp.Message) (ServerInfo, error) {
+	root, err := msg.RootPtr()
+	return ServerInfo{root.Struct()}, err
+}
+
+func (s ServerInfo) String() string {
+	str, _ := text.Marshal(0xf2c68e2547ec3866, s.Struct)
+	return str
+}
+
+func (s ServerInfo) LocationName() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s ServerInfo) HasLocationName() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s ServerInfo) LocationNameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s ServerInfo) SetLocationName(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s ServerInfo) SupportedFeatures() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(1)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s ServerInfo) HasSupportedFeatures() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s ServerInfo) SetSupportedFeatures(v capnp.TextList) error {
+	return s.Struct.SetPtr(1, v.List.ToPtr())
+}
+
+// NewSupportedFeatures sets the supportedFeatures field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s ServerInfo) NewSupportedFeatures(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(1, l.List.ToPtr())
+	return l, err
+}
+
+func (s ServerInfo) Version() (string, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.Text(), err
+}
+
+func (s ServerInfo) HasVersion() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s ServerInfo) VersionBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.TextBytes(), err
+}
+
+func (s ServerInfo) SetVersion(v string) error {
+	return s.Struct.SetText(2, v)
+}
+
+func (s ServerInfo) HeartbeatIntervalMs() uint32 {
+	return s.Struct.Uint32(0)
+}
+
+func (s ServerInfo) SetHeartbeatIntervalMs(v uint32) {
+	s.Struct.SetUint32(0, v)
+}
+
+func (s ServerInfo) MaxHeartbeats() uint32 {
+	return s.Struct.Uint32(4)
+}
+
+func (s ServerInfo) SetMaxHeartbeats(v uint32) {
+	s.Struct.SetUint32(4, v)
+}
+
+// ServerInfo_List is a list of ServerInfo.
+type ServerInfo_List struct{ capnp.List }
+
+// NewServerInfo creates a new list of ServerInfo.
+func NewServerInfo_List(s *capnp.Segment, sz int32) (ServerInfo_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 3}, sz)
+	return ServerInfo_List{l}, err
+}
+
+func (s ServerInfo_List) At(i int) ServerInfo { return ServerInfo{s.List.Struct(i)} }
+
+func (s ServerInfo_List) Set(i int, v ServerInfo) error { return s.List.SetStruct(i, v.Struct) }
+
+// ServerInfo_Promise is a wrapper for a ServerInfo promised by a client call.
+type ServerInfo_Promise struct{ *capnp.Pipeline }
+
+func (p ServerInfo_Promise) Struct() (ServerInfo, error) {
+	s, err := p.Pipeline.Struct()
+	return ServerInfo{s}, err
+}
+
+type TunnelServer struct{ Client capnp.Client }
+
+// TunnelServer_TypeID is the unique identifier for the type TunnelServer.
+const TunnelServer_TypeID = 0xea58385c65416035
+
+func (c TunnelServer) RegisterTunnel(ctx context.Context, params func(TunnelServer_registerTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_registerTunnel_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_registerTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      0,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerTunnel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 3}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_registerTunnel_Params{Struct: s}) }
+	}
+	return TunnelServer_registerTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) GetServerInfo(ctx context.Context, params func(TunnelServer_getServerInfo_Params) error, opts ...capnp.CallOption) TunnelServer_getServerInfo_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_getServerInfo_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      1,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "getServerInfo",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_getServerInfo_Params{Struct: s}) }
+	}
+	return TunnelServer_getServerInfo_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) RegisterTunnels(ctx context.Context, params func(TunnelServer_registerTunnels_Params) error, opts ...capnp.CallOption) TunnelServer_registerTunnels_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_registerTunnels_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      2,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerTunnels",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 2}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_registerTunnels_Params{Struct: s}) }
+	}
+	return TunnelServer_registerTunnels_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) UnregisterTunnel(ctx context.Context, params func(TunnelServer_unregisterTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_unregisterTunnel_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_unregisterTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      3,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "unregisterTunnel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_unregisterTunnel_Params{Struct: s}) }
+	}
+	return TunnelServer_unregisterTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) RegisterNamedTunnel(ctx context.Context, params func(TunnelServer_registerNamedTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_registerNamedTunnel_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_registerNamedTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      4,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerNamedTunnel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 3}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_registerNamedTunnel_Params{Struct: s}) }
+	}
+	return TunnelServer_registerNamedTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) CreateTunnel(ctx context.Context, params func(TunnelServer_createTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_createTunnel_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_createTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      5,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "createTunnel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 3}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_createTunnel_Params{Struct: s}) }
+	}
+	return TunnelServer_createTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) ListTunnels(ctx context.Context, params func(TunnelServer_listTunnels_Params) error, opts ...capnp.CallOption) TunnelServer_listTunnels_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_listTunnels_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      6,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "listTunnels",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_listTunnels_Params{Struct: s}) }
+	}
+	return TunnelServer_listTunnels_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelServer) DeleteTunnel(ctx context.Context, params func(TunnelServer_deleteTunnel_Params) error, opts ...capnp.CallOption) TunnelServer_deleteTunnel_Results_Promise {
+	if c.Client == nil {
+		return TunnelServer_deleteTunnel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      7,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "deleteTunnel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 2}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelServer_deleteTunnel_Params{Struct: s}) }
+	}
+	return TunnelServer_deleteTunnel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+
+type TunnelServer_Server interface {
+	RegisterTunnel(TunnelServer_registerTunnel) error
+
+	GetServerInfo(TunnelServer_getServerInfo) error
+
+	RegisterTunnels(TunnelServer_registerTunnels) error
+
+	UnregisterTunnel(TunnelServer_unregisterTunnel) error
+
+	RegisterNamedTunnel(TunnelServer_registerNamedTunnel) error
+
+	CreateTunnel(TunnelServer_createTunnel) error
+
+	ListTunnels(TunnelServer_listTunnels) error
+
+	DeleteTunnel(TunnelServer_deleteTunnel) error
+}
+
+func TunnelServer_ServerToClient(s TunnelServer_Server) TunnelServer {
+	c, _ := s.(server.Closer)
+	return TunnelServer{Client: server.New(TunnelServer_Methods(nil, s), c)}
+}
+
+func TunnelServer_Methods(methods []server.Method, s TunnelServer_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 8)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      0,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerTunnel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_registerTunnel{c, opts, TunnelServer_registerTunnel_Params{Struct: p}, TunnelServer_registerTunnel_Results{Struct: r}}
+			return s.RegisterTunnel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      1,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "getServerInfo",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_getServerInfo{c, opts, TunnelServer_getServerInfo_Params{Struct: p}, TunnelServer_getServerInfo_Results{Struct: r}}
+			return s.GetServerInfo(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      2,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerTunnels",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_registerTunnels{c, opts, TunnelServer_registerTunnels_Params{Struct: p}, TunnelServer_registerTunnels_Results{Struct: r}}
+			return s.RegisterTunnels(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      3,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "unregisterTunnel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_unregisterTunnel{c, opts, TunnelServer_unregisterTunnel_Params{Struct: p}, TunnelServer_unregisterTunnel_Results{Struct: r}}
+			return s.UnregisterTunnel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 0},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      4,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "registerNamedTunnel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_registerNamedTunnel{c, opts, TunnelServer_registerNamedTunnel_Params{Struct: p}, TunnelServer_registerNamedTunnel_Results{Struct: r}}
+			return s.RegisterNamedTunnel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      5,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "createTunnel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_createTunnel{c, opts, TunnelServer_createTunnel_Params{Struct: p}, TunnelServer_createTunnel_Results{Struct: r}}
+			return s.CreateTunnel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      6,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "listTunnels",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_listTunnels{c, opts, TunnelServer_listTunnels_Params{Struct: p}, TunnelServer_listTunnels_Results{Struct: r}}
+			return s.ListTunnels(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0xea58385c65416035,
+			MethodID:      7,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelServer",
+			MethodName:    "deleteTunnel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelServer_deleteTunnel{c, opts, TunnelServer_deleteTunnel_Params{Struct: p}, TunnelServer_deleteTunnel_Results{Struct: r}}
+			return s.DeleteTunnel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 0},
+	})
+
+	return methods
+}
+
+// TunnelServer_registerTunnel holds the arguments for a server call to TunnelServer.registerTunnel.
+type TunnelServer_registerTunnel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_registerTunnel_Params
+	Results TunnelServer_registerTunnel_Results
+}
+
+// TunnelServer_getServerInfo holds the arguments for a server call to TunnelServer.getServerInfo.
+type TunnelServer_getServerInfo struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_getServerInfo_Params
+	Results TunnelServer_getServerInfo_Results
+}
+
+// TunnelServer_registerTunnels holds the arguments for a server call to TunnelServer.registerTunnels.
+type TunnelServer_registerTunnels struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_registerTunnels_Params
+	Results TunnelServer_registerTunnels_Results
+}
+
+// TunnelServer_unregisterTunnel holds the arguments for a server call to TunnelServer.unregisterTunnel.
+type TunnelServer_unregisterTunnel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_unregisterTunnel_Params
+	Results TunnelServer_unregisterTunnel_Results
+}
+
+// TunnelServer_registerNamedTunnel holds the arguments for a server call to TunnelServer.registerNamedTunnel.
+type TunnelServer_registerNamedTunnel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_registerNamedTunnel_Params
+	Results TunnelServer_registerNamedTunnel_Results
+}
+
+// TunnelServer_createTunnel holds the arguments for a server call to TunnelServer.createTunnel.
+type TunnelServer_createTunnel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_createTunnel_Params
+	Results TunnelServer_createTunnel_Results
+}
+
+// TunnelServer_listTunnels holds the arguments for a server call to TunnelServer.listTunnels.
+type TunnelServer_listTunnels struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_listTunnels_Params
+	Results TunnelServer_listTunnels_Results
+}
+
+// TunnelServer_deleteTunnel holds the arguments for a server call to TunnelServer.deleteTunnel.
+type TunnelServer_deleteTunnel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelServer_deleteTunnel_Params
+	Results TunnelServer_deleteTunnel_Results
+}
+
+type TunnelServer_registerTunnel_Params struct{ capnp.Struct }
+
+// TunnelServer_registerTunnel_Params_TypeID is the unique identifier for the type TunnelServer_registerTunnel_Params.
+const TunnelServer_registerTunnel_Params_TypeID = 0xb70431c0dc014915
+
+func NewTunnelServer_registerTunnel_Params(s *capnp.Segment) (TunnelServer_registerTunnel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_registerTunnel_Params{st}, err
+}
+
+func NewRootTunnelServer_registerTunnel_Params(s *capnp.Segment) (TunnelServer_registerTunnel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_registerTunnel_Params{st}, err
+}
+
+func ReadRootTunnelServer_registerTunnel_Params(msg *capnp.Message) (TunnelServer_registerTunnel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerTunnel_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnel_Params) String() string {
+	str, _ := text.Marshal(0xb70431c0dc014915, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerTunnel_Params) OriginCert() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelServer_registerTunnel_Params) HasOriginCert() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnel_Params) SetOriginCert(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s TunnelServer_registerTunnel_Params) Hostname() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s TunnelServer_registerTunnel_Params) HasHostname() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnel_Params) HostnameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s TunnelServer_registerTunnel_Params) SetHostname(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s TunnelServer_registerTunnel_Params) Options() (RegistrationOptions, error) {
+	p, err := s.Struct.Ptr(2)
+	return RegistrationOptions{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnel_Params) HasOptions() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnel_Params) SetOptions(v RegistrationOptions) error {
+	return s.Struct.SetPtr(2, v.Struct.ToPtr())
+}
+
+// NewOptions sets the options field to a newly
+// allocated RegistrationOptions struct, preferring placement in s's segment.
+func (s TunnelServer_registerTunnel_Params) NewOptions() (RegistrationOptions, error) {
+	ss, err := NewRegistrationOptions(s.Struct.Segment())
+	if err != nil {
+		return RegistrationOptions{}, err
+	}
+	err = s.Struct.SetPtr(2, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_registerTunnel_Params_List is a list of TunnelServer_registerTunnel_Params.
+type TunnelServer_registerTunnel_Params_List struct{ capnp.List }
+
+// NewTunnelServer_registerTunnel_Params creates a new list of TunnelServer_registerTunnel_Params.
+func NewTunnelServer_registerTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3}, sz)
+	return TunnelServer_registerTunnel_Params_List{l}, err
+}
+
+func (s TunnelServer_registerTunnel_Params_List) At(i int) TunnelServer_registerTunnel_Params {
+	return TunnelServer_registerTunnel_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerTunnel_Params_List) Set(i int, v TunnelServer_registerTunnel_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerTunnel_Params_Promise is a wrapper for a TunnelServer_registerTunnel_Params promised by a client call.
+type TunnelServer_registerTunnel_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerTunnel_Params_Promise) Struct() (TunnelServer_registerTunnel_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerTunnel_Params{s}, err
+}
+
+func (p TunnelServer_registerTunnel_Params_Promise) Options() RegistrationOptions_Promise {
+	return RegistrationOptions_Promise{Pipeline: p.Pipeline.GetPipeline(2)}
+}
+
+type TunnelServer_registerTunnel_Results struct{ capnp.Struct }
+
+// TunnelServer_registerTunnel_Results_TypeID is the unique identifier for the type TunnelServer_registerTunnel_Results.
+const TunnelServer_registerTunnel_Results_TypeID = 0xf2c122394f447e8e
+
+func NewTunnelServer_registerTunnel_Results(s *capnp.Segment) (TunnelServer_registerTunnel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerTunnel_Results{st}, err
+}
+
+func NewRootTunnelServer_registerTunnel_Results(s *capnp.Segment) (TunnelServer_registerTunnel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerTunnel_Results{st}, err
+}
+
+func ReadRootTunnelServer_registerTunnel_Results(msg *capnp.Message) (TunnelServer_registerTunnel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerTunnel_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnel_Results) String() string {
+	str, _ := text.Marshal(0xf2c122394f447e8e, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerTunnel_Results) Result() (TunnelRegistration, error) {
+	p, err := s.Struct.Ptr(0)
+	return TunnelRegistration{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnel_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnel_Results) SetResult(v TunnelRegistration) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated TunnelRegistration struct, preferring placement in s's segment.
+func (s TunnelServer_registerTunnel_Results) NewResult() (TunnelRegistration, error) {
+	ss, err := NewTunnelRegistration(s.Struct.Segment())
+	if err != nil {
+		return TunnelRegistration{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_registerTunnel_Results_List is a list of TunnelServer_registerTunnel_Results.
+type TunnelServer_registerTunnel_Results_List struct{ capnp.List }
+
+// NewTunnelServer_registerTunnel_Results creates a new list of TunnelServer_registerTunnel_Results.
+func NewTunnelServer_registerTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_registerTunnel_Results_List{l}, err
+}
+
+func (s TunnelServer_registerTunnel_Results_List) At(i int) TunnelServer_registerTunnel_Results {
+	return TunnelServer_registerTunnel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerTunnel_Results_List) Set(i int, v TunnelServer_registerTunnel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerTunnel_Results_Promise is a wrapper for a TunnelServer_registerTunnel_Results promised by a client call.
+type TunnelServer_registerTunnel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerTunnel_Results_Promise) Struct() (TunnelServer_registerTunnel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerTunnel_Results{s}, err
+}
+
+func (p TunnelServer_registerTunnel_Results_Promise) Result() TunnelRegistration_Promise {
+	return TunnelRegistration_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+type TunnelServer_getServerInfo_Params struct{ capnp.Struct }
+
+// TunnelServer_getServerInfo_Params_TypeID is the unique identifier for the type TunnelServer_getServerInfo_Params.
+const TunnelServer_getServerInfo_Params_TypeID = 0xdc3ed6801961e502
+
+func NewTunnelServer_getServerInfo_Params(s *capnp.Segment) (TunnelServer_getServerInfo_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_getServerInfo_Params{st}, err
+}
+
+func NewRootTunnelServer_getServerInfo_Params(s *capnp.Segment) (TunnelServer_getServerInfo_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_getServerInfo_Params{st}, err
+}
+
+func ReadRootTunnelServer_getServerInfo_Params(msg *capnp.Message) (TunnelServer_getServerInfo_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_getServerInfo_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_getServerInfo_Params) String() string {
+	str, _ := text.Marshal(0xdc3ed6801961e502, s.Struct)
+	return str
+}
+
+// TunnelServer_getServerInfo_Params_List is a list of TunnelServer_getServerInfo_Params.
+type TunnelServer_getServerInfo_Params_List struct{ capnp.List }
+
+// NewTunnelServer_getServerInfo_Params creates a new list of TunnelServer_getServerInfo_Params.
+func NewTunnelServer_getServerInfo_Params_List(s *capnp.Segment, sz int32) (TunnelServer_getServerInfo_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelServer_getServerInfo_Params_List{l}, err
+}
+
+func (s TunnelServer_getServerInfo_Params_List) At(i int) TunnelServer_getServerInfo_Params {
+	return TunnelServer_getServerInfo_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_getServerInfo_Params_List) Set(i int, v TunnelServer_getServerInfo_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_getServerInfo_Params_Promise is a wrapper for a TunnelServer_getServerInfo_Params promised by a client call.
+type TunnelServer_getServerInfo_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_getServerInfo_Params_Promise) Struct() (TunnelServer_getServerInfo_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_getServerInfo_Params{s}, err
+}
+
+type TunnelServer_getServerInfo_Results struct{ capnp.Struct }
+
+// TunnelServer_getServerInfo_Results_TypeID is the unique identifier for the type TunnelServer_getServerInfo_Results.
+const TunnelServer_getServerInfo_Results_TypeID = 0xe3e37d096a5b564e
+
+func NewTunnelServer_getServerInfo_Results(s *capnp.Segment) (TunnelServer_getServerInfo_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_getServerInfo_Results{st}, err
+}
+
+func NewRootTunnelServer_getServerInfo_Results(s *capnp.Segment) (TunnelServer_getServerInfo_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_getServerInfo_Results{st}, err
+}
+
+func ReadRootTunnelServer_getServerInfo_Results(msg *capnp.Message) (TunnelServer_getServerInfo_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_getServerInfo_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_getServerInfo_Results) String() string {
+	str, _ := text.Marshal(0xe3e37d096a5b564e, s.Struct)
+	return str
+}
+
+func (s TunnelServer_getServerInfo_Results) Result() (ServerInfo, error) {
+	p, err := s.Struct.Ptr(0)
+	return ServerInfo{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_getServerInfo_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_getServerInfo_Results) SetResult(v ServerInfo) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated ServerInfo struct, preferring placement in s's segment.
+func (s TunnelServer_getServerInfo_Results) NewResult() (ServerInfo, error) {
+	ss, err := NewServerInfo(s.Struct.Segment())
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_getServerInfo_Results_List is a list of TunnelServer_getServerInfo_Results.
+type TunnelServer_getServerInfo_Results_List struct{ capnp.List }
+
+// NewTunnelServer_getServerInfo_Results creates a new list of TunnelServer_getServerInfo_Results.
+func NewTunnelServer_getServerInfo_Results_List(s *capnp.Segment, sz int32) (TunnelServer_getServerInfo_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_getServerInfo_Results_List{l}, err
+}
+
+func (s TunnelServer_getServerInfo_Results_List) At(i int) TunnelServer_getServerInfo_Results {
+	return TunnelServer_getServerInfo_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_getServerInfo_Results_List) Set(i int, v TunnelServer_getServerInfo_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_getServerInfo_Results_Promise is a wrapper for a TunnelServer_getServerInfo_Results promised by a client call.
+type TunnelServer_getServerInfo_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_getServerInfo_Results_Promise) Struct() (TunnelServer_getServerInfo_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_getServerInfo_Results{s}, err
+}
+
+func (p TunnelServer_getServerInfo_Results_Promise) Result() ServerInfo_Promise {
+	return ServerInfo_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+type TunnelServer_registerTunnels_Params struct{ capnp.Struct }
+
+// TunnelServer_registerTunnels_Params_TypeID is the unique identifier for the type TunnelServer_registerTunnels_Params.
+const TunnelServer_registerTunnels_Params_TypeID = 0x9b87b390babc2ccf
+
+func NewTunnelServer_registerTunnels_Params(s *capnp.Segment) (TunnelServer_registerTunnels_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelServer_registerTunnels_Params{st}, err
+}
+
+func NewRootTunnelServer_registerTunnels_Params(s *capnp.Segment) (TunnelServer_registerTunnels_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelServer_registerTunnels_Params{st}, err
+}
+
+func ReadRootTunnelServer_registerTunnels_Params(msg *capnp.Message) (TunnelServer_registerTunnels_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerTunnels_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnels_Params) String() string {
+	str, _ := text.Marshal(0x9b87b390babc2ccf, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerTunnels_Params) OriginCert() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelServer_registerTunnels_Params) HasOriginCert() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnels_Params) SetOriginCert(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s TunnelServer_registerTunnels_Params) Hostnames() (HostnameRegistration_List, error) {
+	p, err := s.Struct.Ptr(1)
+	return HostnameRegistration_List{List: p.List()}, err
+}
+
+func (s TunnelServer_registerTunnels_Params) HasHostnames() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnels_Params) SetHostnames(v HostnameRegistration_List) error {
+	return s.Struct.SetPtr(1, v.List.ToPtr())
+}
+
+// NewHostnames sets the hostnames field to a newly
+// allocated HostnameRegistration_List, preferring placement in s's segment.
+func (s TunnelServer_registerTunnels_Params) NewHostnames(n int32) (HostnameRegistration_List, error) {
+	l, err := NewHostnameRegistration_List(s.Struct.Segment(), n)
+	if err != nil {
+		return HostnameRegistration_List{}, err
+	}
+	err = s.Struct.SetPtr(1, l.List.ToPtr())
+	return l, err
+}
+
+// TunnelServer_registerTunnels_Params_List is a list of TunnelServer_registerTunnels_Params.
+type TunnelServer_registerTunnels_Params_List struct{ capnp.List }
+
+// NewTunnelServer_registerTunnels_Params creates a new list of TunnelServer_registerTunnels_Params.
+func NewTunnelServer_registerTunnels_Params_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnels_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return TunnelServer_registerTunnels_Params_List{l}, err
+}
+
+func (s TunnelServer_registerTunnels_Params_List) At(i int) TunnelServer_registerTunnels_Params {
+	return TunnelServer_registerTunnels_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerTunnels_Params_List) Set(i int, v TunnelServer_registerTunnels_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerTunnels_Params_Promise is a wrapper for a TunnelServer_registerTunnels_Params promised by a client call.
+type TunnelServer_registerTunnels_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerTunnels_Params_Promise) Struct() (TunnelServer_registerTunnels_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerTunnels_Params{s}, err
+}
+
+type TunnelServer_registerTunnels_Results struct{ capnp.Struct }
+
+// TunnelServer_registerTunnels_Results_TypeID is the unique identifier for the type TunnelServer_registerTunnels_Results.
+const TunnelServer_registerTunnels_Results_TypeID = 0xa29a916d4ebdd894
+
+func NewTunnelServer_registerTunnels_Results(s *capnp.Segment) (TunnelServer_registerTunnels_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerTunnels_Results{st}, err
+}
+
+func NewRootTunnelServer_registerTunnels_Results(s *capnp.Segment) (TunnelServer_registerTunnels_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerTunnels_Results{st}, err
+}
+
+func ReadRootTunnelServer_registerTunnels_Results(msg *capnp.Message) (TunnelServer_registerTunnels_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerTunnels_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_registerTunnels_Results) String() string {
+	str, _ := text.Marshal(0xa29a916d4ebdd894, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerTunnels_Results) Result() (HostnameTunnelRegistration_List, error) {
+	p, err := s.Struct.Ptr(0)
+	return HostnameTunnelRegistration_List{List: p.List()}, err
+}
+
+func (s TunnelServer_registerTunnels_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerTunnels_Results) SetResult(v HostnameTunnelRegistration_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated HostnameTunnelRegistration_List, preferring placement in s's segment.
+func (s TunnelServer_registerTunnels_Results) NewResult(n int32) (HostnameTunnelRegistration_List, error) {
+	l, err := NewHostnameTunnelRegistration_List(s.Struct.Segment(), n)
+	if err != nil {
+		return HostnameTunnelRegistration_List{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
+}
+
+// TunnelServer_registerTunnels_Results_List is a list of TunnelServer_registerTunnels_Results.
+type TunnelServer_registerTunnels_Results_List struct{ capnp.List }
+
+// NewTunnelServer_registerTunnels_Results creates a new list of TunnelServer_registerTunnels_Results.
+func NewTunnelServer_registerTunnels_Results_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnels_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_registerTunnels_Results_List{l}, err
+}
+
+func (s TunnelServer_registerTunnels_Results_List) At(i int) TunnelServer_registerTunnels_Results {
+	return TunnelServer_registerTunnels_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerTunnels_Results_List) Set(i int, v TunnelServer_registerTunnels_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerTunnels_Results_Promise is a wrapper for a TunnelServer_registerTunnels_Results promised by a client call.
+type TunnelServer_registerTunnels_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerTunnels_Results_Promise) Struct() (TunnelServer_registerTunnels_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerTunnels_Results{s}, err
+}
+
+type TunnelServer_unregisterTunnel_Params struct{ capnp.Struct }
+
+// TunnelServer_unregisterTunnel_Params_TypeID is the unique identifier for the type TunnelServer_unregisterTunnel_Params.
+const TunnelServer_unregisterTunnel_Params_TypeID = 0xa766b24d4fe5da35
+
+func NewTunnelServer_unregisterTunnel_Params(s *capnp.Segment) (TunnelServer_unregisterTunnel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_unregisterTunnel_Params{st}, err
+}
+
+func NewRootTunnelServer_unregisterTunnel_Params(s *capnp.Segment) (TunnelServer_unregisterTunnel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_unregisterTunnel_Params{st}, err
+}
+
+func ReadRootTunnelServer_unregisterTunnel_Params(msg *capnp.Message) (TunnelServer_unregisterTunnel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_unregisterTunnel_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_unregisterTunnel_Params) String() string {
+	str, _ := text.Marshal(0xa766b24d4fe5da35, s.Struct)
+	return str
+}
+
+// TunnelServer_unregisterTunnel_Params_List is a list of TunnelServer_unregisterTunnel_Params.
+type TunnelServer_unregisterTunnel_Params_List struct{ capnp.List }
+
+// NewTunnelServer_unregisterTunnel_Params creates a new list of TunnelServer_unregisterTunnel_Params.
+func NewTunnelServer_unregisterTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_unregisterTunnel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelServer_unregisterTunnel_Params_List{l}, err
+}
+
+func (s TunnelServer_unregisterTunnel_Params_List) At(i int) TunnelServer_unregisterTunnel_Params {
+	return TunnelServer_unregisterTunnel_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_unregisterTunnel_Params_List) Set(i int, v TunnelServer_unregisterTunnel_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_unregisterTunnel_Params_Promise is a wrapper for a TunnelServer_unregisterTunnel_Params promised by a client call.
+type TunnelServer_unregisterTunnel_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_unregisterTunnel_Params_Promise) Struct() (TunnelServer_unregisterTunnel_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_unregisterTunnel_Params{s}, err
+}
+
+type TunnelServer_unregisterTunnel_Results struct{ capnp.Struct }
+
+// TunnelServer_unregisterTunnel_Results_TypeID is the unique identifier for the type TunnelServer_unregisterTunnel_Results.
+const TunnelServer_unregisterTunnel_Results_TypeID = 0xfeac5c8f4899ef7c
+
+func NewTunnelServer_unregisterTunnel_Results(s *capnp.Segment) (TunnelServer_unregisterTunnel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_unregisterTunnel_Results{st}, err
+}
+
+func NewRootTunnelServer_unregisterTunnel_Results(s *capnp.Segment) (TunnelServer_unregisterTunnel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_unregisterTunnel_Results{st}, err
+}
+
+func ReadRootTunnelServer_unregisterTunnel_Results(msg *capnp.Message) (TunnelServer_unregisterTunnel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_unregisterTunnel_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_unregisterTunnel_Results) String() string {
+	str, _ := text.Marshal(0xfeac5c8f4899ef7c, s.Struct)
+	return str
+}
+
+// TunnelServer_unregisterTunnel_Results_List is a list of TunnelServer_unregisterTunnel_Results.
+type TunnelServer_unregisterTunnel_Results_List struct{ capnp.List }
+
+// NewTunnelServer_unregisterTunnel_Results creates a new list of TunnelServer_unregisterTunnel_Results.
+func NewTunnelServer_unregisterTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_unregisterTunnel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelServer_unregisterTunnel_Results_List{l}, err
+}
+
+func (s TunnelServer_unregisterTunnel_Results_List) At(i int) TunnelServer_unregisterTunnel_Results {
+	return TunnelServer_unregisterTunnel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_unregisterTunnel_Results_List) Set(i int, v TunnelServer_unregisterTunnel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_unregisterTunnel_Results_Promise is a wrapper for a TunnelServer_unregisterTunnel_Results promised by a client call.
+type TunnelServer_unregisterTunnel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_unregisterTunnel_Results_Promise) Struct() (TunnelServer_unregisterTunnel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_unregisterTunnel_Results{s}, err
+}
+
+type TunnelServer_registerNamedTunnel_Params struct{ capnp.Struct }
+
+// TunnelServer_registerNamedTunnel_Params_TypeID is the unique identifier for the type TunnelServer_registerNamedTunnel_Params.
+const TunnelServer_registerNamedTunnel_Params_TypeID = 0x85c8cea1ab1894f3
+
+func NewTunnelServer_registerNamedTunnel_Params(s *capnp.Segment) (TunnelServer_registerNamedTunnel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_registerNamedTunnel_Params{st}, err
+}
+
+func NewRootTunnelServer_registerNamedTunnel_Params(s *capnp.Segment) (TunnelServer_registerNamedTunnel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_registerNamedTunnel_Params{st}, err
+}
+
+func ReadRootTunnelServer_registerNamedTunnel_Params(msg *capnp.Message) (TunnelServer_registerNamedTunnel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerNamedTunnel_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) String() string {
+	str, _ := text.Marshal(0x85c8cea1ab1894f3, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) Credentials() (TunnelCredentials, error) {
+	p, err := s.Struct.Ptr(0)
+	return TunnelCredentials{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) HasCredentials() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) SetCredentials(v TunnelCredentials) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewCredentials sets the credentials field to a newly
+// allocated TunnelCredentials struct, preferring placement in s's segment.
+func (s TunnelServer_registerNamedTunnel_Params) NewCredentials() (TunnelCredentials, error) {
+	ss, err := NewTunnelCredentials(s.Struct.Segment())
+	if err != nil {
+		return TunnelCredentials{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) Hostname() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) HasHostname() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) HostnameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) SetHostname(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) Options() (RegistrationOptions, error) {
+	p, err := s.Struct.Ptr(2)
+	return RegistrationOptions{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) HasOptions() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerNamedTunnel_Params) SetOptions(v RegistrationOptions) error {
+	return s.Struct.SetPtr(2, v.Struct.ToPtr())
+}
+
+// NewOptions sets the options field to a newly
+// allocated RegistrationOptions struct, preferring placement in s's segment.
+func (s TunnelServer_registerNamedTunnel_Params) NewOptions() (RegistrationOptions, error) {
+	ss, err := NewRegistrationOptions(s.Struct.Segment())
+	if err != nil {
+		return RegistrationOptions{}, err
+	}
+	err = s.Struct.SetPtr(2, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_registerNamedTunnel_Params_List is a list of TunnelServer_registerNamedTunnel_Params.
+type TunnelServer_registerNamedTunnel_Params_List struct{ capnp.List }
+
+// NewTunnelServer_registerNamedTunnel_Params creates a new list of TunnelServer_registerNamedTunnel_Params.
+func NewTunnelServer_registerNamedTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_registerNamedTunnel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3}, sz)
+	return TunnelServer_registerNamedTunnel_Params_List{l}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Params_List) At(i int) TunnelServer_registerNamedTunnel_Params {
+	return TunnelServer_registerNamedTunnel_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerNamedTunnel_Params_List) Set(i int, v TunnelServer_registerNamedTunnel_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerNamedTunnel_Params_Promise is a wrapper for a TunnelServer_registerNamedTunnel_Params promised by a client call.
+type TunnelServer_registerNamedTunnel_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerNamedTunnel_Params_Promise) Struct() (TunnelServer_registerNamedTunnel_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerNamedTunnel_Params{s}, err
+}
+
+func (p TunnelServer_registerNamedTunnel_Params_Promise) Credentials() TunnelCredentials_Promise {
+	return TunnelCredentials_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+func (p TunnelServer_registerNamedTunnel_Params_Promise) Options() RegistrationOptions_Promise {
+	return RegistrationOptions_Promise{Pipeline: p.Pipeline.GetPipeline(2)}
+}
+
+type TunnelServer_registerNamedTunnel_Results struct{ capnp.Struct }
+
+// TunnelServer_registerNamedTunnel_Results_TypeID is the unique identifier for the type TunnelServer_registerNamedTunnel_Results.
+const TunnelServer_registerNamedTunnel_Results_TypeID = 0xfc5edf80e39c0796
+
+func NewTunnelServer_registerNamedTunnel_Results(s *capnp.Segment) (TunnelServer_registerNamedTunnel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerNamedTunnel_Results{st}, err
+}
+
+func NewRootTunnelServer_registerNamedTunnel_Results(s *capnp.Segment) (TunnelServer_registerNamedTunnel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_registerNamedTunnel_Results{st}, err
+}
+
+func ReadRootTunnelServer_registerNamedTunnel_Results(msg *capnp.Message) (TunnelServer_registerNamedTunnel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_registerNamedTunnel_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Results) String() string {
+	str, _ := text.Marshal(0xfc5edf80e39c0796, s.Struct)
+	return str
+}
+
+func (s TunnelServer_registerNamedTunnel_Results) Result() (TunnelRegistration, error) {
+	p, err := s.Struct.Ptr(0)
+	return TunnelRegistration{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_registerNamedTunnel_Results) SetResult(v TunnelRegistration) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated TunnelRegistration struct, preferring placement in s's segment.
+func (s TunnelServer_registerNamedTunnel_Results) NewResult() (TunnelRegistration, error) {
+	ss, err := NewTunnelRegistration(s.Struct.Segment())
+	if err != nil {
+		return TunnelRegistration{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_registerNamedTunnel_Results_List is a list of TunnelServer_registerNamedTunnel_Results.
+type TunnelServer_registerNamedTunnel_Results_List struct{ capnp.List }
+
+// NewTunnelServer_registerNamedTunnel_Results creates a new list of TunnelServer_registerNamedTunnel_Results.
+func NewTunnelServer_registerNamedTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_registerNamedTunnel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_registerNamedTunnel_Results_List{l}, err
+}
+
+func (s TunnelServer_registerNamedTunnel_Results_List) At(i int) TunnelServer_registerNamedTunnel_Results {
+	return TunnelServer_registerNamedTunnel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_registerNamedTunnel_Results_List) Set(i int, v TunnelServer_registerNamedTunnel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_registerNamedTunnel_Results_Promise is a wrapper for a TunnelServer_registerNamedTunnel_Results promised by a client call.
+type TunnelServer_registerNamedTunnel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_registerNamedTunnel_Results_Promise) Struct() (TunnelServer_registerNamedTunnel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_registerNamedTunnel_Results{s}, err
+}
+
+func (p TunnelServer_registerNamedTunnel_Results_Promise) Result() TunnelRegistration_Promise {
+	return TunnelRegistration_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+type TunnelServer_createTunnel_Params struct{ capnp.Struct }
+
+// TunnelServer_createTunnel_Params_TypeID is the unique identifier for the type TunnelServer_createTunnel_Params.
+const TunnelServer_createTunnel_Params_TypeID = 0xa353a3556df74984
+
+func NewTunnelServer_createTunnel_Params(s *capnp.Segment) (TunnelServer_createTunnel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_createTunnel_Params{st}, err
+}
+
+func NewRootTunnelServer_createTunnel_Params(s *capnp.Segment) (TunnelServer_createTunnel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
+	return TunnelServer_createTunnel_Params{st}, err
+}
+
+func ReadRootTunnelServer_createTunnel_Params(msg *capnp.Message) (TunnelServer_createTunnel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_createTunnel_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_createTunnel_Params) String() string {
+	str, _ := text.Marshal(0xa353a3556df74984, s.Struct)
+	return str
+}
+
+func (s TunnelServer_createTunnel_Params) OriginCert() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelServer_createTunnel_Params) HasOriginCert() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_createTunnel_Params) SetOriginCert(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s TunnelServer_createTunnel_Params) Name() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s TunnelServer_createTunnel_Params) HasName() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_createTunnel_Params) NameBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s TunnelServer_createTunnel_Params) SetName(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s TunnelServer_createTunnel_Params) Hostnames() (capnp.TextList, error) {
+	p, err := s.Struct.Ptr(2)
+	return capnp.TextList{List: p.List()}, err
+}
+
+func (s TunnelServer_createTunnel_Params) HasHostnames() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_createTunnel_Params) SetHostnames(v capnp.TextList) error {
+	return s.Struct.SetPtr(2, v.List.ToPtr())
+}
+
+// NewHostnames sets the hostnames field to a newly
+// allocated capnp.TextList, preferring placement in s's segment.
+func (s TunnelServer_createTunnel_Params) NewHostnames(n int32) (capnp.TextList, error) {
+	l, err := capnp.NewTextList(s.Struct.Segment(), n)
+	if err != nil {
+		return capnp.TextList{}, err
+	}
+	err = s.Struct.SetPtr(2, l.List.ToPtr())
+	return l, err
+}
+
+// TunnelServer_createTunnel_Params_List is a list of TunnelServer_createTunnel_Params.
+type TunnelServer_createTunnel_Params_List struct{ capnp.List }
+
+// NewTunnelServer_createTunnel_Params creates a new list of TunnelServer_createTunnel_Params.
+func NewTunnelServer_createTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_createTunnel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3}, sz)
+	return TunnelServer_createTunnel_Params_List{l}, err
+}
+
+func (s TunnelServer_createTunnel_Params_List) At(i int) TunnelServer_createTunnel_Params {
+	return TunnelServer_createTunnel_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_createTunnel_Params_List) Set(i int, v TunnelServer_createTunnel_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_createTunnel_Params_Promise is a wrapper for a TunnelServer_createTunnel_Params promised by a client call.
+type TunnelServer_createTunnel_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_createTunnel_Params_Promise) Struct() (TunnelServer_createTunnel_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_createTunnel_Params{s}, err
+}
+
+type TunnelServer_createTunnel_Results struct{ capnp.Struct }
+
+// TunnelServer_createTunnel_Results_TypeID is the unique identifier for the type TunnelServer_createTunnel_Results.
+const TunnelServer_createTunnel_Results_TypeID = 0xd4d18de97bb12de3
+
+func NewTunnelServer_createTunnel_Results(s *capnp.Segment) (TunnelServer_createTunnel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_createTunnel_Results{st}, err
+}
+
+func NewRootTunnelServer_createTunnel_Results(s *capnp.Segment) (TunnelServer_createTunnel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_createTunnel_Results{st}, err
+}
+
+func ReadRootTunnelServer_createTunnel_Results(msg *capnp.Message) (TunnelServer_createTunnel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_createTunnel_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_createTunnel_Results) String() string {
+	str, _ := text.Marshal(0xd4d18de97bb12de3, s.Struct)
+	return str
+}
+
+func (s TunnelServer_createTunnel_Results) Result() (TunnelCredentials, error) {
+	p, err := s.Struct.Ptr(0)
+	return TunnelCredentials{Struct: p.Struct()}, err
+}
+
+func (s TunnelServer_createTunnel_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_createTunnel_Results) SetResult(v TunnelCredentials) error {
+	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated TunnelCredentials struct, preferring placement in s's segment.
+func (s TunnelServer_createTunnel_Results) NewResult() (TunnelCredentials, error) {
+	ss, err := NewTunnelCredentials(s.Struct.Segment())
+	if err != nil {
+		return TunnelCredentials{}, err
+	}
+	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
+	return ss, err
+}
+
+// TunnelServer_createTunnel_Results_List is a list of TunnelServer_createTunnel_Results.
+type TunnelServer_createTunnel_Results_List struct{ capnp.List }
+
+// NewTunnelServer_createTunnel_Results creates a new list of TunnelServer_createTunnel_Results.
+func NewTunnelServer_createTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_createTunnel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_createTunnel_Results_List{l}, err
+}
+
+func (s TunnelServer_createTunnel_Results_List) At(i int) TunnelServer_createTunnel_Results {
+	return TunnelServer_createTunnel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_createTunnel_Results_List) Set(i int, v TunnelServer_createTunnel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_createTunnel_Results_Promise is a wrapper for a TunnelServer_createTunnel_Results promised by a client call.
+type TunnelServer_createTunnel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_createTunnel_Results_Promise) Struct() (TunnelServer_createTunnel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_createTunnel_Results{s}, err
+}
+
+func (p TunnelServer_createTunnel_Results_Promise) Result() TunnelCredentials_Promise {
+	return TunnelCredentials_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+type TunnelServer_listTunnels_Params struct{ capnp.Struct }
+
+// TunnelServer_listTunnels_Params_TypeID is the unique identifier for the type TunnelServer_listTunnels_Params.
+const TunnelServer_listTunnels_Params_TypeID = 0xfa17b9ddf350a8a9
+
+func NewTunnelServer_listTunnels_Params(s *capnp.Segment) (TunnelServer_listTunnels_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_listTunnels_Params{st}, err
+}
+
+func NewRootTunnelServer_listTunnels_Params(s *capnp.Segment) (TunnelServer_listTunnels_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_listTunnels_Params{st}, err
+}
+
+func ReadRootTunnelServer_listTunnels_Params(msg *capnp.Message) (TunnelServer_listTunnels_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_listTunnels_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_listTunnels_Params) String() string {
+	str, _ := text.Marshal(0xfa17b9ddf350a8a9, s.Struct)
+	return str
+}
+
+func (s TunnelServer_listTunnels_Params) OriginCert() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelServer_listTunnels_Params) HasOriginCert() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_listTunnels_Params) SetOriginCert(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+// TunnelServer_listTunnels_Params_List is a list of TunnelServer_listTunnels_Params.
+type TunnelServer_listTunnels_Params_List struct{ capnp.List }
+
+// NewTunnelServer_listTunnels_Params creates a new list of TunnelServer_listTunnels_Params.
+func NewTunnelServer_listTunnels_Params_List(s *capnp.Segment, sz int32) (TunnelServer_listTunnels_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_listTunnels_Params_List{l}, err
+}
+
+func (s TunnelServer_listTunnels_Params_List) At(i int) TunnelServer_listTunnels_Params {
+	return TunnelServer_listTunnels_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_listTunnels_Params_List) Set(i int, v TunnelServer_listTunnels_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_listTunnels_Params_Promise is a wrapper for a TunnelServer_listTunnels_Params promised by a client call.
+type TunnelServer_listTunnels_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_listTunnels_Params_Promise) Struct() (TunnelServer_listTunnels_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_listTunnels_Params{s}, err
+}
+
+type TunnelServer_listTunnels_Results struct{ capnp.Struct }
+
+// TunnelServer_listTunnels_Results_TypeID is the unique identifier for the type TunnelServer_listTunnels_Results.
+const TunnelServer_listTunnels_Results_TypeID = 0xc59ead2bd74d4787
+
+func NewTunnelServer_listTunnels_Results(s *capnp.Segment) (TunnelServer_listTunnels_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_listTunnels_Results{st}, err
+}
+
+func NewRootTunnelServer_listTunnels_Results(s *capnp.Segment) (TunnelServer_listTunnels_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelServer_listTunnels_Results{st}, err
+}
+
+func ReadRootTunnelServer_listTunnels_Results(msg *capnp.Message) (TunnelServer_listTunnels_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_listTunnels_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_listTunnels_Results) String() string {
+	str, _ := text.Marshal(0xc59ead2bd74d4787, s.Struct)
+	return str
+}
+
+func (s TunnelServer_listTunnels_Results) Result() (NamedTunnel_List, error) {
+	p, err := s.Struct.Ptr(0)
+	return NamedTunnel_List{List: p.List()}, err
+}
+
+func (s TunnelServer_listTunnels_Results) HasResult() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_listTunnels_Results) SetResult(v NamedTunnel_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
+}
+
+// NewResult sets the result field to a newly
+// allocated NamedTunnel_List, preferring placement in s's segment.
+func (s TunnelServer_listTunnels_Results) NewResult(n int32) (NamedTunnel_List, error) {
+	l, err := NewNamedTunnel_List(s.Struct.Segment(), n)
+	if err != nil {
+		return NamedTunnel_List{}, err
+	}
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
+}
+
+// TunnelServer_listTunnels_Results_List is a list of TunnelServer_listTunnels_Results.
+type TunnelServer_listTunnels_Results_List struct{ capnp.List }
+
+// NewTunnelServer_listTunnels_Results creates a new list of TunnelServer_listTunnels_Results.
+func NewTunnelServer_listTunnels_Results_List(s *capnp.Segment, sz int32) (TunnelServer_listTunnels_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelServer_listTunnels_Results_List{l}, err
+}
+
+func (s TunnelServer_listTunnels_Results_List) At(i int) TunnelServer_listTunnels_Results {
+	return TunnelServer_listTunnels_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_listTunnels_Results_List) Set(i int, v TunnelServer_listTunnels_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_listTunnels_Results_Promise is a wrapper for a TunnelServer_listTunnels_Results promised by a client call.
+type TunnelServer_listTunnels_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_listTunnels_Results_Promise) Struct() (TunnelServer_listTunnels_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_listTunnels_Results{s}, err
+}
+
+type TunnelServer_deleteTunnel_Params struct{ capnp.Struct }
+
+// TunnelServer_deleteTunnel_Params_TypeID is the unique identifier for the type TunnelServer_deleteTunnel_Params.
+const TunnelServer_deleteTunnel_Params_TypeID = 0xf1ad57680d1b2984
+
+func NewTunnelServer_deleteTunnel_Params(s *capnp.Segment) (TunnelServer_deleteTunnel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelServer_deleteTunnel_Params{st}, err
+}
+
+func NewRootTunnelServer_deleteTunnel_Params(s *capnp.Segment) (TunnelServer_deleteTunnel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2})
+	return TunnelServer_deleteTunnel_Params{st}, err
+}
+
+func ReadRootTunnelServer_deleteTunnel_Params(msg *capnp.Message) (TunnelServer_deleteTunnel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_deleteTunnel_Params{root.Struct()}, err
+}
+
+func (s TunnelServer_deleteTunnel_Params) String() string {
+	str, _ := text.Marshal(0xf1ad57680d1b2984, s.Struct)
+	return str
+}
+
+func (s TunnelServer_deleteTunnel_Params) OriginCert() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return []byte(p.Data()), err
+}
+
+func (s TunnelServer_deleteTunnel_Params) HasOriginCert() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_deleteTunnel_Params) SetOriginCert(v []byte) error {
+	return s.Struct.SetData(0, v)
+}
+
+func (s TunnelServer_deleteTunnel_Params) TunnelId() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s TunnelServer_deleteTunnel_Params) HasTunnelId() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelServer_deleteTunnel_Params) TunnelIdBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s TunnelServer_deleteTunnel_Params) SetTunnelId(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+// TunnelServer_deleteTunnel_Params_List is a list of TunnelServer_deleteTunnel_Params.
+type TunnelServer_deleteTunnel_Params_List struct{ capnp.List }
+
+// NewTunnelServer_deleteTunnel_Params creates a new list of TunnelServer_deleteTunnel_Params.
+func NewTunnelServer_deleteTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_deleteTunnel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 2}, sz)
+	return TunnelServer_deleteTunnel_Params_List{l}, err
+}
+
+func (s TunnelServer_deleteTunnel_Params_List) At(i int) TunnelServer_deleteTunnel_Params {
+	return TunnelServer_deleteTunnel_Params{s.List.Struct(i)}
+}
+
+func (s TunnelServer_deleteTunnel_Params_List) Set(i int, v TunnelServer_deleteTunnel_Params) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_deleteTunnel_Params_Promise is a wrapper for a TunnelServer_deleteTunnel_Params promised by a client call.
+type TunnelServer_deleteTunnel_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_deleteTunnel_Params_Promise) Struct() (TunnelServer_deleteTunnel_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_deleteTunnel_Params{s}, err
+}
+
+type TunnelServer_deleteTunnel_Results struct{ capnp.Struct }
+
+// TunnelServer_deleteTunnel_Results_TypeID is the unique identifier for the type TunnelServer_deleteTunnel_Results.
+const TunnelServer_deleteTunnel_Results_TypeID = 0x9d34949b7c2e333e
+
+func NewTunnelServer_deleteTunnel_Results(s *capnp.Segment) (TunnelServer_deleteTunnel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_deleteTunnel_Results{st}, err
+}
+
+func NewRootTunnelServer_deleteTunnel_Results(s *capnp.Segment) (TunnelServer_deleteTunnel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelServer_deleteTunnel_Results{st}, err
+}
+
+func ReadRootTunnelServer_deleteTunnel_Results(msg *capnp.Message) (TunnelServer_deleteTunnel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelServer_deleteTunnel_Results{root.Struct()}, err
+}
+
+func (s TunnelServer_deleteTunnel_Results) String() string {
+	str, _ := text.Marshal(0x9d34949b7c2e333e, s.Struct)
+	return str
+}
+
+// TunnelServer_deleteTunnel_Results_List is a list of TunnelServer_deleteTunnel_Results.
+type TunnelServer_deleteTunnel_Results_List struct{ capnp.List }
+
+// NewTunnelServer_deleteTunnel_Results creates a new list of TunnelServer_deleteTunnel_Results.
+func NewTunnelServer_deleteTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_deleteTunnel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelServer_deleteTunnel_Results_List{l}, err
+}
+
+func (s TunnelServer_deleteTunnel_Results_List) At(i int) TunnelServer_deleteTunnel_Results {
+	return TunnelServer_deleteTunnel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelServer_deleteTunnel_Results_List) Set(i int, v TunnelServer_deleteTunnel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelServer_deleteTunnel_Results_Promise is a wrapper for a TunnelServer_deleteTunnel_Results promised by a client call.
+type TunnelServer_deleteTunnel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelServer_deleteTunnel_Results_Promise) Struct() (TunnelServer_deleteTunnel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelServer_deleteTunnel_Results{s}, err
+}
+
+type Diagnostics struct{ capnp.Struct }
+
+// Diagnostics_TypeID is the unique identifier for the type Diagnostics.
+const Diagnostics_TypeID = 0x993fb1b00b1afbb9
+
+func NewDiagnostics(s *capnp.Segment) (Diagnostics, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4})
+	return Diagnostics{st}, err
+}
+
+func NewRootDiagnostics(s *capnp.Segment) (Diagnostics, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4})
+	return Diagnostics{st}, err
+}
+
+func ReadRootDiagnostics(msg *capnp.Message) (Diagnostics, error) {
+	root, err := msg.RootPtr()
+	return Diagnostics{root.Struct()}, err
+}
+
+func (s Diagnostics) String() string {
+	str, _ := text.Marshal(0x993fb1b00b1afbb9, s.Struct)
+	return str
+}
+
+func (s Diagnostics) Version() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s Diagnostics) HasVersion() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s Diagnostics) VersionBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s Diagnostics) SetVersion(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+func (s Diagnostics) Os() (string, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.Text(), err
+}
+
+func (s Diagnostics) HasOs() bool {
+	p, err := s.Struct.Ptr(1)
+	return p.IsValid() || err != nil
+}
+
+func (s Diagnostics) OsBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(1)
+	return p.TextBytes(), err
+}
+
+func (s Diagnostics) SetOs(v string) error {
+	return s.Struct.SetText(1, v)
+}
+
+func (s Diagnostics) GoVersion() (string, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.Text(), err
+}
+
+func (s Diagnostics) HasGoVersion() bool {
+	p, err := s.Struct.Ptr(2)
+	return p.IsValid() || err != nil
+}
+
+func (s Diagnostics) GoVersionBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(2)
+	return p.TextBytes(), err
+}
+
+func (s Diagnostics) SetGoVersion(v string) error {
+	return s.Struct.SetText(2, v)
+}
+
+func (s Diagnostics) ConnectionId() uint8 {
+	return s.Struct.Uint8(0)
+}
+
+func (s Diagnostics) SetConnectionId(v uint8) {
+	s.Struct.SetUint8(0, v)
+}
+
+func (s Diagnostics) Goroutines() (string, error) {
+	p, err := s.Struct.Ptr(3)
+	return p.Text(), err
+}
+
+func (s Diagnostics) HasGoroutines() bool {
+	p, err := s.Struct.Ptr(3)
+	return p.IsValid() || err != nil
+}
+
+func (s Diagnostics) GoroutinesBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(3)
+	return p.TextBytes(), err
+}
+
+func (s Diagnostics) SetGoroutines(v string) error {
+	return s.Struct.SetText(3, v)
+}
+
+// Diagnostics_List is a list of Diagnostics.
+type Diagnostics_List struct{ capnp.List }
+
+// NewDiagnostics creates a new list of Diagnostics.
+func NewDiagnostics_List(s *capnp.Segment, sz int32) (Diagnostics_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 8, PointerCount: 4}, sz)
+	return Diagnostics_List{l}, err
+}
+
+func (s Diagnostics_List) At(i int) Diagnostics { return Diagnostics{s.List.Struct(i)} }
+
+func (s Diagnostics_List) Set(i int, v Diagnostics) error { return s.List.SetStruct(i, v.Struct) }
+
+// Diagnostics_Promise is a wrapper for a Diagnostics promised by a client call.
+type Diagnostics_Promise struct{ *capnp.Pipeline }
+
+func (p Diagnostics_Promise) Struct() (Diagnostics, error) {
+	s, err := p.Pipeline.Struct()
+	return Diagnostics{s}, err
+}
+
+type TunnelClient struct{ Client capnp.Client }
+
+// TunnelClient_TypeID is the unique identifier for the type TunnelClient.
+const TunnelClient_TypeID = 0x8e778f66a7f66a76
+
+func (c TunnelClient) Reconnect(ctx context.Context, params func(TunnelClient_reconnect_Params) error, opts ...capnp.CallOption) TunnelClient_reconnect_Results_Promise {
+	if c.Client == nil {
+		return TunnelClient_reconnect_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      0,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "reconnect",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelClient_reconnect_Params{Struct: s}) }
+	}
+	return TunnelClient_reconnect_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelClient) SetLogLevel(ctx context.Context, params func(TunnelClient_setLogLevel_Params) error, opts ...capnp.CallOption) TunnelClient_setLogLevel_Results_Promise {
+	if c.Client == nil {
+		return TunnelClient_setLogLevel_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      1,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "setLogLevel",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelClient_setLogLevel_Params{Struct: s}) }
+	}
+	return TunnelClient_setLogLevel_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelClient) SetTags(ctx context.Context, params func(TunnelClient_setTags_Params) error, opts ...capnp.CallOption) TunnelClient_setTags_Results_Promise {
+	if c.Client == nil {
+		return TunnelClient_setTags_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      2,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "setTags",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 1}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelClient_setTags_Params{Struct: s}) }
+	}
+	return TunnelClient_setTags_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+func (c TunnelClient) GetDiagnostics(ctx context.Context, params func(TunnelClient_getDiagnostics_Params) error, opts ...capnp.CallOption) TunnelClient_getDiagnostics_Results_Promise {
+	if c.Client == nil {
+		return TunnelClient_getDiagnostics_Results_Promise{Pipeline: capnp.NewPipeline(capnp.ErrorAnswer(capnp.ErrNullClient))}
+	}
+	call := &capnp.Call{
+		Ctx: ctx,
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      3,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "getDiagnostics",
+		},
+		Options: capnp.NewCallOptions(opts),
+	}
+	if params != nil {
+		call.ParamsSize = capnp.ObjectSize{DataSize: 0, PointerCount: 0}
+		call.ParamsFunc = func(s capnp.Struct) error { return params(TunnelClient_getDiagnostics_Params{Struct: s}) }
+	}
+	return TunnelClient_getDiagnostics_Results_Promise{Pipeline: capnp.NewPipeline(c.Client.Call(call))}
+}
+
+type TunnelClient_Server interface {
+	Reconnect(TunnelClient_reconnect) error
+
+	SetLogLevel(TunnelClient_setLogLevel) error
+
+	SetTags(TunnelClient_setTags) error
+
+	GetDiagnostics(TunnelClient_getDiagnostics) error
+}
+
+func TunnelClient_ServerToClient(s TunnelClient_Server) TunnelClient {
+	c, _ := s.(server.Closer)
+	return TunnelClient{Client: server.New(TunnelClient_Methods(nil, s), c)}
+}
+
+func TunnelClient_Methods(methods []server.Method, s TunnelClient_Server) []server.Method {
+	if cap(methods) == 0 {
+		methods = make([]server.Method, 0, 4)
+	}
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      0,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "reconnect",
 		},
 		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
-			call := TunnelServer_getServerInfo{c, opts, TunnelServer_getServerInfo_Params{Struct: p}, TunnelServer_getServerInfo_Results{Struct: r}}
-			return s.GetServerInfo(call)
+			call := TunnelClient_reconnect{c, opts, TunnelClient_reconnect_Params{Struct: p}, TunnelClient_reconnect_Results{Struct: r}}
+			return s.Reconnect(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 0},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      1,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "setLogLevel",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelClient_setLogLevel{c, opts, TunnelClient_setLogLevel_Params{Struct: p}, TunnelClient_setLogLevel_Results{Struct: r}}
+			return s.SetLogLevel(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 0},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      2,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "setTags",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelClient_setTags{c, opts, TunnelClient_setTags_Params{Struct: p}, TunnelClient_setTags_Results{Struct: r}}
+			return s.SetTags(call)
+		},
+		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 0},
+	})
+
+	methods = append(methods, server.Method{
+		Method: capnp.Method{
+			InterfaceID:   0x8e778f66a7f66a76,
+			MethodID:      3,
+			InterfaceName: "tunnelrpc/tunnelrpc.capnp:TunnelClient",
+			MethodName:    "getDiagnostics",
+		},
+		Impl: func(c context.Context, opts capnp.CallOptions, p, r capnp.Struct) error {
+			call := TunnelClient_getDiagnostics{c, opts, TunnelClient_getDiagnostics_Params{Struct: p}, TunnelClient_getDiagnostics_Results{Struct: r}}
+			return s.GetDiagnostics(call)
 		},
 		ResultsSize: capnp.ObjectSize{DataSize: 0, PointerCount: 1},
 	})
 
-	return methods
+	return methods
+}
+
+// TunnelClient_reconnect holds the arguments for a server call to TunnelClient.reconnect.
+type TunnelClient_reconnect struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelClient_reconnect_Params
+	Results TunnelClient_reconnect_Results
+}
+
+// TunnelClient_setLogLevel holds the arguments for a server call to TunnelClient.setLogLevel.
+type TunnelClient_setLogLevel struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelClient_setLogLevel_Params
+	Results TunnelClient_setLogLevel_Results
+}
+
+// TunnelClient_setTags holds the arguments for a server call to TunnelClient.setTags.
+type TunnelClient_setTags struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelClient_setTags_Params
+	Results TunnelClient_setTags_Results
+}
+
+// TunnelClient_getDiagnostics holds the arguments for a server call to TunnelClient.getDiagnostics.
+type TunnelClient_getDiagnostics struct {
+	Ctx     context.Context
+	Options capnp.CallOptions
+	Params  TunnelClient_getDiagnostics_Params
+	Results TunnelClient_getDiagnostics_Results
+}
+
+type TunnelClient_reconnect_Params struct{ capnp.Struct }
+
+// TunnelClient_reconnect_Params_TypeID is the unique identifier for the type TunnelClient_reconnect_Params.
+const TunnelClient_reconnect_Params_TypeID = 0xadc8b7e40450e9f5
+
+func NewTunnelClient_reconnect_Params(s *capnp.Segment) (TunnelClient_reconnect_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelClient_reconnect_Params{st}, err
+}
+
+func NewRootTunnelClient_reconnect_Params(s *capnp.Segment) (TunnelClient_reconnect_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelClient_reconnect_Params{st}, err
+}
+
+func ReadRootTunnelClient_reconnect_Params(msg *capnp.Message) (TunnelClient_reconnect_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelClient_reconnect_Params{root.Struct()}, err
+}
+
+func (s TunnelClient_reconnect_Params) String() string {
+	str, _ := text.Marshal(0xadc8b7e40450e9f5, s.Struct)
+	return str
+}
+
+func (s TunnelClient_reconnect_Params) Reason() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s TunnelClient_reconnect_Params) HasReason() bool {
+	p, err := s.Struct.Ptr(0)
+	return p.IsValid() || err != nil
+}
+
+func (s TunnelClient_reconnect_Params) ReasonBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
+}
+
+func (s TunnelClient_reconnect_Params) SetReason(v string) error {
+	return s.Struct.SetText(0, v)
+}
+
+// TunnelClient_reconnect_Params_List is a list of TunnelClient_reconnect_Params.
+type TunnelClient_reconnect_Params_List struct{ capnp.List }
+
+// NewTunnelClient_reconnect_Params creates a new list of TunnelClient_reconnect_Params.
+func NewTunnelClient_reconnect_Params_List(s *capnp.Segment, sz int32) (TunnelClient_reconnect_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelClient_reconnect_Params_List{l}, err
+}
+
+func (s TunnelClient_reconnect_Params_List) At(i int) TunnelClient_reconnect_Params {
+	return TunnelClient_reconnect_Params{s.List.Struct(i)}
 }
 
-// TunnelServer_registerTunnel holds the arguments for a server call to TunnelServer.registerTunnel.
-type TunnelServer_registerTunnel struct {
-	Ctx     context.Context
-	Options capnp.CallOptions
-	Params  TunnelServer_registerTunnel_Params
-	Results TunnelServer_registerTunnel_Results
+func (s TunnelClient_reconnect_Params_List) Set(i int, v TunnelClient_reconnect_Params) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-// TunnelServer_getServerInfo holds the arguments for a server call to TunnelServer.getServerInfo.
-type TunnelServer_getServerInfo struct {
-	Ctx     context.Context
-	Options capnp.CallOptions
-	Params  TunnelServer_getServerInfo_Params
-	Results TunnelServer_getServerInfo_Results
+// TunnelClient_reconnect_Params_Promise is a wrapper for a TunnelClient_reconnect_Params promised by a client call.
+type TunnelClient_reconnect_Params_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelClient_reconnect_Params_Promise) Struct() (TunnelClient_reconnect_Params, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelClient_reconnect_Params{s}, err
 }
 
-type TunnelServer_registerTunnel_Params struct{ capnp.Struct }
+type TunnelClient_reconnect_Results struct{ capnp.Struct }
 
-// TunnelServer_registerTunnel_Params_TypeID is the unique identifier for the type TunnelServer_registerTunnel_Params.
-const TunnelServer_registerTunnel_Params_TypeID = 0xb70431c0dc014915
+// TunnelClient_reconnect_Results_TypeID is the unique identifier for the type TunnelClient_reconnect_Results.
+const TunnelClient_reconnect_Results_TypeID = 0xa8c5ac85fe6cf240
 
-func NewTunnelServer_registerTunnel_Params(s *capnp.Segment) (TunnelServer_registerTunnel_Params, error) {
-	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
-	return TunnelServer_registerTunnel_Params{st}, err
+func NewTunnelClient_reconnect_Results(s *capnp.Segment) (TunnelClient_reconnect_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_reconnect_Results{st}, err
 }
 
-func NewRootTunnelServer_registerTunnel_Params(s *capnp.Segment) (TunnelServer_registerTunnel_Params, error) {
-	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3})
-	return TunnelServer_registerTunnel_Params{st}, err
+func NewRootTunnelClient_reconnect_Results(s *capnp.Segment) (TunnelClient_reconnect_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_reconnect_Results{st}, err
 }
 
-func ReadRootTunnelServer_registerTunnel_Params(msg *capnp.Message) (TunnelServer_registerTunnel_Params, error) {
+func ReadRootTunnelClient_reconnect_Results(msg *capnp.Message) (TunnelClient_reconnect_Results, error) {
 	root, err := msg.RootPtr()
-	return TunnelServer_registerTunnel_Params{root.Struct()}, err
+	return TunnelClient_reconnect_Results{root.Struct()}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) String() string {
-	str, _ := text.Marshal(0xb70431c0dc014915, s.Struct)
+func (s TunnelClient_reconnect_Results) String() string {
+	str, _ := text.Marshal(0xa8c5ac85fe6cf240, s.Struct)
 	return str
 }
 
-func (s TunnelServer_registerTunnel_Params) OriginCert() ([]byte, error) {
-	p, err := s.Struct.Ptr(0)
-	return []byte(p.Data()), err
+// TunnelClient_reconnect_Results_List is a list of TunnelClient_reconnect_Results.
+type TunnelClient_reconnect_Results_List struct{ capnp.List }
+
+// NewTunnelClient_reconnect_Results creates a new list of TunnelClient_reconnect_Results.
+func NewTunnelClient_reconnect_Results_List(s *capnp.Segment, sz int32) (TunnelClient_reconnect_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelClient_reconnect_Results_List{l}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) HasOriginCert() bool {
-	p, err := s.Struct.Ptr(0)
-	return p.IsValid() || err != nil
+func (s TunnelClient_reconnect_Results_List) At(i int) TunnelClient_reconnect_Results {
+	return TunnelClient_reconnect_Results{s.List.Struct(i)}
 }
 
-func (s TunnelServer_registerTunnel_Params) SetOriginCert(v []byte) error {
-	return s.Struct.SetData(0, v)
+func (s TunnelClient_reconnect_Results_List) Set(i int, v TunnelClient_reconnect_Results) error {
+	return s.List.SetStruct(i, v.Struct)
 }
 
-func (s TunnelServer_registerTunnel_Params) Hostname() (string, error) {
-	p, err := s.Struct.Ptr(1)
-	return p.Text(), err
+// TunnelClient_reconnect_Results_Promise is a wrapper for a TunnelClient_reconnect_Results promised by a client call.
+type TunnelClient_reconnect_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelClient_reconnect_Results_Promise) Struct() (TunnelClient_reconnect_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelClient_reconnect_Results{s}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) HasHostname() bool {
-	p, err := s.Struct.Ptr(1)
-	return p.IsValid() || err != nil
+type TunnelClient_setLogLevel_Params struct{ capnp.Struct }
+
+// TunnelClient_setLogLevel_Params_TypeID is the unique identifier for the type TunnelClient_setLogLevel_Params.
+const TunnelClient_setLogLevel_Params_TypeID = 0xf984272be9e06394
+
+func NewTunnelClient_setLogLevel_Params(s *capnp.Segment) (TunnelClient_setLogLevel_Params, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelClient_setLogLevel_Params{st}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) HostnameBytes() ([]byte, error) {
-	p, err := s.Struct.Ptr(1)
-	return p.TextBytes(), err
+func NewRootTunnelClient_setLogLevel_Params(s *capnp.Segment) (TunnelClient_setLogLevel_Params, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
+	return TunnelClient_setLogLevel_Params{st}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) SetHostname(v string) error {
-	return s.Struct.SetText(1, v)
+func ReadRootTunnelClient_setLogLevel_Params(msg *capnp.Message) (TunnelClient_setLogLevel_Params, error) {
+	root, err := msg.RootPtr()
+	return TunnelClient_setLogLevel_Params{root.Struct()}, err
 }
 
-func (s TunnelServer_registerTunnel_Params) Options() (RegistrationOptions, error) {
-	p, err := s.Struct.Ptr(2)
-	return RegistrationOptions{Struct: p.Struct()}, err
+func (s TunnelClient_setLogLevel_Params) String() string {
+	str, _ := text.Marshal(0xf984272be9e06394, s.Struct)
+	return str
 }
 
-func (s TunnelServer_registerTunnel_Params) HasOptions() bool {
-	p, err := s.Struct.Ptr(2)
+func (s TunnelClient_setLogLevel_Params) Level() (string, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.Text(), err
+}
+
+func (s TunnelClient_setLogLevel_Params) HasLevel() bool {
+	p, err := s.Struct.Ptr(0)
 	return p.IsValid() || err != nil
 }
 
-func (s TunnelServer_registerTunnel_Params) SetOptions(v RegistrationOptions) error {
-	return s.Struct.SetPtr(2, v.Struct.ToPtr())
+func (s TunnelClient_setLogLevel_Params) LevelBytes() ([]byte, error) {
+	p, err := s.Struct.Ptr(0)
+	return p.TextBytes(), err
 }
 
-// NewOptions sets the options field to a newly
-// allocated RegistrationOptions struct, preferring placement in s's segment.
-func (s TunnelServer_registerTunnel_Params) NewOptions() (RegistrationOptions, error) {
-	ss, err := NewRegistrationOptions(s.Struct.Segment())
-	if err != nil {
-		return RegistrationOptions{}, err
-	}
-	err = s.Struct.SetPtr(2, ss.Struct.ToPtr())
-	return ss, err
+func (s TunnelClient_setLogLevel_Params) SetLevel(v string) error {
+	return s.Struct.SetText(0, v)
 }
 
-// TunnelServer_registerTunnel_Params_List is a list of TunnelServer_registerTunnel_Params.
-type TunnelServer_registerTunnel_Params_List struct{ capnp.List }
+// TunnelClient_setLogLevel_Params_List is a list of TunnelClient_setLogLevel_Params.
+type TunnelClient_setLogLevel_Params_List struct{ capnp.List }
 
-// NewTunnelServer_registerTunnel_Params creates a new list of TunnelServer_registerTunnel_Params.
-func NewTunnelServer_registerTunnel_Params_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnel_Params_List, error) {
-	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 3}, sz)
-	return TunnelServer_registerTunnel_Params_List{l}, err
+// NewTunnelClient_setLogLevel_Params creates a new list of TunnelClient_setLogLevel_Params.
+func NewTunnelClient_setLogLevel_Params_List(s *capnp.Segment, sz int32) (TunnelClient_setLogLevel_Params_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
+	return TunnelClient_setLogLevel_Params_List{l}, err
 }
 
-func (s TunnelServer_registerTunnel_Params_List) At(i int) TunnelServer_registerTunnel_Params {
-	return TunnelServer_registerTunnel_Params{s.List.Struct(i)}
+func (s TunnelClient_setLogLevel_Params_List) At(i int) TunnelClient_setLogLevel_Params {
+	return TunnelClient_setLogLevel_Params{s.List.Struct(i)}
 }
 
-func (s TunnelServer_registerTunnel_Params_List) Set(i int, v TunnelServer_registerTunnel_Params) error {
+func (s TunnelClient_setLogLevel_Params_List) Set(i int, v TunnelClient_setLogLevel_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-// TunnelServer_registerTunnel_Params_Promise is a wrapper for a TunnelServer_registerTunnel_Params promised by a client call.
-type TunnelServer_registerTunnel_Params_Promise struct{ *capnp.Pipeline }
+// TunnelClient_setLogLevel_Params_Promise is a wrapper for a TunnelClient_setLogLevel_Params promised by a client call.
+type TunnelClient_setLogLevel_Params_Promise struct{ *capnp.Pipeline }
 
-func (p TunnelServer_registerTunnel_Params_Promise) Struct() (TunnelServer_registerTunnel_Params, error) {
+func (p TunnelClient_setLogLevel_Params_Promise) Struct() (TunnelClient_setLogLevel_Params, error) {
 	s, err := p.Pipeline.Struct()
-	return TunnelServer_registerTunnel_Params{s}, err
+	return TunnelClient_setLogLevel_Params{s}, err
 }
 
-func (p TunnelServer_registerTunnel_Params_Promise) Options() RegistrationOptions_Promise {
-	return RegistrationOptions_Promise{Pipeline: p.Pipeline.GetPipeline(2)}
+type TunnelClient_setLogLevel_Results struct{ capnp.Struct }
+
+// TunnelClient_setLogLevel_Results_TypeID is the unique identifier for the type TunnelClient_setLogLevel_Results.
+const TunnelClient_setLogLevel_Results_TypeID = 0xc947f91ef15838eb
+
+func NewTunnelClient_setLogLevel_Results(s *capnp.Segment) (TunnelClient_setLogLevel_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_setLogLevel_Results{st}, err
 }
 
-type TunnelServer_registerTunnel_Results struct{ capnp.Struct }
+func NewRootTunnelClient_setLogLevel_Results(s *capnp.Segment) (TunnelClient_setLogLevel_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_setLogLevel_Results{st}, err
+}
 
-// TunnelServer_registerTunnel_Results_TypeID is the unique identifier for the type TunnelServer_registerTunnel_Results.
-const TunnelServer_registerTunnel_Results_TypeID = 0xf2c122394f447e8e
+func ReadRootTunnelClient_setLogLevel_Results(msg *capnp.Message) (TunnelClient_setLogLevel_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelClient_setLogLevel_Results{root.Struct()}, err
+}
 
-func NewTunnelServer_registerTunnel_Results(s *capnp.Segment) (TunnelServer_registerTunnel_Results, error) {
+func (s TunnelClient_setLogLevel_Results) String() string {
+	str, _ := text.Marshal(0xc947f91ef15838eb, s.Struct)
+	return str
+}
+
+// TunnelClient_setLogLevel_Results_List is a list of TunnelClient_setLogLevel_Results.
+type TunnelClient_setLogLevel_Results_List struct{ capnp.List }
+
+// NewTunnelClient_setLogLevel_Results creates a new list of TunnelClient_setLogLevel_Results.
+func NewTunnelClient_setLogLevel_Results_List(s *capnp.Segment, sz int32) (TunnelClient_setLogLevel_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelClient_setLogLevel_Results_List{l}, err
+}
+
+func (s TunnelClient_setLogLevel_Results_List) At(i int) TunnelClient_setLogLevel_Results {
+	return TunnelClient_setLogLevel_Results{s.List.Struct(i)}
+}
+
+func (s TunnelClient_setLogLevel_Results_List) Set(i int, v TunnelClient_setLogLevel_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelClient_setLogLevel_Results_Promise is a wrapper for a TunnelClient_setLogLevel_Results promised by a client call.
+type TunnelClient_setLogLevel_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelClient_setLogLevel_Results_Promise) Struct() (TunnelClient_setLogLevel_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelClient_setLogLevel_Results{s}, err
+}
+
+type TunnelClient_setTags_Params struct{ capnp.Struct }
+
+// TunnelClient_setTags_Params_TypeID is the unique identifier for the type TunnelClient_setTags_Params.
+const TunnelClient_setTags_Params_TypeID = 0x8178fb81c9af6888
+
+func NewTunnelClient_setTags_Params(s *capnp.Segment) (TunnelClient_setTags_Params, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return TunnelServer_registerTunnel_Results{st}, err
+	return TunnelClient_setTags_Params{st}, err
 }
 
-func NewRootTunnelServer_registerTunnel_Results(s *capnp.Segment) (TunnelServer_registerTunnel_Results, error) {
+func NewRootTunnelClient_setTags_Params(s *capnp.Segment) (TunnelClient_setTags_Params, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return TunnelServer_registerTunnel_Results{st}, err
+	return TunnelClient_setTags_Params{st}, err
 }
 
-func ReadRootTunnelServer_registerTunnel_Results(msg *capnp.Message) (TunnelServer_registerTunnel_Results, error) {
+func ReadRootTunnelClient_setTags_Params(msg *capnp.Message) (TunnelClient_setTags_Params, error) {
 	root, err := msg.RootPtr()
-	return TunnelServer_registerTunnel_Results{root.Struct()}, err
+	return TunnelClient_setTags_Params{root.Struct()}, err
 }
 
-func (s TunnelServer_registerTunnel_Results) String() string {
-	str, _ := text.Marshal(0xf2c122394f447e8e, s.Struct)
+func (s TunnelClient_setTags_Params) String() string {
+	str, _ := text.Marshal(0x8178fb81c9af6888, s.Struct)
 	return str
 }
 
-func (s TunnelServer_registerTunnel_Results) Result() (TunnelRegistration, error) {
+func (s TunnelClient_setTags_Params) Tags() (Tag_List, error) {
 	p, err := s.Struct.Ptr(0)
-	return TunnelRegistration{Struct: p.Struct()}, err
+	return Tag_List{List: p.List()}, err
 }
 
-func (s TunnelServer_registerTunnel_Results) HasResult() bool {
+func (s TunnelClient_setTags_Params) HasTags() bool {
 	p, err := s.Struct.Ptr(0)
 	return p.IsValid() || err != nil
 }
 
-func (s TunnelServer_registerTunnel_Results) SetResult(v TunnelRegistration) error {
-	return s.Struct.SetPtr(0, v.Struct.ToPtr())
+func (s TunnelClient_setTags_Params) SetTags(v Tag_List) error {
+	return s.Struct.SetPtr(0, v.List.ToPtr())
 }
 
-// NewResult sets the result field to a newly
-// allocated TunnelRegistration struct, preferring placement in s's segment.
-func (s TunnelServer_registerTunnel_Results) NewResult() (TunnelRegistration, error) {
-	ss, err := NewTunnelRegistration(s.Struct.Segment())
+// NewTags sets the tags field to a newly
+// allocated Tag_List, preferring placement in s's segment.
+func (s TunnelClient_setTags_Params) NewTags(n int32) (Tag_List, error) {
+	l, err := NewTag_List(s.Struct.Segment(), n)
 	if err != nil {
-		return TunnelRegistration{}, err
+		return Tag_List{}, err
 	}
-	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
-	return ss, err
+	err = s.Struct.SetPtr(0, l.List.ToPtr())
+	return l, err
 }
 
-// TunnelServer_registerTunnel_Results_List is a list of TunnelServer_registerTunnel_Results.
-type TunnelServer_registerTunnel_Results_List struct{ capnp.List }
+// TunnelClient_setTags_Params_List is a list of TunnelClient_setTags_Params.
+type TunnelClient_setTags_Params_List struct{ capnp.List }
 
-// NewTunnelServer_registerTunnel_Results creates a new list of TunnelServer_registerTunnel_Results.
-func NewTunnelServer_registerTunnel_Results_List(s *capnp.Segment, sz int32) (TunnelServer_registerTunnel_Results_List, error) {
+// NewTunnelClient_setTags_Params creates a new list of TunnelClient_setTags_Params.
+func NewTunnelClient_setTags_Params_List(s *capnp.Segment, sz int32) (TunnelClient_setTags_Params_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return TunnelServer_registerTunnel_Results_List{l}, err
+	return TunnelClient_setTags_Params_List{l}, err
 }
 
-func (s TunnelServer_registerTunnel_Results_List) At(i int) TunnelServer_registerTunnel_Results {
-	return TunnelServer_registerTunnel_Results{s.List.Struct(i)}
+func (s TunnelClient_setTags_Params_List) At(i int) TunnelClient_setTags_Params {
+	return TunnelClient_setTags_Params{s.List.Struct(i)}
 }
 
-func (s TunnelServer_registerTunnel_Results_List) Set(i int, v TunnelServer_registerTunnel_Results) error {
+func (s TunnelClient_setTags_Params_List) Set(i int, v TunnelClient_setTags_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-// TunnelServer_registerTunnel_Results_Promise is a wrapper for a TunnelServer_registerTunnel_Results promised by a client call.
-type TunnelServer_registerTunnel_Results_Promise struct{ *capnp.Pipeline }
+// TunnelClient_setTags_Params_Promise is a wrapper for a TunnelClient_setTags_Params promised by a client call.
+type TunnelClient_setTags_Params_Promise struct{ *capnp.Pipeline }
 
-func (p TunnelServer_registerTunnel_Results_Promise) Struct() (TunnelServer_registerTunnel_Results, error) {
+func (p TunnelClient_setTags_Params_Promise) Struct() (TunnelClient_setTags_Params, error) {
 	s, err := p.Pipeline.Struct()
-	return TunnelServer_registerTunnel_Results{s}, err
+	return TunnelClient_setTags_Params{s}, err
 }
 
-func (p TunnelServer_registerTunnel_Results_Promise) Result() TunnelRegistration_Promise {
-	return TunnelRegistration_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+type TunnelClient_setTags_Results struct{ capnp.Struct }
+
+// TunnelClient_setTags_Results_TypeID is the unique identifier for the type TunnelClient_setTags_Results.
+const TunnelClient_setTags_Results_TypeID = 0xe2acab3645e66e05
+
+func NewTunnelClient_setTags_Results(s *capnp.Segment) (TunnelClient_setTags_Results, error) {
+	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_setTags_Results{st}, err
 }
 
-type TunnelServer_getServerInfo_Params struct{ capnp.Struct }
+func NewRootTunnelClient_setTags_Results(s *capnp.Segment) (TunnelClient_setTags_Results, error) {
+	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
+	return TunnelClient_setTags_Results{st}, err
+}
 
-// TunnelServer_getServerInfo_Params_TypeID is the unique identifier for the type TunnelServer_getServerInfo_Params.
-const TunnelServer_getServerInfo_Params_TypeID = 0xdc3ed6801961e502
+func ReadRootTunnelClient_setTags_Results(msg *capnp.Message) (TunnelClient_setTags_Results, error) {
+	root, err := msg.RootPtr()
+	return TunnelClient_setTags_Results{root.Struct()}, err
+}
 
-func NewTunnelServer_getServerInfo_Params(s *capnp.Segment) (TunnelServer_getServerInfo_Params, error) {
+func (s TunnelClient_setTags_Results) String() string {
+	str, _ := text.Marshal(0xe2acab3645e66e05, s.Struct)
+	return str
+}
+
+// TunnelClient_setTags_Results_List is a list of TunnelClient_setTags_Results.
+type TunnelClient_setTags_Results_List struct{ capnp.List }
+
+// NewTunnelClient_setTags_Results creates a new list of TunnelClient_setTags_Results.
+func NewTunnelClient_setTags_Results_List(s *capnp.Segment, sz int32) (TunnelClient_setTags_Results_List, error) {
+	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
+	return TunnelClient_setTags_Results_List{l}, err
+}
+
+func (s TunnelClient_setTags_Results_List) At(i int) TunnelClient_setTags_Results {
+	return TunnelClient_setTags_Results{s.List.Struct(i)}
+}
+
+func (s TunnelClient_setTags_Results_List) Set(i int, v TunnelClient_setTags_Results) error {
+	return s.List.SetStruct(i, v.Struct)
+}
+
+// TunnelClient_setTags_Results_Promise is a wrapper for a TunnelClient_setTags_Results promised by a client call.
+type TunnelClient_setTags_Results_Promise struct{ *capnp.Pipeline }
+
+func (p TunnelClient_setTags_Results_Promise) Struct() (TunnelClient_setTags_Results, error) {
+	s, err := p.Pipeline.Struct()
+	return TunnelClient_setTags_Results{s}, err
+}
+
+type TunnelClient_getDiagnostics_Params struct{ capnp.Struct }
+
+// TunnelClient_getDiagnostics_Params_TypeID is the unique identifier for the type TunnelClient_getDiagnostics_Params.
+const TunnelClient_getDiagnostics_Params_TypeID = 0xd65e563cbfaefdb3
+
+func NewTunnelClient_getDiagnostics_Params(s *capnp.Segment) (TunnelClient_getDiagnostics_Params, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return TunnelServer_getServerInfo_Params{st}, err
+	return TunnelClient_getDiagnostics_Params{st}, err
 }
 
-func NewRootTunnelServer_getServerInfo_Params(s *capnp.Segment) (TunnelServer_getServerInfo_Params, error) {
+func NewRootTunnelClient_getDiagnostics_Params(s *capnp.Segment) (TunnelClient_getDiagnostics_Params, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0})
-	return TunnelServer_getServerInfo_Params{st}, err
+	return TunnelClient_getDiagnostics_Params{st}, err
 }
 
-func ReadRootTunnelServer_getServerInfo_Params(msg *capnp.Message) (TunnelServer_getServerInfo_Params, error) {
+func ReadRootTunnelClient_getDiagnostics_Params(msg *capnp.Message) (TunnelClient_getDiagnostics_Params, error) {
 	root, err := msg.RootPtr()
-	return TunnelServer_getServerInfo_Params{root.Struct()}, err
+	return TunnelClient_getDiagnostics_Params{root.Struct()}, err
 }
 
-func (s TunnelServer_getServerInfo_Params) String() string {
-	str, _ := text.Marshal(0xdc3ed6801961e502, s.Struct)
+func (s TunnelClient_getDiagnostics_Params) String() string {
+	str, _ := text.Marshal(0xd65e563cbfaefdb3, s.Struct)
 	return str
 }
 
-// TunnelServer_getServerInfo_Params_List is a list of TunnelServer_getServerInfo_Params.
-type TunnelServer_getServerInfo_Params_List struct{ capnp.List }
+// TunnelClient_getDiagnostics_Params_List is a list of TunnelClient_getDiagnostics_Params.
+type TunnelClient_getDiagnostics_Params_List struct{ capnp.List }
 
-// NewTunnelServer_getServerInfo_Params creates a new list of TunnelServer_getServerInfo_Params.
-func NewTunnelServer_getServerInfo_Params_List(s *capnp.Segment, sz int32) (TunnelServer_getServerInfo_Params_List, error) {
+// NewTunnelClient_getDiagnostics_Params creates a new list of TunnelClient_getDiagnostics_Params.
+func NewTunnelClient_getDiagnostics_Params_List(s *capnp.Segment, sz int32) (TunnelClient_getDiagnostics_Params_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 0}, sz)
-	return TunnelServer_getServerInfo_Params_List{l}, err
+	return TunnelClient_getDiagnostics_Params_List{l}, err
 }
 
-func (s TunnelServer_getServerInfo_Params_List) At(i int) TunnelServer_getServerInfo_Params {
-	return TunnelServer_getServerInfo_Params{s.List.Struct(i)}
+func (s TunnelClient_getDiagnostics_Params_List) At(i int) TunnelClient_getDiagnostics_Params {
+	return TunnelClient_getDiagnostics_Params{s.List.Struct(i)}
 }
 
-func (s TunnelServer_getServerInfo_Params_List) Set(i int, v TunnelServer_getServerInfo_Params) error {
+func (s TunnelClient_getDiagnostics_Params_List) Set(i int, v TunnelClient_getDiagnostics_Params) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-// TunnelServer_getServerInfo_Params_Promise is a wrapper for a TunnelServer_getServerInfo_Params promised by a client call.
-type TunnelServer_getServerInfo_Params_Promise struct{ *capnp.Pipeline }
+// TunnelClient_getDiagnostics_Params_Promise is a wrapper for a TunnelClient_getDiagnostics_Params promised by a client call.
+type TunnelClient_getDiagnostics_Params_Promise struct{ *capnp.Pipeline }
 
-func (p TunnelServer_getServerInfo_Params_Promise) Struct() (TunnelServer_getServerInfo_Params, error) {
+func (p TunnelClient_getDiagnostics_Params_Promise) Struct() (TunnelClient_getDiagnostics_Params, error) {
 	s, err := p.Pipeline.Struct()
-	return TunnelServer_getServerInfo_Params{s}, err
+	return TunnelClient_getDiagnostics_Params{s}, err
 }
 
-type TunnelServer_getServerInfo_Results struct{ capnp.Struct }
+type TunnelClient_getDiagnostics_Results struct{ capnp.Struct }
 
-// TunnelServer_getServerInfo_Results_TypeID is the unique identifier for the type TunnelServer_getServerInfo_Results.
-const TunnelServer_getServerInfo_Results_TypeID = 0xe3e37d096a5b564e
+// TunnelClient_getDiagnostics_Results_TypeID is the unique identifier for the type TunnelClient_getDiagnostics_Results.
+const TunnelClient_getDiagnostics_Results_TypeID = 0x84beeb7e51b03712
 
-func NewTunnelServer_getServerInfo_Results(s *capnp.Segment) (TunnelServer_getServerInfo_Results, error) {
+func NewTunnelClient_getDiagnostics_Results(s *capnp.Segment) (TunnelClient_getDiagnostics_Results, error) {
 	st, err := capnp.NewStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return TunnelServer_getServerInfo_Results{st}, err
+	return TunnelClient_getDiagnostics_Results{st}, err
 }
 
-func NewRootTunnelServer_getServerInfo_Results(s *capnp.Segment) (TunnelServer_getServerInfo_Results, error) {
+func NewRootTunnelClient_getDiagnostics_Results(s *capnp.Segment) (TunnelClient_getDiagnostics_Results, error) {
 	st, err := capnp.NewRootStruct(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1})
-	return TunnelServer_getServerInfo_Results{st}, err
+	return TunnelClient_getDiagnostics_Results{st}, err
 }
 
-func ReadRootTunnelServer_getServerInfo_Results(msg *capnp.Message) (TunnelServer_getServerInfo_Results, error) {
+func ReadRootTunnelClient_getDiagnostics_Results(msg *capnp.Message) (TunnelClient_getDiagnostics_Results, error) {
 	root, err := msg.RootPtr()
-	return TunnelServer_getServerInfo_Results{root.Struct()}, err
+	return TunnelClient_getDiagnostics_Results{root.Struct()}, err
 }
 
-func (s TunnelServer_getServerInfo_Results) String() string {
-	str, _ := text.Marshal(0xe3e37d096a5b564e, s.Struct)
+func (s TunnelClient_getDiagnostics_Results) String() string {
+	str, _ := text.Marshal(0x84beeb7e51b03712, s.Struct)
 	return str
 }
 
-func (s TunnelServer_getServerInfo_Results) Result() (ServerInfo, error) {
+func (s TunnelClient_getDiagnostics_Results) Result() (Diagnostics, error) {
 	p, err := s.Struct.Ptr(0)
-	return ServerInfo{Struct: p.Struct()}, err
+	return Diagnostics{Struct: p.Struct()}, err
 }
 
-func (s TunnelServer_getServerInfo_Results) HasResult() bool {
+func (s TunnelClient_getDiagnostics_Results) HasResult() bool {
 	p, err := s.Struct.Ptr(0)
 	return p.IsValid() || err != nil
 }
 
-func (s TunnelServer_getServerInfo_Results) SetResult(v ServerInfo) error {
+func (s TunnelClient_getDiagnostics_Results) SetResult(v Diagnostics) error {
 	return s.Struct.SetPtr(0, v.Struct.ToPtr())
 }
 
 // NewResult sets the result field to a newly
-// allocated ServerInfo struct, preferring placement in s's segment.
-func (s TunnelServer_getServerInfo_Results) NewResult() (ServerInfo, error) {
-	ss, err := NewServerInfo(s.Struct.Segment())
+// allocated Diagnostics struct, preferring placement in s's segment.
+func (s TunnelClient_getDiagnostics_Results) NewResult() (Diagnostics, error) {
+	ss, err := NewDiagnostics(s.Struct.Segment())
 	if err != nil {
-		return ServerInfo{}, err
+		return Diagnostics{}, err
 	}
 	err = s.Struct.SetPtr(0, ss.Struct.ToPtr())
 	return ss, err
 }
 
-// TunnelServer_getServerInfo_Results_List is a list of TunnelServer_getServerInfo_Results.
-type TunnelServer_getServerInfo_Results_List struct{ capnp.List }
+// TunnelClient_getDiagnostics_Results_List is a list of TunnelClient_getDiagnostics_Results.
+type TunnelClient_getDiagnostics_Results_List struct{ capnp.List }
 
-// NewTunnelServer_getServerInfo_Results creates a new list of TunnelServer_getServerInfo_Results.
-func NewTunnelServer_getServerInfo_Results_List(s *capnp.Segment, sz int32) (TunnelServer_getServerInfo_Results_List, error) {
+// NewTunnelClient_getDiagnostics_Results creates a new list of TunnelClient_getDiagnostics_Results.
+func NewTunnelClient_getDiagnostics_Results_List(s *capnp.Segment, sz int32) (TunnelClient_getDiagnostics_Results_List, error) {
 	l, err := capnp.NewCompositeList(s, capnp.ObjectSize{DataSize: 0, PointerCount: 1}, sz)
-	return TunnelServer_getServerInfo_Results_List{l}, err
+	return TunnelClient_getDiagnostics_Results_List{l}, err
 }
 
-func (s TunnelServer_getServerInfo_Results_List) At(i int) TunnelServer_getServerInfo_Results {
-	return TunnelServer_getServerInfo_Results{s.List.Struct(i)}
+func (s TunnelClient_getDiagnostics_Results_List) At(i int) TunnelClient_getDiagnostics_Results {
+	return TunnelClient_getDiagnostics_Results{s.List.Struct(i)}
 }
 
-func (s TunnelServer_getServerInfo_Results_List) Set(i int, v TunnelServer_getServerInfo_Results) error {
+func (s TunnelClient_getDiagnostics_Results_List) Set(i int, v TunnelClient_getDiagnostics_Results) error {
 	return s.List.SetStruct(i, v.Struct)
 }
 
-// TunnelServer_getServerInfo_Results_Promise is a wrapper for a TunnelServer_getServerInfo_Results promised by a client call.
-type TunnelServer_getServerInfo_Results_Promise struct{ *capnp.Pipeline }
+// TunnelClient_getDiagnostics_Results_Promise is a wrapper for a TunnelClient_getDiagnostics_Results promised by a client call.
+type TunnelClient_getDiagnostics_Results_Promise struct{ *capnp.Pipeline }
 
-func (p TunnelServer_getServerInfo_Results_Promise) Struct() (TunnelServer_getServerInfo_Results, error) {
+func (p TunnelClient_getDiagnostics_Results_Promise) Struct() (TunnelClient_getDiagnostics_Results, error) {
 	s, err := p.Pipeline.Struct()
-	return TunnelServer_getServerInfo_Results{s}, err
-}
-
-func (p TunnelServer_getServerInfo_Results_Promise) Result() ServerInfo_Promise {
-	return ServerInfo_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
-}
-
-const schema_db8274f9144abc7e = "x\xda\x9cUM\x88\x14W\x17\xbd\xe7\xbd\xee\xaa\x11z" +
-	"\xbe\x9e\xa2z@\x1b\xa4AF>\x95\x8c:Q\x83\x99" +
-	"H\xe6'j\xe8\xc9\x8c\xf6\x9b1\xc1\xbf\x80e\xf7\xb3" +
-	"\xa7&\xd5UMU\xb5\x89\x82\x9a\x88\x10\x08(1\x92" +
-	"E6Y\x08n\x02\xf9\xd9\x85@\x16f\x93,$d" +
-	"\x13\x02\x12\xb3\x89H@\x14\xc3` BB\x85W5" +
-	"\xd5]\x8eD\x83\xbb\xd7\xa7\xee\xbb\xf7\xdc{\xcf;\xbd" +
-	"\xf92\x1bg#\xf9My\"\xb1#\xafE;Z?" +
-	"\\~\xee\xc3k\xe7\xc8(\xb3\xe8\xf4\xd7S\xa5\x07\xe1" +
-	"\xd9\x9f\x89\xb0\xe5\x14;\x09\xf3\"\xd3\x89\xcc\xf3l/" +
-	"!\x1a\xac\xe2\xc6\xd5\x91\xdc\x97d\xfc\x1fDy\xae\x13" +
-	"m\xb9\xc2n\x83`~\xc5>'D\xab\xefN\xf6\xbb" +
-	"\xf7\xce^%\xa3\x8c^\xaa$\xf0\x00\x9f\x82\xd9RG" +
-	"\xd3\xe6*x\xea\xd0\xa5\x0f\xf2\xb7.}G\xa2\x8cl" +
-	"\xb4\xa6\xa2\xf39\x1f\xe6\xaa\x9c:\x0e\xe6\"\x10\xa2\xf2" +
-	"\x17/|6\xd9\xb8~mY\xee\x98\xde\x84\xb6h\xce" +
-	"\xa8{fU{\x93\x10\xb1[\xd6\xaa\xb7\x7fz\xf1F" +
-	"B4\xcerE\xfb\x15\x94\x8b\xf6\xbcvha\xc5\xa9" +
-	"\x9b7\x97Z\x80\xfa\xf4\x91\x16\xb7\xf0\xa96F\x88\xb6" +
-	"\x1d\x99\x90\x87\xb7\xef\xbfMF\x99?4\x8d\xef\xb5Q" +
-	"\x98\xbf\xc4E\xaek\xef\x9a#\xbaN\x14]8\xbds" +
-	"\xef\xf3k\xbeY\xcc\xa6[\xa5/\xaat\xc3\xbaJw" +
-	"l\xfb\x9d\x97\xd7^\xf8vq\x19\xeb8pF\xdf\x00" +
-	"\xf3u\x95\xc7<\xa0\x82\xef\xed\xfe\xf8\xc7r\xb1|\x7f" +
-	"\xd9<\xe2\xe9\x9d\xd2\x17`^\x8cc\xcf\xeb\xbf\xd1p" +
-	"\x14v\\W:~;W\xdf\x94\x1e\xeb\x1b\xebV\xdb" +
-	"m\x8f\xeez\xcb\x0eB\xdbm\xee\x8b\xf1\xb1\x9a\xe7\xd8" +
-	"\xf5\x135@\x14\xc0\x88\x8c\xd5\xa3D\x801x\x90\x08" +
-	"\xcc0&\x89\xc6\xec\xa6\xeb\xf92j\xd8A\xdds]" +
-	"I\xbc\x1e\x9e9j9\x96[\x97\xddB\xda\xa3\x85\x92" +
-	"\x02s\xd2?.\xfd\x8d\xbel\xdaA(\xfd\x04\x1c\xaa" +
-	"YE\xdfj\x05\xa2\xc0sD9\x10\x19\xbb\x0e\x12\x89" +
-	"\x9d\x1c\xa2\xc6`\x00%(pf\x8aHLs\x88\xfd" +
-	"\x0c\x06c\xa5\x98\xe1\xab\x93D\xa2\xc6!\x0e3D\x9e" +
-	"o7m\xf7%I\xdc\x0f\xd1O\x0c\xfd\x84h\xde\x0b" +
-	"B\xd7jI\"B\x81\x18\x0a\x843^;\xb4=7" +
-	"\xc0@OY\x04\x0c\x10\x1e7\xab\x89N8/\xdd\xd0" +
-	"\xae[\xea2Q<\xa6\x1e\xe55Db\x9cCLg" +
-	"(W\x9f\xcd\xf4\x91R\x9e9\xda\xebC\x7fC\x9eH" +
-	"YUd\xcb\xb2\x9d\xf4W\xda\xcc\x04\xe9\xaf\xf4b\x1e" +
-	"\xc7o6\x9e\xaa\x1f\xb3\xdb\xdb\xae\xc4\x1d*\x8eC]" +
-	"\x8ew\xd5\x04\xefp\x88?3\x1c\xffP\x13\xfc\x9dC" +
-	"\xfc\x95\xe1\xf8\xa0L$\xees\xcc\x82\x01\xbc\x04Nd" +
-	"\xfc\xfd\x09\xd1,8\xe6\x0a`0r\xbc\x84\x1c\x91\xb9" +
-	"\x02SDs}\x0a/)<\x9f+!Od\x1a\xd8" +
-	"@4WP\xf8:\x85k\xac\x04\x8d\xc8\\\x8b\x05\xa2" +
-	"\xb9!\x85oV\xb8\x9e/)\x85\x9b\xc3\xf0\x89\xe6\x9e" +
-	"Q\xf8v\x85\xf7\xad,\xa1\x8f\xc8\xdc\x16\xe3[\x15>" +
-	"\x0e\x86\xa8\xee\xd8\xd2\x0d\xab\x8d\xec:\x8fK?\xb0=" +
-	"7\xfd\xcd\xbd\xa0;/\xb9$p$Z\xabyE\xa5" +
-	"p\x14{VF@\x91\x10\xb5=\xcf\xd9\xf3\xb0L\x8a" +
-	"\xa1\xd5\x0c\xf0?B\x8d\x03\x03=W!(0\x8a\xf5" +
-	"_\x0fm*zn\xb5\x01\x8d\x18\xb4\xee\xde\xa6=\xaa" +
-	"\xd4-\xa7\xda\xee2\xb1\x83\x89N\xe8u\xdaTiX" +
-	"\xa1l\x00\xc4\x80\xccF\xd9\xf2\x8dV\xda\xa3\xfb\xac\xa6" +
-	"\xda`_w\x83\xeb7\x10\x89!\x0e\xb19\xb3\xc1a" +
-	"\xa5\xb2u\x1cb+CQI\xbd\xab\xa8\xe3\x96\xd3\x91" +
-	"\x8fh\xe7I\xcf\xb3)\xc3\xe4Tu\x8fyC5\xcb" +
-	"\xd7\xadV\xf0\x94\xb7geP\xec8a r\xdd\x1e" +
-	"\xfaG\x89D\x1f\x87(1\x8c\xf92\xe88!\x06z" +
-	"\xee\xb7\xec!\xf2\x7f+7\x96TI\xe6\x93'\xea\xfe" +
-	"\xe5 uZc\xe4$1c\xbd\x8e\x9e\xcb#5u" +
-	"c\xb5O\xcc\x18\xd4\xa3\xd4\x8bh,I;\x8e(\xed" +
-	"\x80*q\x0f\xe3\xa8\x01O\xebm\xb3\xb2\x12\xfc\x97\xfe" +
-	"SC\x7fr\xf7I\x9d\xa2b\xa6z\xcf\xe4] \x12" +
-	"\x05\x0e\xb1\x92!r\xbc%\x9b*\xee\xc9\x08\xe2q\xf6" +
-	"\x91\x10NM\xa4\xa8.\xab\xfc\x03\xdd\xfc\x96r\xb8\xc3" +
-	"\x1cb>\xa3=\xa9\xc0#\x1c\xc2\xc9\xb8\x87\xad|f" +
-	"\x9eC\x9c\xeb\xb9\xc7;\xef\x11\x89s\x1c\xe2}\x06]" +
-	"\xfa~JI\xef\xf8=\xcfs\xbc\xe6\xb4\xed\xca@=" +
-	"\xc2\xa5w\xa7>\xa9\xd7\xd6\x96~\xcbr\xa5\x8bp\xb7" +
-	"e;\x1d_*\xa1$O\xe8\x9f\x00\x00\x00\xff\xff\x0d" +
-	"v6\xc6"
+	return TunnelClient_getDiagnostics_Results{s}, err
+}
+
+func (p TunnelClient_getDiagnostics_Results_Promise) Result() Diagnostics_Promise {
+	return Diagnostics_Promise{Pipeline: p.Pipeline.GetPipeline(0)}
+}
+
+const schema_db8274f9144abc7e = "x\xda\xa4Y}p\x14\xe5\x19\x7f\x9e\xdd\xbb\xecE." +
+	"\\6{\x11\x92J\xcfa\xe2\x08j(\x1f\xa2\x98\xaa" +
+	"\xf9\x00\x84\xd0\x00\xd9\x04\x14\x11\x1d7w/a\xe3\xde" +
+	"\xeeuw/|T\xc40aD+\xe2\x07\xb4\xb5~" +
+	"LA\x1d\xa9\x80\x12?Fq\xd0Jgh\xa5\xb6u" +
+	"\xb4j\xa9\x95\xa9\x8a\xb6\x13\xc4q@mE\xab\xdby" +
+	"wo?r\\\x92K\xfa\x17\xc7\xfb>\xfb>\xdf\xcf" +
+	"\xf3{\x9eL\x9d\x18i`\xa6\x85_\x1f\x0b \xee\x09" +
+	"\x97XO\xcf{\xf1\xb6x\xff\x0b\xb7\x02_\xcdX\xb7" +
+	"\x1cX\x10?mn\xfc;\x00\xceX\xc8mDA\xe2" +
+	"8\x00\xe1z\xee\xe7\x80\xd6\xe6UO\x1d\xee\xf9fM" +
+	"\x0f\xf0\xe7#@\x189\x80\x19\xfb\xb9\xa7\x11P8\xcc" +
+	"\xd5\x03Z?\xbd\xa9\xf6\xc6\x977\xbd\xd0\x03|5\xfa" +
+	"O\x85\x19Jx\x9a\xeb@\xa1,B_+\x8d\xac\x06" +
+	"\xb4*.\xdd'\xde\xf2\xc9\xcb\xbd\xc1\xd7\xe4\xc8)\xfa" +
+	"\xda\xfa\x08}\xed\xf2\xf4\xeb;/\xd9\xfeZ\xef\x19\x82" +
+	"=\x14Y\x87B\x9f\xfd\xd4\xde\xc8b@\xeb\xf3m\xe3" +
+	"w\xef\xf8\xf3\xab\x9brO\xb1\xf4\xa9C\x91\x0a\x06P" +
+	"8\x1ay\x0a\xd0\xea\xee\xfa\xf7\xe3+\xb7\xae\xbe\x0b\xf8" +
+	"jv\xc0S\xebK\xebP\xd8RJ?\xb8\xbd\x94C" +
+	"a\xe6Y\x1c\x80\xb5\xff\x9b\xea1\xfb\xfa\xea\xef\x07\xb1" +
+	"\x1a\x83\x8a\x84(\xdd\x84\xb3\xa6\xa3PK\xe9fL>" +
+	"+\x81\x80\xd6\xeb\x17\x1dx\xf1\xeegn{ \xc7\xde" +
+	"Vw\xee\x18[\x93k\xc7PU\xaf\x9c1\xe5\xe6\x07" +
+	"\xb6]\xfc\x90C`\xbf\xf2\xa71\x1f\"\x84\xacmG" +
+	"^Z\x94\xbe\xe7\x97;\x83F88\xe6k\xfa\xe9\xdb" +
+	"c\xa8\x11z\x9b\xff\x93^\xfaH\xfb#A\xd5\xc2\xd1" +
+	"w)AU\x94\xaa6\xf3\xdd\x8f\x17/|z\xe5\xe3" +
+	"\x81\xb7\x0fG\xbf\xa6o7\x9cR\xbe\xdb\xb4\xe7\xd0\xae" +
+	"\xc0\xcdK\xd1?\xd0\x9b/\xfb[C\x1f=\xff\xea\xde" +
+	" \xd7\xbe\xe8o\xe9\xa3\x07\xa3\x94\xeb\x89C\xe9\xdd\x1d" +
+	"[n\xef\xcb7\x80\xcd\xfe\x83\xe8t\x14NF\xa9\xf9" +
+	"?\x8d\xfe\x0b\xd0\xaal\xc6\xf7^\x99\x16z>(\xe3" +
+	"\xd1\xb2\xe3\xf4\xb9\x93eT\xc6\x09\x9f6\x95\xa9\x9fm" +
+	"|%/.l\xc2{\xc6.@\xe1\xb1\xb1\xf4\xb5\x1d" +
+	"c)\xf1m\xf3\x16\xfe\xf5\xc2\xbd\x0f\x1f\x0a\x0awE" +
+	"\xcc\xd6X\x8cQ\xe1\x16\\w\xdf\xbd\xe1\x8f\xef\xfb}" +
+	"\xbep4Rg\xf4\xc4t\x14\xb6\xc7\xec\x97c\x972" +
+	"\x80\xd6'\xb3\x96\x9d\xfc\xfe\xe9y\x87\x03f(\xabx" +
+	"\x97\x9a\xa1z\xdf\x0f\x9flJ\xfd\xed\xb5\x02\xe1*`" +
+	"\xc5)\xa1\xac\xc2\x8e\xd6\x0a\xea\xc2\xdd\xabK\xf6\x1e\xbc" +
+	"\xe6\xfc7\x0a\x856\xa9x\x02\x85\xf56\xf1Z\x9b\xf8" +
+	"Xm\xdfO\xfa\xb7\xbc\xf1VP\x85\xa3\x15\x1f\xda\x06" +
+	"\xa9\xa0*<\xf3\xed\x93\xbf\xb9\xfc\xea\x1b\xde\x09\xc8\xc4" +
+	"\x0b\xc7\xa9L\xcc\xc7R\xd5\xad\xef\\\xf9^\xe0&," +
+	"\xd8\xa1\x12V\xff9\xf7\x92\xdd{>\x0c\xdc\x9c\xaex" +
+	"\x91\xde,\xba\xfa\xba\xae\xd2\xf5\xc7\x8e\x05\xd9\xf5W\xd8" +
+	"\xf6\xff\xd6f7\xf3\xc6F\xb2b\xd6\xb2\xe3g\x84\xff" +
+	"\x04\xa1\x0e\x85Z\xc1\x8ee\x81c\x84{\xe24\xfc\x1f" +
+	"=\xfe\xd0\x9do\xfe\xe5\xab\xe3\x85t]\x1b\x9f\x8e\xc2" +
+	"\xed\x94L\xd8\x14\xa7\xba\x9e\xfb\xc7#\x9f\xee\xde\xbc\xf5" +
+	"D!\xe2\x0f\xe2\xebP\xf8\xd2&>i\x13\xf7N\xfe" +
+	"^\xd9\xaak\xf6\x9e\x0cf\x8aXi\xfb\x96TR\x82" +
+	"\xbbn\x99\xb3\xf8\xb2\x89\x07O\x05U9\\i\xa7\xd2" +
+	"\xd1J\xaa\xca\xcaY'\xe6\x9dw\xd7\xefN\x15\x8c\xcc" +
+	"o+/@\xa1\xecl\xfa\xb3\xf4l\x9a\x9a\x9f]\xf5" +
+	"\xf0[\xd5\xb1\xea/\x0a\xe5q\xe3\xb8.\x14\x96\x8e\xb3" +
+	"E\x18\xb7\x15\x01\xadm\xc9\xf7\xfb/<\xbf\xf7t\x90" +
+	"ye\xd5\x9b\x94\xf9\xe4*\xca\xfc\xd7\xbbZ??\xba" +
+	"\x7f\xdc\xd7A\x82f\x87\xe0z\x9b\xe0g\xdc\x83\xc7n" +
+	"\xfd\xc7\x0d\xff\x0d\x12\xf4TU\xd3Bt\xbfMp\xf3" +
+	"g\xf7\xcf\xdf\xbab\xcfw\x01'\xee\xafb\x18\x98j" +
+	"\x99YU%\x8a\x9e\x09'\x7f\xe0\xfeLNIJ\x19" +
+	"5S\xd7F:e\xc3\xd4%S\xd6\xd4\xb9\xba\xae\xe9" +
+	"\xb3\xb5\x14\x81VDq<2\x00|c\x13\x00\"\x7f" +
+	"\xd9N\x00d\xf8\xcb:\x00\x90\xe5gv\x01`\x88\x9f" +
+	"\xb6\x1c\x00\xc3|\xad\x0e\xb0!\xab\xde\xa4j\xabU+" +
+	"\x95\xcd(rR2\x91\xcc\xd6T\x95$MN\xd6T" +
+	"K\x97L\xd2\"\xa7e\xe0L\x92\xb2\xb2\xaa\x945W" +
+	"i:\xc4\xe4u$ei\xddDW4)\x05,I" +
+	"Y\xddD7dM]\x02\x09M[\xac\xa4\x86\x12}" +
+	"\x89\xfd\xff\xd9\x8aLTs\x8aA\xcc%R\xa7Q\xd3" +
+	"\x9a\x90t)m\x88!6\x04\x10B\x00\xbe\xec\x02\x00" +
+	"1\xc2\xa2X\xc3`\xcc\x94:\x0d\x1c\x0b\xd8\xca\"\x96" +
+	"\xfbI\x0aH\x0f=f\xa1A\x99\xe9$ETS\xe6" +
+	"$\xc5\xa06\x8ax\\&/\x00\x10'\xb1(^\xcc" +
+	" \x8f\x18Gz8\xad\x0e@\xbc\x88Eq\x16\xe3>" +
+	"\xde\x9c\x02\x00\x8c\x02\x83Q\xc0z\x83$ubb\x19" +
+	"0X\x16\xe0_2\x8c\xb2\x9d\xc4\x9c#K\x9d\xaaf" +
+	"\x98r\xd2\xa8i#\x09#\xab\x98\x03\x94\xae\xcb)\x1d" +
+	"g\xb0^'\xf4\x1a\xcb\xfd\xde\x03\x88\xe5C\xeb;w" +
+	"\x8dl\x98\xb2\xda\xe9\xf0\xado\xd5\x149\xb9\x96\xaa\x1c" +
+	"\xb5\xc3bB\x9d\x1d\x16\x95\xcb\xed\xb0\xe0\x9b\x00\xea\xe5" +
+	"NU\xd3\x89\x95\x92\x8d$\xf5<\xb0IsC\x87\xa4" +
+	"Hj\x92x\x8c\xb8\xc1\x14k'z7\xd1\xa7\xe8v" +
+	"4\x12}\x91\x94&)\xe7\xa6\xa6\x95:\x14\x0d1\xea" +
+	")7\xb7\x03@\x9c\xc3\xa2\xd8\x1a\xb0\xf5B\xea\x80\x16" +
+	"\x16\xc5e\x0c\xf2\x0c\x13\xb7\xc5\\\xda\x04 \xb6\xb2(" +
+	"\xae`\xd0J\xe6\x9c\x07\xd4{X\xee#\x8a\x9c5V" +
+	"i\x86\xa9Ji\x12p\xd0\x06-C\x13\x83R{\x9d" +
+	"!\xcfv\xec`*\xd5;\xce\xa26+g\xc3\x00^" +
+	"gD\xb7y\xf2?n\x03\x86\x979\xf4\xcb\x03\xba\x1d" +
+	"\x85\xbf\xbe\x03\x18~)\x87\x8c\x07\x8d\xd0\xad\xd2|s" +
+	"\x130\xfc\x15\x1c\xb2^\xb1G\x17\xf1\xf0\xd3\xd6\x01\xc3" +
+	"O\xe6,\x9d\xd8nH\x02\x9a\x0dh\x19\xc4l\xd1:" +
+	"[\x08p\xddDi\xc0\x0d\xb9ti@\xcb\x8d%\xa8" +
+	"w\xa2\xa9\x01[qH\xed\x9c\xc8K\xd8\xc4T\xb9\xb8" +
+	"\xe7\x97\xf5\xd4\xdakX\x14{\x03~\xe9\xa9\x06\x10o" +
+	"fQ\xdc\x1c\xf0\xcb\xa66\x00\xb1\x97E\xf1n\x06\x91" +
+	"\x8d#\x0b\xc0o\xe9\x02\x10\xefdQ\xfc\x05\x83|\x88" +
+	"\x8dc\x08\x80\xdf\xbe\x1c@\xdc\xc6\xa2\xf8+\x067\xe4" +
+	"j\x83\xeb\x1cV3\xdc\x9fV\xa7v\xb5}\x09\xe8]" +
+	"[\x8e\xfe\xa6\x0c1MmNa\x090Xb\x93\xea" +
+	"Z\xd6\x94U`\x89\xff\xfd\xb0\x99\x97\x17\xa0\xce\xa1_" +
+	"n\x02\x85`y\xa1B@\xf5\x9d\xca\xa2\xd8\xc2\xa0\xa5" +
+	"\xe9r\xa7\xac\xce&\xc0\xea~\xea{\xc1\x87\x81\xfa\xe4" +
+	"\xf5\xbf\xbc\xfa4\x9c\x94)\xa2\x10\x93\xe4\xf2\xa7\x8d\x18" +
+	"YN1\x8dQ\xeb\xd8VO\x06-/5~y\xf1" +
+	"\xa4\xf6\xe0\xcc\x08\xa5N\xeaD2I \xeb\xd9\xf4\x80" +
+	"\xac_^(\xebiq\x9f\xcf\xa2\xb8$\x10]b[" +
+	".\xeb\x95A\xac\x1d\xa3\x96\xf6|_\xc0\xf4\xf4j$" +
+	"\xa2g\xd5\x81F\xab\xa9\xb7\xab\x96QtEw\xb3\xd5" +
+	"\xb4\xdd\xa5\x98\x06\xc0(\xbeu\x98\xc2\xe0}@2\xfc" +
+	"\xf4\x19*\xc7\x9d\xfa\x9b\xb0O\x9d\x02\xe6>(\xd12" +
+	"{\xa3c[\xcf\x0b2\xf5B\x8aE1\x13\xf0B\x9a" +
+	"zAq\x12\xdf\xcd\xf1`\xde\x17j\x88E;\xc6\x89" +
+	"\x95T#\xa0\x89a`0<\xea\x1c\xaei\x95b\xba" +
+	"TD\xa4\x0d\xdb_\x86\xce\xeb\x115\x95\x02\x0d\xb91" +
+	"k\xae\xa2\xdd+iC5\xb0!Z@\xe4\x89\x00b" +
+	"\x83S`<\x91\x9b\xa7\x07\xf4pE^\xd8\xe1\xeb\xc1" +
+	"\xddD\xd6\xbaR%HZ\x92\x15\xcf\xf69e\x1a\x81" +
+	"\xfb\x91OS\xb4\x89\x15\xd90\xbd\xf2A\x03\x9a-\xbe" +
+	"|x\xf3\xe1\xf0\xa0,\x08^\x17g\x12\xf4\x1f\xbb%" +
+	"Mr9\x09\xa5\xb8\x00\xa0=\x82,\xb6\xc7\xd17\x8d" +
+	"\xc0c\x13@{\x94\x9e\x8fG\xdf:B%V\x03\xb4" +
+	"\x97\xd3\xf3s\xd0\x8b[\xa1\x0a\x9f\x00h?\x87\x1eO" +
+	"B\xbf=\x09\xe7\xd9\xcf\xd7\xd0\xf3\xa9\xf4<\x1c\x8ac" +
+	"\x18@\xa8\xc5\x0b\x00\xda'\xd1\xf39\xf4\xbc\x84\x89c" +
+	"\x09\x80\xd0\x88]\x00\xed\x0d\xf4\xbc\x85\x9es\xe18\xd2" +
+	"\x09\xa6\x19u\x80\xf6\xf9\xf4|\x09=\x8f\x8c\x8fc\x04" +
+	"@\x10\xed\xf3Vz\xbe\x82\x9e\x97\x96\xc4\xb1\x14@\xb8" +
+	"\xd6\xe6\xbb\x8c\x9e\x9bH\xa1\x8d]\x09\x06\xa4\xd2P\xdd" +
+	"\x92\xe4\xc0\x1d:>j\xd5b\x14\xdda\xcc_\x8b\x00" +
+	"b\x0c\xd0\xcah\x9a\xb2h`\xf4\x0e\x03\xa1\x07i\xba" +
+	"N8\xb5h\x90HJJs\xc6\x93D6\x1a\xb3\xa6" +
+	"\x96\xcd@\"E\xf3\x19\x11\x18D:\x8a\x11\xc9\xcc\xea" +
+	"\xc4\x00\x80\x11\xd7d\x7f\"\xb0!O7Q\xbc\x18\xf4" +
+	">f\xf2?Nd\xea\x96H\x9dy\x98\x9e\x96\xb5\x1a" +
+	"\x16\xc5\xa9\x81\xa4\xaa\x9d\xee\xf7\xf7\x01\xe5*\xd1-)" +
+	"YrF\xaa\x14\x18\\\xe6\xe7j\x82#\xae\x1b\xc41" +
+	"\x1a\xbe\xc5\x0c\x15]9,q9S\xb0\xbcXz\xee" +
+	"A\xb0_\xc4rwP\xcd\xab1#j\xc7.\x88\x18" +
+	"v\xc4\xc8\x07\xd5\xa3\x1cirE\xb9ha;\x89\xe9" +
+	"\xfcjVWj\xb4\x0fr\xc1\xaf\x8b\x1d\x1e]\xa43" +
+	"J\xb6m\xc4\x88\x155\x87y\x8b\x86\xa2g\x09\x87\x0b" +
+	"\x8d\x8es\xedY\xc2]\x8b\xa1\xbb\xd4\xe0OR\xdc\xdf" +
+	"Og\x09w\xcd\x83\xee\xee\x86?\xaa\x03\xc3\xbfMg" +
+	"\x09w\x9d\x88\xeer\x90?\xbc\x11\x18\xfe \x9d%\xdc" +
+	"m\x1f\xba\x8b\x04\xfe\xb9;\x80\xe1\xfb8\x0cy[P" +
+	"t\xb7\x10\xfcc;\x81\xe1wp\x18\xf6\xd6\x88\xe8\xae" +
+	"\xa6\xf8\xed]\xc0\xf0[8,\xf1\xb6\x1a\xe8n\xde\xf8" +
+	"\x1e:\xd7\xac\xe5\x90\xf3\x166\xe8\xae0\xf94\xfd\x8e" +
+	"p\x96\xdb\xa1\xa1\xdeQ\xdf\x19Sl\x1b@\xc2\xb6u" +
+	"\x03zD\xb9\"f@\x03Z.\x0eC\xb7\xbb\x03\x04" +
+	")\xdd\xb1\x92s\xdet#\x1cb9&n\xdb\x02\x8e" +
+	"(t6rq\xb4K0\xccdd\xefO\xe6$\x88" +
+	")\xc9J^\"O\x1c\xa6\x92\x0c\xe8\xc4\x85\x0bIh" +
+	"\xf0B\xe2\x94\x90z\xa7\x11\x16SB\x9a\x02{\x89\xd1" +
+	"!\x94\x11\x8d .\x98\x1fnJZ\x10\xa8l\x05\xd1" +
+	"T\x01\xc88Z\xd8W\xec\xd2d\x90\xeaY\xc0\xfd\x0e" +
+	"\x9f\x18\x0d\xcf\xbc\xb9\xb8+0\x02\xbb\xcan\xba\x17@" +
+	"\xdc\xcc\xa2\xf8h\x00\x9c\xed\xa0\x8ey\x90Eq\x97\x8f" +
+	"\x99\x1f\xdb\x09 \xeebQ|\x96\x02\x0ft\xe6\xe2>" +
+	"\x1d@\xdc\xc7\xa2x\x80AK\xd1r\xa00\xb6(\x08" +
+	"\x9f\x8dl&\xa3\xe9&\xc1\xd4UN7=\x03F\xe7" +
+	"c\x04k\x15\x91t\xb3\x83Hh6\xab&\xd1\xbb%" +
+	"NYh`\x04\x18\x8c\x00Zii\xcd|J\x00\x89" +
+	"\x0e\"\x99\xfe\xf9\xb0k\xb33Z\xdc9\x9em\x9e\x9b" +
+	"\xe8+\xe2\xd9f?=|\x96E\xf1\x95\x80m^\xa2" +
+	"\xd1q\x80E\xf1\x88o\x9b\xb7\xef\x00\x10\x8f\xb0(~" +
+	"\x14\xb0\xcd\x07t\xc8x\x9fE\xf1\x04Edh#2" +
+	"\xbe\x9f\x9a\xfb\x04\x8b\xe2W\x14\x8e\xb16\x1c\xe3\xbf\xa4" +
+	"\x8e\xf9\x82\xc5\xf6rd\x90#\xba\xee\x1a\x82\xcb\xea>" +
+	"\x14V\xb4\xce\x16Y-\x88E2DOK*Q\xd1" +
+	"\xbcJ\x92\x95\xacN\x00<\x08Cr\xdbT@\x821" +
+	"\xff/a9d\xa5\x13S_\xdb\xb8\x92V\xaav:" +
+	"\xbf\xa5\xd0\xb7\xa7\xfd\xe5\x1cbBL\x92\x95\x00\xd6\xf2" +
+	"v\xe7\xc5\x0e\xd6\x05\x90\x90\xbbM\x0bD\xfdt?\xea" +
+	"\x13\x0a%\xfa\xbf\xe0~\x01\x064\xd7\xa3,\x8a\xe3\x87" +
+	"N\xeb\xd1\xad\x07\x87\x1a/\x8a\xc8cn\xa4\x93\xbd;" +
+	"\x9f\xffo\x00\xbe\xcd\x14\x09"
 
 func init() {
 	schemas.Register(schema_db8274f9144abc7e,
+		0x80b8e91487ba47b2,
+		0x8178fb81c9af6888,
+		0x81b885be602d6b8b,
+		0x84beeb7e51b03712,
 		0x84cb9536a2cf6d3c,
+		0x85c8cea1ab1894f3,
+		0x8e778f66a7f66a76,
+		0x993fb1b00b1afbb9,
+		0x9b87b390babc2ccf,
+		0x9d34949b7c2e333e,
+		0xa29a916d4ebdd894,
+		0xa353a3556df74984,
+		0xa766b24d4fe5da35,
+		0xa8c5ac85fe6cf240,
+		0xadc8b7e40450e9f5,
+		0xb1898d62ab6dc5ec,
 		0xb70431c0dc014915,
 		0xc082ef6e0d42ed1d,
+		0xc59ead2bd74d4787,
 		0xc793e50592935b4a,
+		0xc947f91ef15838eb,
 		0xcbd96442ae3bb01a,
+		0xd12757c1ad0677ab,
+		0xd4d18de97bb12de3,
+		0xd65e563cbfaefdb3,
 		0xdc3ed6801961e502,
+		0xe2acab3645e66e05,
 		0xe3e37d096a5b564e,
 		0xea58385c65416035,
+		0xeaf8d3d28c9deaa4,
+		0xec8f88abedd8cc20,
+		0xf1ad57680d1b2984,
 		0xf2c122394f447e8e,
 		0xf2c68e2547ec3866,
-		0xf41a0f001ad49e46)
+		0xf41a0f001ad49e46,
+		0xf984272be9e06394,
+		0xfa17b9ddf350a8a9,
+		0xfc5edf80e39c0796,
+		0xfeac5c8f4899ef7c)
 }